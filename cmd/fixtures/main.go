@@ -93,8 +93,13 @@ func run(dbPath string) error {
 	monthList := generateMonths(defaultMonths)
 	start := time.Now()
 
+	packages := buildPackageList()
+	if err := insertPackageNames(ctx, db, packages); err != nil {
+		return fmt.Errorf("generate package names: %w", err)
+	}
+
 	tables := []fixtureTable{
-		{"packages", "INSERT INTO package (name, month, count) VALUES (?, ?, ?)", buildPackageList(), 20000},
+		{"packages", "INSERT INTO package (name_id, month, count) VALUES ((SELECT id FROM package_name WHERE name = ?), ?, ?)", packages, 20000},
 		{"countries", "INSERT INTO country (code, month, count) VALUES (?, ?, ?)", countries, 6000},
 		{"mirrors", "INSERT INTO mirror (url, month, count) VALUES (?, ?, ?)", mirrors, 5000},
 		{"system architectures", "INSERT INTO system_architecture (name, month, count) VALUES (?, ?, ?)", systemArchitectures, 20000},
@@ -135,6 +140,28 @@ func realisticCount(rng *rand.Rand, entity entityConfig, maxSamples, monthIndex
 	return max(1, int(pop*float64(maxSamples)))
 }
 
+func insertPackageNames(ctx context.Context, db *sql.DB, packages []entityConfig) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	stmt, err := tx.PrepareContext(ctx, "INSERT INTO package_name (name) VALUES (?)")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = stmt.Close() }()
+
+	for _, pkg := range packages {
+		if _, err := stmt.ExecContext(ctx, pkg.name); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
 func generateFixtures(ctx context.Context, db *sql.DB, rng *rand.Rand, months []int, table fixtureTable) error {
 	tx, err := db.BeginTx(ctx, nil)
 	if err != nil {
@@ -29,6 +29,9 @@ const (
 	maxDisplayItems               = 10
 	maxCorrelationDisplay         = 5
 	maxCorrelationPackages        = 8
+	// packageNames selects the package counts by name, to be aliased where
+	// it is used as a table.
+	packageNames = `(SELECT name, month, count FROM package JOIN package_name ON package_name.id = package.name_id)`
 )
 
 type (
@@ -204,7 +207,7 @@ func detect(ctx context.Context, db *sql.DB, targetMonth, baselineStart, baselin
 		return nil, fmt.Errorf("count correlations: %w", err)
 	}
 
-	newPackageSpikes, err := detectNewSpikes(ctx, db, "package", "name", targetMonth, baselineStart)
+	newPackageSpikes, err := detectNewSpikes(ctx, db, packageNames, "name", targetMonth, baselineStart)
 	if err != nil {
 		return nil, fmt.Errorf("new package spikes: %w", err)
 	}
@@ -246,24 +249,27 @@ func detect(ctx context.Context, db *sql.DB, targetMonth, baselineStart, baselin
 }
 
 func detectCountCorrelations(ctx context.Context, db *sql.DB, targetMonth, previousMonth int) ([]CountCorrelation, error) {
-	query := `
+	dialect := database.DialectOf(db)
+	//nolint:gosec // the aggregate is a hardcoded expression, not user input
+	query := fmt.Sprintf(`
 		WITH deltas AS (
 			SELECT
-				curr.name,
+				n.name,
 				curr.count - COALESCE(prev.count, 0) as delta
 			FROM package curr
-			LEFT JOIN package prev ON curr.name = prev.name AND prev.month = ?
+			JOIN package_name n ON n.id = curr.name_id
+			LEFT JOIN package prev ON curr.name_id = prev.name_id AND prev.month = ?
 			WHERE curr.month = ?
 			  AND curr.count - COALESCE(prev.count, 0) >= ?
 		)
-		SELECT delta, GROUP_CONCAT(name) as packages, COUNT(*) as num_packages
+		SELECT delta, %s as packages, COUNT(*) as num_packages
 		FROM deltas
 		GROUP BY delta
 		HAVING COUNT(*) >= 3
 		ORDER BY delta DESC
-		LIMIT 50`
+		LIMIT 50`, dialect.GroupConcat("name"))
 
-	rows, err := db.QueryContext(ctx, query, previousMonth, targetMonth, minCorrelationCount)
+	rows, err := db.QueryContext(ctx, dialect.Rebind(query), previousMonth, targetMonth, minCorrelationCount)
 	if err != nil {
 		return nil, err
 	}
@@ -304,7 +310,7 @@ func detectNewSpikes(ctx context.Context, db *sql.DB, table, idColumn string, ta
 		ORDER BY t.count DESC
 		LIMIT 50`, idColumn, table, table, idColumn, idColumn)
 
-	rows, err := db.QueryContext(ctx, query, targetMonth, minCorrelationCount, baselineStart, targetMonth)
+	rows, err := db.QueryContext(ctx, database.DialectOf(db).Rebind(query), targetMonth, minCorrelationCount, baselineStart, targetMonth)
 	if err != nil {
 		return nil, err
 	}
@@ -352,7 +358,7 @@ func detectGrowthAnomalies(ctx context.Context, db *sql.DB, table, idColumn stri
 		idColumn,
 		idColumn, idColumn)
 
-	rows, err := db.QueryContext(ctx, query, baselineStart, baselineEnd, targetMonth, minBaselineCount, growthThreshold)
+	rows, err := db.QueryContext(ctx, database.DialectOf(db).Rebind(query), baselineStart, baselineEnd, targetMonth, minBaselineCount, growthThreshold)
 	if err != nil {
 		return nil, err
 	}
@@ -423,9 +429,9 @@ func fetchExpectedPackageCounts(ctx context.Context, db *sql.DB, targetMonth int
 		args = append(args, pkg)
 	}
 
-	query := fmt.Sprintf("SELECT name, count FROM package WHERE month = ? AND name IN (%s)", strings.Join(placeholders, ",")) //nolint:gosec // placeholders are "?" literals, not user input
+	query := fmt.Sprintf("SELECT name, count FROM "+packageNames+" AS p WHERE month = ? AND name IN (%s)", strings.Join(placeholders, ",")) //nolint:gosec // placeholders are "?" literals, not user input
 
-	rows, err := db.QueryContext(ctx, query, args...)
+	rows, err := db.QueryContext(ctx, database.DialectOf(db).Rebind(query), args...)
 	if err != nil {
 		return nil, err
 	}
@@ -484,12 +490,12 @@ func findPackagesAboveBaseThreshold(ctx context.Context, db *sql.DB, targetMonth
 	//nolint:gosec // placeholders are "?" literals, not user input
 	query := fmt.Sprintf(`
 		SELECT name, count
-		FROM package
+		FROM `+packageNames+` AS p
 		WHERE month = ? AND count > ? AND name NOT IN (%s)
 		ORDER BY count DESC
 		LIMIT 50`, strings.Join(placeholders, ","))
 
-	rows, err := db.QueryContext(ctx, query, args...)
+	rows, err := db.QueryContext(ctx, database.DialectOf(db).Rebind(query), args...)
 	if err != nil {
 		return nil, err
 	}
@@ -112,11 +112,11 @@ func TestAnomalyDetection_Integration(t *testing.T) {
 		('http://m1', 202501, 1000)`) // 900% growth
 
 	// Package spikes
-	_, _ = db.Exec(`INSERT INTO package (name, month, count) VALUES ('new-spike', 202501, 2000)`)
+	_, _ = db.Exec(`INSERT INTO package_name (id, name) VALUES (1, 'new-spike'); INSERT INTO package (name_id, month, count) VALUES (1, 202501, 2000)`)
 
 	// Base package correlation
-	_, _ = db.Exec(`INSERT INTO package (name, month, count) VALUES
-		('pkgstats', 202501, 5000), ('pacman', 202501, 5000), ('linux', 202501, 5000)`)
+	_, _ = db.Exec(`INSERT INTO package_name (id, name) VALUES (2, 'pkgstats'), (3, 'pacman'), (4, 'linux'); INSERT INTO package (name_id, month, count) VALUES
+		(2, 202501, 5000), (3, 202501, 5000), (4, 202501, 5000)`)
 
 	target := 202501
 	baselineStart := 202407
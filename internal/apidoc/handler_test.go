@@ -55,9 +55,15 @@ func TestHandleDocJSON(t *testing.T) {
 		"/api/packages",
 		"/api/packages/{name}",
 		"/api/packages/{name}/series",
+		"/api/packages/new",
+		"/api/packages/gone",
+		"/api/packages/suggest",
+		"/api/packages/{name}/forecast",
+		"/api/packages/diff",
 		"/api/countries",
 		"/api/countries/{code}",
 		"/api/countries/{code}/series",
+		"/api/countries/diff",
 		"/api/mirrors",
 		"/api/mirrors/{url}",
 		"/api/mirrors/{url}/series",
@@ -102,6 +108,11 @@ func TestHandleDocJSONProduction(t *testing.T) {
 		"/api/packages",
 		"/api/packages/{name}",
 		"/api/packages/{name}/series",
+		"/api/packages/new",
+		"/api/packages/gone",
+		"/api/packages/suggest",
+		"/api/packages/{name}/forecast",
+		"/api/packages/diff",
 	}
 	for _, p := range publicPaths {
 		if _, found := paths[p]; !found {
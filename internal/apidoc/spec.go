@@ -31,6 +31,7 @@ type PathItem struct {
 type Operation struct {
 	Tags        []string            `json:"tags,omitempty"`
 	Summary     string              `json:"summary,omitempty"`
+	Description string              `json:"description,omitempty"`
 	OperationID string              `json:"operationId,omitempty"`
 	Parameters  []Parameter         `json:"parameters,omitempty"`
 	Responses   map[string]Response `json:"responses"`
@@ -62,6 +63,8 @@ type Schema struct {
 	Minimum     *int               `json:"minimum,omitempty"`
 	Maximum     *int               `json:"maximum,omitempty"`
 	MaxLength   *int               `json:"maxLength,omitempty"`
+	Pattern     string             `json:"pattern,omitempty"`
+	Enum        []string           `json:"enum,omitempty"`
 	Nullable    bool               `json:"nullable,omitempty"`
 	Required    []string           `json:"required,omitempty"`
 	Properties  map[string]*Schema `json:"properties,omitempty"`
@@ -169,6 +172,24 @@ var (
 		Description: "End month in Ym format (e.g. 202501). Defaults to last month.",
 		Schema:      &Schema{Type: "integer"},
 	}
+	paramMonth = Parameter{
+		Name:        "month",
+		In:          "query",
+		Description: "Month in Ym format (e.g. 202501). Defaults to last month.",
+		Schema:      &Schema{Type: "integer"},
+	}
+	paramFrom = Parameter{
+		Name:        "from",
+		In:          "query",
+		Description: "Month to compare from in Ym format (e.g. 202412). Defaults to the month before to.",
+		Schema:      &Schema{Type: "integer"},
+	}
+	paramTo = Parameter{
+		Name:        "to",
+		In:          "query",
+		Description: "Month to compare to in Ym format (e.g. 202501). Defaults to last month. Must differ from from.",
+		Schema:      &Schema{Type: "integer"},
+	}
 	paramLimit = Parameter{
 		Name:        "limit",
 		In:          "query",
@@ -181,10 +202,52 @@ var (
 		Description: "Number of results to skip.",
 		Schema:      &Schema{Type: "integer", Default: 0, Minimum: new(0), Maximum: new(web.MaxOffset)},
 	}
+	paramSort = Parameter{
+		Name:        "sort",
+		In:          "query",
+		Description: "Sort field. growth compares the share of samples in the first and last month of the range, or in the previous and selected month for a single month.",
+		Schema:      &Schema{Type: "string", Default: web.SortPopularity, Enum: []string{web.SortPopularity, web.SortName, web.SortGrowth}},
+	}
+	paramOrder = Parameter{
+		Name:        "order",
+		In:          "query",
+		Description: "Sort direction. Defaults to asc when sorting by name and desc otherwise.",
+		Schema:      &Schema{Type: "string", Enum: []string{web.OrderAsc, web.OrderDesc}},
+	}
+	paramMinCount = Parameter{
+		Name:        "minCount",
+		In:          "query",
+		Description: "Minimum count for a record to be listed. Defaults to 16 for packages and no minimum otherwise.",
+		Schema:      &Schema{Type: "integer", Minimum: new(web.MinCountFloor)},
+	}
+	paramMatch = Parameter{
+		Name:        "match",
+		In:          "query",
+		Description: "How query is matched against names. glob supports * and ? wildcards; fuzzy tolerates typos by comparing trigrams. Defaults to prefix for packages and contains otherwise.",
+		Schema:      &Schema{Type: "string", Enum: []string{web.MatchPrefix, web.MatchContains, web.MatchGlob, web.MatchFuzzy}},
+	}
+	paramCursor = Parameter{
+		Name:        "cursor",
+		In:          "query",
+		Description: "Opaque cursor from the next field of a previous response. Continues after the last returned record; cannot be combined with offset or growth ordering.",
+		Schema:      &Schema{Type: "string"},
+	}
+	paramGranularity = Parameter{
+		Name:        "granularity",
+		In:          "query",
+		Description: "Length of the period each point covers. Popularity is recomputed from the counts and samples of all months in the period; startMonth and endMonth of each point are clipped to the requested range.",
+		Schema:      &Schema{Type: "string", Default: web.GranularityMonth, Enum: []string{web.GranularityMonth, web.GranularityQuarter, web.GranularityYear}},
+	}
+	paramSmooth = Parameter{
+		Name:        "smooth",
+		In:          "query",
+		Description: "Smooth popularity over the series: maN is a trailing moving average over N periods, skipping periods without data, emaN exponential smoothing with a span of N periods (alpha = 2/(N+1)). Counts and samples stay unsmoothed, and the response's smoothing field names the applied method.",
+		Schema:      &Schema{Type: "string", Pattern: "^e?ma([2-9]|1[0-9]|2[0-4])$"},
+	}
 	paramQuery = Parameter{
 		Name:        "query",
 		In:          "query",
-		Description: "Filter by name.",
+		Description: "Filter by name. The wildcards * and ? are only accepted with match=glob.",
 		Schema:      &Schema{Type: "string", MaxLength: new(submit.MaxPackageLen)},
 	}
 )
@@ -202,12 +265,14 @@ func popularityItemSchema(identifierField, identifierDescription string, package
 		Type:     "object",
 		Required: []string{identifierField, "samples", "count", "popularity", "startMonth", "endMonth"},
 		Properties: map[string]*Schema{
-			identifierField: {Type: "string", Description: identifierDescription},
-			"samples":       {Type: "integer", Description: samplesDescription},
-			"count":         {Type: "integer", Description: countDescription},
-			"popularity":    {Type: "number", Format: "float", Description: "Percentage calculated as count / samples × 100, rounded to two decimal places."},
-			"startMonth":    {Type: "integer", Description: "First month included, in YYYYMM format."},
-			"endMonth":      {Type: "integer", Description: "Last month included, in YYYYMM format."},
+			identifierField:  {Type: "string", Description: identifierDescription},
+			"samples":        {Type: "integer", Description: samplesDescription},
+			"count":          {Type: "integer", Description: countDescription},
+			"popularity":     {Type: "number", Format: "float", Description: "Percentage calculated as count / samples × 100, rounded to two decimal places."},
+			"popularityLow":  {Type: "number", Format: "float", Description: "Lower bound of the 95% Wilson score interval of popularity. Present on items, list entries, new report entries and unsmoothed series points."},
+			"popularityHigh": {Type: "number", Format: "float", Description: "Upper bound of the 95% Wilson score interval of popularity. Present on items, list entries, new report entries and unsmoothed series points."},
+			"startMonth":     {Type: "integer", Description: "First month included, in YYYYMM format."},
+			"endMonth":       {Type: "integer", Description: "Last month included, in YYYYMM format."},
 		},
 	}
 }
@@ -223,11 +288,133 @@ func popularityListSchema(collectionField, itemSchemaRef string) *Schema {
 				Description: "Matching popularity records.",
 				Items:       &Schema{Ref: itemSchemaRef},
 			},
+			"total":     {Type: "integer", Description: "Total number of matching records."},
+			"count":     {Type: "integer", Description: "Number of records returned."},
+			"limit":     {Type: "integer", Description: "Maximum number of records requested."},
+			"offset":    {Type: "integer", Description: "Number of records skipped."},
+			"query":     {Type: "string", Nullable: true, Description: "Applied name filter, or null when not supplied."},
+			"next":      {Type: "string", Description: "Cursor for the next page, present when more records may follow."},
+			"smoothing": {Type: "string", Description: "Smoothing applied to popularity, present only on smoothed series."},
+		},
+	}
+}
+
+//nolint:goconst
+func gonePackageSchema() *Schema {
+	return &Schema{
+		Type:     "object",
+		Required: []string{"name", "lastMonth", "peakMonth", "peakPopularity"},
+		Properties: map[string]*Schema{
+			"name":           {Type: "string", Description: "Package name"},
+			"lastMonth":      {Type: "integer", Description: "Last month the package was listed, in YYYYMM format."},
+			"peakMonth":      {Type: "integer", Description: "Month of the highest popularity, in YYYYMM format."},
+			"peakPopularity": {Type: "number", Format: "float", Description: "Highest popularity the package reached."},
+		},
+	}
+}
+
+//nolint:goconst
+func gonePackageListSchema() *Schema {
+	return &Schema{
+		Type:     "object",
+		Required: []string{"gonePackages", "total", "count", "limit", "offset"},
+		Properties: map[string]*Schema{
+			"gonePackages": {
+				Type:        "array",
+				Description: "Packages that dropped out in the selected month.",
+				Items:       &Schema{Ref: "#/components/schemas/GonePackage"},
+			},
+			"total":  {Type: "integer", Description: "Total number of matching records."},
+			"count":  {Type: "integer", Description: "Number of records returned."},
+			"limit":  {Type: "integer", Description: "Maximum number of records requested."},
+			"offset": {Type: "integer", Description: "Number of records skipped."},
+		},
+	}
+}
+
+//nolint:goconst
+func packageSuggestionsSchema() *Schema {
+	return &Schema{
+		Type:     "object",
+		Required: []string{"query", "suggestions"},
+		Properties: map[string]*Schema{
+			"query": {Type: "string", Description: "Requested name prefix."},
+			"suggestions": {
+				Type:        "array",
+				Description: "Package names starting with the prefix, most popular in the last month first.",
+				Items:       &Schema{Type: "string"},
+			},
+		},
+	}
+}
+
+//nolint:goconst
+func packageForecastSchema() *Schema {
+	return &Schema{
+		Type:     "object",
+		Required: []string{"name", "startMonth", "endMonth", "forecasts"},
+		Properties: map[string]*Schema{
+			"name":       {Type: "string", Description: "Package name"},
+			"startMonth": {Type: "integer", Description: "First month the trend is fitted to, in YYYYMM format."},
+			"endMonth":   {Type: "integer", Description: "Last month the trend is fitted to, in YYYYMM format."},
+			"forecasts": {
+				Type:        "array",
+				Description: "Projected months following endMonth, from a linear trend fitted to the logit of monthly popularity. The projection extrapolates past behavior and does not anticipate replacements or removals. Empty when the package was reported in fewer than three months of the fitted range.",
+				Items:       &Schema{Ref: "#/components/schemas/ForecastPoint"},
+			},
+		},
+	}
+}
+
+//nolint:goconst
+func forecastPointSchema() *Schema {
+	return &Schema{
+		Type:     "object",
+		Required: []string{"month", "popularity", "popularityLow", "popularityHigh"},
+		Properties: map[string]*Schema{
+			"month":          {Type: "integer", Description: "Projected month, in YYYYMM format."},
+			"popularity":     {Type: "number", Format: "float", Description: "Projected popularity."},
+			"popularityLow":  {Type: "number", Format: "float", Description: "Lower bound of the 95% prediction interval."},
+			"popularityHigh": {Type: "number", Format: "float", Description: "Upper bound of the 95% prediction interval."},
+		},
+	}
+}
+
+//nolint:goconst
+func diffEntrySchema() *Schema {
+	return &Schema{
+		Type:     "object",
+		Required: []string{"identifier", "fromPopularity", "toPopularity", "change", "relativeChange", "fromRank", "toRank", "rankChange"},
+		Properties: map[string]*Schema{
+			"identifier":     {Type: "string", Description: "Name, code, URL or ID of the entry."},
+			"fromPopularity": {Type: "number", Format: "float", Description: "Popularity in the from month, 0 when not listed."},
+			"toPopularity":   {Type: "number", Format: "float", Description: "Popularity in the to month, 0 when not listed."},
+			"change":         {Type: "number", Format: "float", Description: "Change in percentage points."},
+			"relativeChange": {Type: "number", Format: "float", Nullable: true, Description: "Change relative to fromPopularity in percent, or null when fromPopularity is 0."},
+			"fromRank":       {Type: "integer", Nullable: true, Description: "Rank by popularity in the from month, or null when not listed. Ties share a rank."},
+			"toRank":         {Type: "integer", Nullable: true, Description: "Rank by popularity in the to month, or null when not listed. Ties share a rank."},
+			"rankChange":     {Type: "integer", Nullable: true, Description: "Ranks climbed (positive) or dropped (negative), or null when not listed in both months."},
+		},
+	}
+}
+
+//nolint:goconst
+func diffListSchema() *Schema {
+	return &Schema{
+		Type:     "object",
+		Required: []string{"fromMonth", "toMonth", "entries", "total", "count", "limit", "offset"},
+		Properties: map[string]*Schema{
+			"fromMonth": {Type: "integer", Description: "Month compared from, in YYYYMM format."},
+			"toMonth":   {Type: "integer", Description: "Month compared to, in YYYYMM format."},
+			"entries": {
+				Type:        "array",
+				Description: "Entries listed in either month, by popularity in the to month.",
+				Items:       &Schema{Ref: "#/components/schemas/DiffEntry"},
+			},
 			"total":  {Type: "integer", Description: "Total number of matching records."},
 			"count":  {Type: "integer", Description: "Number of records returned."},
 			"limit":  {Type: "integer", Description: "Maximum number of records requested."},
 			"offset": {Type: "integer", Description: "Number of records skipped."},
-			"query":  {Type: "string", Nullable: true, Description: "Applied name filter, or null when not supplied."},
 		},
 	}
 }
@@ -281,10 +468,19 @@ func BuildSpec(includeInternal bool) *OpenAPISpec {
 				Tags:        []string{e.tag},
 				Summary:     "List " + e.tag,
 				OperationID: "list_" + e.tag,
-				Parameters:  []Parameter{paramStartMonth, paramEndMonth, paramLimit, paramOffset, paramQuery},
+				Parameters:  []Parameter{paramStartMonth, paramEndMonth, paramLimit, paramOffset, paramQuery, paramMatch, paramSort, paramOrder, paramMinCount, paramCursor},
 				Responses:   jsonResponse(e.listSchemaName),
 			},
 		}
+		spec.Paths[e.basePath+"/diff"] = PathItem{
+			Get: &Operation{
+				Tags:        []string{e.tag},
+				Summary:     "Compare " + e.tag + " between two months",
+				OperationID: "diff_" + e.tag,
+				Parameters:  []Parameter{paramFrom, paramTo, paramLimit, paramOffset},
+				Responses:   jsonResponse("DiffList"),
+			},
+		}
 		spec.Paths[e.basePath+"/{"+e.pathParam+"}"] = PathItem{
 			Get: &Operation{
 				Tags:        []string{e.tag},
@@ -299,11 +495,85 @@ func BuildSpec(includeInternal bool) *OpenAPISpec {
 				Tags:        []string{e.tag},
 				Summary:     "List " + e.tag + " series by " + e.pathParam,
 				OperationID: "list_" + e.tag + "_series_by_" + e.pathParam,
-				Parameters:  []Parameter{pathParam, paramStartMonth, paramEndMonth, paramLimit, paramOffset},
+				Parameters:  []Parameter{pathParam, paramStartMonth, paramEndMonth, paramLimit, paramOffset, paramGranularity, paramSmooth},
 				Responses:   jsonResponse(e.listSchemaName),
 			},
 		}
 	}
 
+	spec.Components.Schemas["DiffEntry"] = diffEntrySchema()
+	spec.Components.Schemas["DiffList"] = diffListSchema()
+	spec.Components.Schemas["GonePackage"] = gonePackageSchema()
+	spec.Components.Schemas["GonePackageList"] = gonePackageListSchema()
+	spec.Paths["/api/packages/new"] = PathItem{
+		Get: &Operation{
+			Tags:        []string{"packages"},
+			Summary:     "List packages first listed in a month",
+			Description: "Packages are listed once they reach the listing threshold and ordered by count, so this report takes no sort, order or minCount.",
+			OperationID: "list_new_packages",
+			Parameters:  []Parameter{paramMonth, paramLimit, paramOffset},
+			Responses:   jsonResponse("PackagePopularityList"),
+		},
+	}
+	spec.Paths["/api/packages/gone"] = PathItem{
+		Get: &Operation{
+			Tags:        []string{"packages"},
+			Summary:     "List packages no longer listed since a month",
+			Description: "Packages are listed once they reach the listing threshold and ordered by count in their last month, so this report takes no sort, order or minCount.",
+			OperationID: "list_gone_packages",
+			Parameters:  []Parameter{paramMonth, paramLimit, paramOffset},
+			Responses:   jsonResponse("GonePackageList"),
+		},
+	}
+	spec.Components.Schemas["PackageSuggestions"] = packageSuggestionsSchema()
+	spec.Paths["/api/packages/suggest"] = PathItem{
+		Get: &Operation{
+			Tags:        []string{"packages"},
+			Summary:     "Suggest package names for a prefix",
+			OperationID: "suggest_packages",
+			Parameters: []Parameter{
+				{
+					Name:        "q",
+					In:          "query",
+					Description: "Case-insensitive package name prefix, without wildcards.",
+					Required:    true,
+					Schema:      &Schema{Type: "string", MaxLength: new(submit.MaxPackageLen)},
+				},
+				{
+					Name:        "limit",
+					In:          "query",
+					Description: "Maximum number of suggestions to return.",
+					Schema:      &Schema{Type: "integer", Default: 10, Minimum: new(1), Maximum: new(100)},
+				},
+			},
+			Responses: jsonResponse("PackageSuggestions"),
+		},
+	}
+	spec.Components.Schemas["ForecastPoint"] = forecastPointSchema()
+	spec.Components.Schemas["PackageForecast"] = packageForecastSchema()
+	spec.Paths["/api/packages/{name}/forecast"] = PathItem{
+		Get: &Operation{
+			Tags:        []string{"packages"},
+			Summary:     "Forecast package popularity",
+			OperationID: "forecast_package",
+			Parameters: []Parameter{
+				{
+					Name:        "name",
+					In:          "path",
+					Description: "Package name",
+					Required:    true,
+					Schema:      &Schema{Type: "string"},
+				},
+				{
+					Name:        "months",
+					In:          "query",
+					Description: "Number of months to project past the last complete month.",
+					Schema:      &Schema{Type: "integer", Default: 6, Minimum: new(1), Maximum: new(24)},
+				},
+			},
+			Responses: jsonResponse("PackageForecast"),
+		},
+	}
+
 	return spec
 }
@@ -0,0 +1,137 @@
+package backup
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"pkgstatsd/internal/config"
+	"pkgstatsd/internal/database"
+)
+
+// privateTables hold client IPs, headers and raw payloads or short-lived
+// request state. They are emptied in public snapshots.
+var privateTables = []string{"submission_log", "rate_limit", "submission_dedup"}
+
+// Run executes the backup subcommand. It writes a consistent copy of the
+// database to the destination given as argument, without the private tables
+// when --public is set, and returns the process exit code. It is safe to run
+// while the server is writing.
+func Run(args []string, cfg config.Config) int {
+	fs := flag.NewFlagSet("backup", flag.ExitOnError)
+	publicFlag := fs.Bool("public", false, "Leave out submission logs and rate limiting state for a shareable snapshot")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: pkgstatsd backup [--public] <dest>")
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+	dest := fs.Arg(0)
+
+	if database.IsPostgres(cfg.Database) {
+		fmt.Fprintln(os.Stderr, "Error: backup supports SQLite databases only, use pg_dump for PostgreSQL")
+		return 1
+	}
+
+	db, err := database.Connect(cfg.Database)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	defer func() { _ = db.Close() }()
+
+	if err := Backup(context.Background(), db, dest, *publicFlag); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	fmt.Printf("Wrote backup to %s.\n", dest)
+	return 0
+}
+
+// Backup writes a copy of db to the new file dest with VACUUM INTO, which
+// reads a single snapshot of the database and so is consistent even while
+// other connections write. With public set, the private tables are emptied
+// and the copy vacuumed again so no deleted rows remain in free pages. The
+// copy is checked with PRAGMA integrity_check and removed if any step fails.
+func Backup(ctx context.Context, db *sql.DB, dest string, public bool) (err error) {
+	if _, err := os.Stat(dest); err == nil {
+		return fmt.Errorf("%s already exists", dest)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("check destination: %w", err)
+	}
+
+	if _, err := db.ExecContext(ctx, `VACUUM INTO ?`, dest); err != nil {
+		return fmt.Errorf("copy database: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = os.Remove(dest)
+		}
+	}()
+
+	snapshot, err := sql.Open("sqlite", dest)
+	if err != nil {
+		return fmt.Errorf("open backup: %w", err)
+	}
+	defer func() { _ = snapshot.Close() }()
+
+	if public {
+		if err := removePrivateData(ctx, snapshot); err != nil {
+			return err
+		}
+	}
+
+	return checkIntegrity(ctx, snapshot)
+}
+
+func removePrivateData(ctx context.Context, db *sql.DB) error {
+	for _, table := range privateTables {
+		if _, err := db.ExecContext(ctx, `DELETE FROM `+table); err != nil { //nolint:gosec // table names are constants
+			return fmt.Errorf("clear %s: %w", table, err)
+		}
+	}
+
+	if _, err := db.ExecContext(ctx, `VACUUM`); err != nil {
+		return fmt.Errorf("vacuum backup: %w", err)
+	}
+
+	return nil
+}
+
+func checkIntegrity(ctx context.Context, db *sql.DB) error {
+	rows, err := db.QueryContext(ctx, `PRAGMA integrity_check`)
+	if err != nil {
+		return fmt.Errorf("check integrity: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var problems []string
+	for rows.Next() {
+		var result string
+		if err := rows.Scan(&result); err != nil {
+			return fmt.Errorf("scan integrity check: %w", err)
+		}
+		if result != "ok" {
+			problems = append(problems, result)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("check integrity: %w", err)
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("integrity check failed: %s", strings.Join(problems, "; "))
+	}
+
+	return nil
+}
@@ -0,0 +1,124 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"pkgstatsd/internal/database"
+)
+
+func setupTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, err := database.New(filepath.Join(t.TempDir(), "pkgstats.db"))
+	if err != nil {
+		t.Fatalf("create database: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	_, err = db.Exec(`INSERT INTO package_name (id, name) VALUES (1, 'pacman'), (2, 'linux'); INSERT INTO package (name_id, month, count) VALUES (1, 202501, 10), (2, 202501, 8)`)
+	if err != nil {
+		t.Fatalf("insert packages: %v", err)
+	}
+	_, err = db.Exec(`INSERT INTO submission_log (month, timestamp, ip, headers, payload, payload_hash, country)
+		VALUES (202501, 1735689600, '203.0.113.50', '{}', '{"secret":"payload"}', 'hash', 'DE')`)
+	if err != nil {
+		t.Fatalf("insert submission log: %v", err)
+	}
+	_, err = db.Exec(`INSERT INTO rate_limit (key, timestamp) VALUES ('203.0.113.0', 1735689600)`)
+	if err != nil {
+		t.Fatalf("insert rate limit: %v", err)
+	}
+
+	return db
+}
+
+func countRows(t *testing.T, path, table string) int {
+	t.Helper()
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("open backup: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	var count int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM ` + table).Scan(&count); err != nil {
+		t.Fatalf("count %s: %v", table, err)
+	}
+
+	return count
+}
+
+func TestBackup(t *testing.T) {
+	db := setupTestDB(t)
+	dest := filepath.Join(t.TempDir(), "backup.db")
+
+	if err := Backup(context.Background(), db, dest, false); err != nil {
+		t.Fatalf("Backup error: %v", err)
+	}
+
+	if count := countRows(t, dest, "package"); count != 2 {
+		t.Errorf("expected 2 packages, got %d", count)
+	}
+	if count := countRows(t, dest, "submission_log"); count != 1 {
+		t.Errorf("expected 1 submission log entry, got %d", count)
+	}
+}
+
+func TestBackup_Public(t *testing.T) {
+	db := setupTestDB(t)
+	dest := filepath.Join(t.TempDir(), "public.db")
+
+	if err := Backup(context.Background(), db, dest, true); err != nil {
+		t.Fatalf("Backup error: %v", err)
+	}
+
+	if count := countRows(t, dest, "package"); count != 2 {
+		t.Errorf("expected 2 packages, got %d", count)
+	}
+	for _, table := range privateTables {
+		if count := countRows(t, dest, table); count != 0 {
+			t.Errorf("expected empty %s, got %d rows", table, count)
+		}
+	}
+
+	data, err := os.ReadFile(dest)
+	if err != nil {
+		t.Fatalf("read backup: %v", err)
+	}
+	if bytes.Contains(data, []byte(`"secret":"payload"`)) || bytes.Contains(data, []byte("203.0.113.50")) {
+		t.Error("expected private data to be removed from the file")
+	}
+
+	// The source database is untouched.
+	var count int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM submission_log`).Scan(&count); err != nil {
+		t.Fatalf("count source submission log: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("expected source submission log to keep 1 entry, got %d", count)
+	}
+}
+
+func TestBackup_ExistingDestination(t *testing.T) {
+	db := setupTestDB(t)
+	dest := filepath.Join(t.TempDir(), "backup.db")
+
+	if err := os.WriteFile(dest, []byte("keep"), 0o600); err != nil {
+		t.Fatalf("write destination: %v", err)
+	}
+
+	if err := Backup(context.Background(), db, dest, false); err == nil {
+		t.Fatal("expected error for existing destination, got nil")
+	}
+
+	data, err := os.ReadFile(dest)
+	if err != nil || string(data) != "keep" {
+		t.Errorf("expected destination to be left alone, got %q (%v)", data, err)
+	}
+}
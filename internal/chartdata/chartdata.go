@@ -2,49 +2,76 @@ package chartdata
 
 import (
 	"sort"
-)
 
-const (
-	monthsInYear = 12
-	yearFactor   = 100
+	"pkgstatsd/internal/popularity"
+	"pkgstatsd/internal/web"
 )
 
 type Popularity interface {
 	GetName() string
 	GetStartMonth() int
 	GetPopularity() float64
+	Bounds() (low, high float64, ok bool)
 }
 
 type Data struct {
-	Labels   []int     `json:"labels"`
-	Datasets []Dataset `json:"datasets"`
+	Labels      []int     `json:"labels"`
+	Granularity string    `json:"granularity"`
+	Smoothing   string    `json:"smoothing,omitempty"`
+	Datasets    []Dataset `json:"datasets"`
+	Forecast    *Forecast `json:"forecast,omitempty"`
 }
 
+// Dataset holds one entity's series. Low and High carry the confidence
+// interval of each point for drawing error bands; they are omitted when the
+// series is smoothed.
 type Dataset struct {
 	Label string     `json:"label"`
 	Data  []*float64 `json:"data"`
+	Low   []*float64 `json:"low,omitempty"`
+	High  []*float64 `json:"high,omitempty"`
+}
+
+// Forecast continues the first dataset past the last label with projected
+// values and their prediction interval.
+type Forecast struct {
+	Labels []int     `json:"labels"`
+	Data   []float64 `json:"data"`
+	Low    []float64 `json:"low"`
+	High   []float64 `json:"high"`
+}
+
+type point struct {
+	popularity float64
+	low, high  float64
+	bounded    bool
 }
 
-// Build transforms popularity entries into ChartJS-ready format.
-// Null values represent missing months for a given entity.
-func Build[T Popularity](popularities []T) Data {
+// Build transforms popularity entries into ChartJS-ready format. Labels are
+// the first month of each period of the given granularity. Null values
+// represent missing periods for a given entity. Entries are expected to be
+// unsmoothed; opts.Smoothing is applied to each dataset here.
+func Build[T Popularity](popularities []T, opts web.SeriesOptions) Data {
+	granularity := opts.Granularity
 	labelSet := make(map[int]struct{})
-	seriesMap := make(map[string]map[int]float64)
+	seriesMap := make(map[string]map[int]point)
 
 	for _, p := range popularities {
-		labelSet[p.GetStartMonth()] = struct{}{}
+		label := web.PeriodStart(p.GetStartMonth(), granularity)
+		labelSet[label] = struct{}{}
 
 		m, ok := seriesMap[p.GetName()]
 		if !ok {
-			m = make(map[int]float64)
+			m = make(map[int]point)
 			seriesMap[p.GetName()] = m
 		}
 
-		m[p.GetStartMonth()] = p.GetPopularity()
+		low, high, bounded := p.Bounds()
+		m[label] = point{popularity: p.GetPopularity(), low: low, high: high, bounded: bounded}
 	}
 
 	if len(labelSet) == 0 {
-		return Data{Labels: []int{}, Datasets: []Dataset{}}
+		return Data{Labels: []int{}, Granularity: granularity, Smoothing: opts.Smoothing.String(), Datasets: []Dataset{}}
 	}
 
 	var minMonth, maxMonth int
@@ -58,11 +85,11 @@ func Build[T Popularity](popularities []T) Data {
 		}
 	}
 
-	labels := monthRange(minMonth, maxMonth)
+	labels := periodRange(minMonth, maxMonth, granularity)
 
 	type namedSeries struct {
 		name   string
-		series map[int]float64
+		series map[int]point
 	}
 
 	sorted := make([]namedSeries, 0, len(seriesMap))
@@ -72,37 +99,39 @@ func Build[T Popularity](popularities []T) Data {
 
 	lastLabel := labels[len(labels)-1]
 	sort.Slice(sorted, func(i, j int) bool {
-		return sorted[i].series[lastLabel] > sorted[j].series[lastLabel]
+		return sorted[i].series[lastLabel].popularity > sorted[j].series[lastLabel].popularity
 	})
 
 	datasets := make([]Dataset, len(sorted))
 	for i, s := range sorted {
 		data := make([]*float64, len(labels))
+		low := make([]*float64, len(labels))
+		high := make([]*float64, len(labels))
+		bounded := false
 		for j, label := range labels {
-			if v, ok := s.series[label]; ok {
-				data[j] = &v
+			if p, ok := s.series[label]; ok {
+				data[j] = &p.popularity
+				if p.bounded {
+					low[j], high[j] = &p.low, &p.high
+					bounded = true
+				}
 			}
 		}
 
-		datasets[i] = Dataset{Label: s.name, Data: data}
-	}
-
-	return Data{Labels: labels, Datasets: datasets}
-}
-
-func monthRange(from, to int) []int {
-	var months []int
-	for m := from; m <= to; m = nextMonth(m) {
-		months = append(months, m)
+		datasets[i] = Dataset{Label: s.name, Data: popularity.Smooth(data, opts.Smoothing)}
+		if bounded && opts.Smoothing.Method == "" {
+			datasets[i].Low, datasets[i].High = low, high
+		}
 	}
 
-	return months
+	return Data{Labels: labels, Granularity: granularity, Smoothing: opts.Smoothing.String(), Datasets: datasets}
 }
 
-func nextMonth(m int) int {
-	if m%yearFactor >= monthsInYear {
-		return (m/yearFactor+1)*yearFactor + 1
+func periodRange(from, to int, granularity string) []int {
+	var periods []int
+	for p := from; p <= to; p = web.NextPeriod(p, granularity) {
+		periods = append(periods, p)
 	}
 
-	return m + 1
+	return periods
 }
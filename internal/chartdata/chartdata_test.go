@@ -1,7 +1,10 @@
 package chartdata
 
 import (
+	"slices"
 	"testing"
+
+	"pkgstatsd/internal/web"
 )
 
 type testPop struct {
@@ -14,6 +17,15 @@ func (p testPop) GetName() string        { return p.name }
 func (p testPop) GetStartMonth() int     { return p.month }
 func (p testPop) GetPopularity() float64 { return p.popularity }
 
+func (p testPop) Bounds() (float64, float64, bool) { return 0, 0, false }
+
+type boundedPop struct {
+	testPop
+	low, high float64
+}
+
+func (p boundedPop) Bounds() (float64, float64, bool) { return p.low, p.high, true }
+
 func TestBuild(t *testing.T) {
 	tests := []struct {
 		name         string
@@ -55,7 +67,7 @@ func TestBuild(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := Build(tt.input)
+			got := Build(tt.input, web.SeriesOptions{Granularity: web.GranularityMonth})
 			if len(got.Labels) != len(tt.wantLabels) {
 				t.Errorf("got %d labels, want %d", len(got.Labels), len(tt.wantLabels))
 			}
@@ -66,19 +78,109 @@ func TestBuild(t *testing.T) {
 	}
 }
 
-func TestNextMonth(t *testing.T) {
+func TestBuild_Quarter(t *testing.T) {
+	// Series points carry their clipped start month, labels the period start.
+	got := Build([]testPop{
+		{"a", 202402, 10.0},
+		{"a", 202410, 20.0},
+	}, web.SeriesOptions{Granularity: web.GranularityQuarter})
+
+	wantLabels := []int{202401, 202404, 202407, 202410}
+	if !slices.Equal(got.Labels, wantLabels) {
+		t.Fatalf("got labels %v, want %v", got.Labels, wantLabels)
+	}
+	if got.Granularity != web.GranularityQuarter {
+		t.Errorf("got granularity %q, want %q", got.Granularity, web.GranularityQuarter)
+	}
+
+	data := got.Datasets[0].Data
+	if data[0] == nil || *data[0] != 10.0 || data[1] != nil || data[2] != nil || data[3] == nil || *data[3] != 20.0 {
+		t.Errorf("unexpected data %v", data)
+	}
+}
+
+func TestBuild_Smoothing(t *testing.T) {
+	opts := web.SeriesOptions{
+		Granularity: web.GranularityMonth,
+		Smoothing:   web.Smoothing{Method: web.SmoothMovingAverage, Window: 2},
+	}
+	got := Build([]testPop{
+		{"a", 202501, 10.0},
+		{"a", 202502, 20.0},
+		{"a", 202504, 40.0},
+	}, opts)
+
+	if got.Smoothing != "ma2" {
+		t.Errorf("got smoothing %q, want ma2", got.Smoothing)
+	}
+
+	want := []*float64{new(10.0), new(15.0), nil, new(40.0)}
+	if !equalSeries(got.Datasets[0].Data, want) {
+		t.Errorf("got %v, want %v", deref(got.Datasets[0].Data), deref(want))
+	}
+}
+
+func TestBuild_Bounds(t *testing.T) {
+	input := []boundedPop{
+		{testPop{"a", 202501, 10.0}, 8.0, 12.0},
+		{testPop{"a", 202503, 20.0}, 17.0, 23.0},
+	}
+
+	got := Build(input, web.SeriesOptions{Granularity: web.GranularityMonth})
+	ds := got.Datasets[0]
+	if !equalSeries(ds.Low, []*float64{new(8.0), nil, new(17.0)}) {
+		t.Errorf("got low %v", deref(ds.Low))
+	}
+	if !equalSeries(ds.High, []*float64{new(12.0), nil, new(23.0)}) {
+		t.Errorf("got high %v", deref(ds.High))
+	}
+
+	smoothed := Build(input, web.SeriesOptions{
+		Granularity: web.GranularityMonth,
+		Smoothing:   web.Smoothing{Method: web.SmoothMovingAverage, Window: 2},
+	})
+	if smoothed.Datasets[0].Low != nil || smoothed.Datasets[0].High != nil {
+		t.Error("expected no bounds on smoothed series")
+	}
+
+	unbounded := Build([]testPop{{"a", 202501, 10.0}}, web.SeriesOptions{Granularity: web.GranularityMonth})
+	if unbounded.Datasets[0].Low != nil {
+		t.Error("expected no bounds without intervals")
+	}
+}
+
+func equalSeries(a, b []*float64) bool {
+	return slices.EqualFunc(a, b, func(x, y *float64) bool {
+		return (x == nil && y == nil) || (x != nil && y != nil && *x == *y)
+	})
+}
+
+func deref(values []*float64) []any {
+	out := make([]any, len(values))
+	for i, v := range values {
+		if v != nil {
+			out[i] = *v
+		}
+	}
+	return out
+}
+
+func TestPeriodRange(t *testing.T) {
 	tests := []struct {
-		in   int
-		want int
+		from        int
+		to          int
+		granularity string
+		want        []int
 	}{
-		{202401, 202402},
-		{202412, 202501},
+		{202411, 202502, web.GranularityMonth, []int{202411, 202412, 202501, 202502}},
+		{202407, 202501, web.GranularityQuarter, []int{202407, 202410, 202501}},
+		{202301, 202501, web.GranularityYear, []int{202301, 202401, 202501}},
 	}
 
 	for _, tt := range tests {
-		got := nextMonth(tt.in)
-		if got != tt.want {
-			t.Errorf("nextMonth(%d) = %d, want %d", tt.in, got, tt.want)
+		got := periodRange(tt.from, tt.to, tt.granularity)
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("periodRange(%d, %d, %s) = %v, want %v", tt.from, tt.to, tt.granularity, got, tt.want)
 		}
 	}
 }
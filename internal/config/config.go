@@ -5,16 +5,38 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
 )
 
 //nolint:goconst
 var defaultExpectedPackages = []string{"pkgstats", "pacman"}
 
+const defaultResultCacheSize = 512
+
+// Exporters that TRACES_EXPORTER selects.
+const (
+	TracesExporterOTLP   = "otlp"
+	TracesExporterStdout = "stdout"
+	TracesExporterFile   = "file"
+)
+
 type Config struct {
-	Database         string
-	GeoIPDatabase    string
-	Port             string
+	// Database is the path of an SQLite database file or a postgres:// URL.
+	Database      string
+	GeoIPDatabase string
+	Port          string
+	// AdminPort serves /metrics and the /healthz and /readyz probes on a
+	// separate listener; empty disables it.
+	AdminPort        string
 	ExpectedPackages []string
+	// ResultCacheSize is the number of query results kept in memory; 0
+	// disables the result cache, which is the default for PostgreSQL.
+	ResultCacheSize int
+	// TracesExporter enables tracing and sends spans to an OTLP collector,
+	// stdout or TracesFile; empty disables it.
+	TracesExporter string
+	TracesFile     string
 }
 
 func Load() (Config, error) {
@@ -23,17 +45,44 @@ func Load() (Config, error) {
 		return Config{}, err
 	}
 
+	db := getEnv("DATABASE", "")
+
+	// The result cache is invalidated only in the process that writes, so it
+	// is off by default for PostgreSQL, where replicas share the database.
+	resultCacheSize := defaultResultCacheSize
+	if strings.HasPrefix(db, "postgres://") || strings.HasPrefix(db, "postgresql://") {
+		resultCacheSize = 0
+	}
+	resultCacheSize, err = getEnvInt("RESULT_CACHE_SIZE", resultCacheSize)
+	if err != nil {
+		return Config{}, err
+	}
+
 	cfg := Config{
-		Database:         getEnv("DATABASE", ""),
+		Database:         db,
 		GeoIPDatabase:    getEnv("GEOIP_DATABASE", ""),
 		Port:             getEnv("PORT", "8282"),
+		AdminPort:        getEnv("ADMIN_PORT", ""),
 		ExpectedPackages: expectedPackages,
+		ResultCacheSize:  resultCacheSize,
+		TracesExporter:   getEnv("TRACES_EXPORTER", ""),
+		TracesFile:       getEnv("TRACES_FILE", ""),
 	}
 
 	if cfg.Database == "" {
 		return Config{}, errors.New("DATABASE environment variable is required")
 	}
 
+	switch cfg.TracesExporter {
+	case "", TracesExporterOTLP, TracesExporterStdout:
+	case TracesExporterFile:
+		if cfg.TracesFile == "" {
+			return Config{}, errors.New("TRACES_FILE is required for the file traces exporter")
+		}
+	default:
+		return Config{}, fmt.Errorf("invalid TRACES_EXPORTER: %q", cfg.TracesExporter)
+	}
+
 	return cfg, nil
 }
 
@@ -57,3 +106,17 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func getEnvInt(key string, defaultValue int) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid %s: %q", key, value)
+	}
+
+	return n, nil
+}
@@ -39,3 +39,80 @@ func TestLoadExpectedPackages_InvalidJSON(t *testing.T) {
 		t.Error("expected error for invalid JSON, got nil")
 	}
 }
+
+func TestGetEnvInt(t *testing.T) {
+	t.Setenv("RESULT_CACHE_SIZE", "")
+
+	n, err := getEnvInt("RESULT_CACHE_SIZE", 512)
+	if err != nil || n != 512 {
+		t.Errorf("expected default 512, got %d (%v)", n, err)
+	}
+
+	t.Setenv("RESULT_CACHE_SIZE", "0")
+
+	n, err = getEnvInt("RESULT_CACHE_SIZE", 512)
+	if err != nil || n != 0 {
+		t.Errorf("expected 0, got %d (%v)", n, err)
+	}
+
+	for _, value := range []string{"abc", "-1"} {
+		t.Setenv("RESULT_CACHE_SIZE", value)
+
+		if _, err := getEnvInt("RESULT_CACHE_SIZE", 512); err == nil {
+			t.Errorf("expected error for %q, got nil", value)
+		}
+	}
+}
+
+func TestLoad_ResultCacheSize(t *testing.T) {
+	tests := []struct {
+		database string
+		size     string
+		want     int
+	}{
+		{"test.db", "", defaultResultCacheSize},
+		{"postgres://localhost/pkgstats", "", 0},
+		{"postgresql://localhost/pkgstats", "", 0},
+		{"postgres://localhost/pkgstats", "128", 128},
+		{"test.db", "0", 0},
+	}
+
+	for _, tt := range tests {
+		t.Setenv("DATABASE", tt.database)
+		t.Setenv("RESULT_CACHE_SIZE", tt.size)
+
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Load error: %v", err)
+		}
+		if cfg.ResultCacheSize != tt.want {
+			t.Errorf("DATABASE=%q RESULT_CACHE_SIZE=%q: expected %d, got %d", tt.database, tt.size, tt.want, cfg.ResultCacheSize)
+		}
+	}
+}
+
+func TestLoad_TracesExporter(t *testing.T) {
+	t.Setenv("DATABASE", "test.db")
+
+	tests := []struct {
+		exporter string
+		file     string
+		valid    bool
+	}{
+		{"", "", true},
+		{"otlp", "", true},
+		{"stdout", "", true},
+		{"file", "traces.jsonl", true},
+		{"file", "", false},
+		{"jaeger", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Setenv("TRACES_EXPORTER", tt.exporter)
+		t.Setenv("TRACES_FILE", tt.file)
+
+		if _, err := Load(); (err == nil) != tt.valid {
+			t.Errorf("TRACES_EXPORTER=%q TRACES_FILE=%q: expected valid %v, got error %v", tt.exporter, tt.file, tt.valid, err)
+		}
+	}
+}
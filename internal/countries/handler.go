@@ -5,36 +5,39 @@ import (
 	"database/sql"
 	"net/http"
 
+	"pkgstatsd/internal/database"
 	"pkgstatsd/internal/popularity"
+	"pkgstatsd/internal/web"
 )
 
-type SQLiteRepository struct {
+type SQLRepository struct {
 	*popularity.Repository[CountryPopularity, CountryPopularityList]
 }
 
-func NewSQLiteRepository(db *sql.DB) *SQLiteRepository {
-	return &SQLiteRepository{
+func NewSQLRepository(db *sql.DB, results *database.ResultCache) *SQLRepository {
+	return &SQLRepository{
 		Repository: popularity.NewRepository(db, popularity.Config{
 			Table:         "country",
 			Column:        "code",
 			QueryContains: true,
+			Results:       results,
 		}, newItem, newList),
 	}
 }
 
-func (r *SQLiteRepository) FindByCode(ctx context.Context, code string, startMonth, endMonth int) (*CountryPopularity, error) {
+func (r *SQLRepository) FindByCode(ctx context.Context, code string, startMonth, endMonth int) (*CountryPopularity, error) {
 	return r.FindByIdentifier(ctx, code, startMonth, endMonth)
 }
 
-func (r *SQLiteRepository) FindSeriesByCode(ctx context.Context, code string, startMonth, endMonth, limit, offset int) (*CountryPopularityList, error) {
-	return r.FindSeries(ctx, code, startMonth, endMonth, limit, offset)
+func (r *SQLRepository) FindSeriesByCode(ctx context.Context, code string, startMonth, endMonth, limit, offset int, opts web.SeriesOptions) (*CountryPopularityList, error) {
+	return r.FindSeries(ctx, code, startMonth, endMonth, limit, offset, opts)
 }
 
 type Handler struct {
 	pop *popularity.Handler[CountryPopularity, CountryPopularityList]
 }
 
-func NewHandler(repo *SQLiteRepository) *Handler {
+func NewHandler(repo *SQLRepository) *Handler {
 	return &Handler{
 		pop: popularity.NewHandler[CountryPopularity, CountryPopularityList](
 			repo, "/api/countries", "code", "country code required",
@@ -9,25 +9,31 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"pkgstatsd/internal/popularity"
 	"pkgstatsd/internal/web"
 )
 
 type mockQuerier struct {
 	findByIdentifierFunc func(ctx context.Context, identifier string, startMonth, endMonth int) (*CountryPopularity, error)
-	findAllFunc          func(ctx context.Context, query string, startMonth, endMonth, limit, offset int) (*CountryPopularityList, error)
-	findSeriesFunc       func(ctx context.Context, identifier string, startMonth, endMonth, limit, offset int) (*CountryPopularityList, error)
+	findAllFunc          func(ctx context.Context, query string, startMonth, endMonth, limit, offset int, opts web.ListOptions) (*CountryPopularityList, error)
+	findSeriesFunc       func(ctx context.Context, identifier string, startMonth, endMonth, limit, offset int, opts web.SeriesOptions) (*CountryPopularityList, error)
+	findDiffFunc         func(ctx context.Context, fromMonth, toMonth, limit, offset int) (*popularity.DiffList, error)
 }
 
 func (m *mockQuerier) FindByIdentifier(ctx context.Context, identifier string, startMonth, endMonth int) (*CountryPopularity, error) {
 	return m.findByIdentifierFunc(ctx, identifier, startMonth, endMonth)
 }
 
-func (m *mockQuerier) FindAll(ctx context.Context, query string, startMonth, endMonth, limit, offset int) (*CountryPopularityList, error) {
-	return m.findAllFunc(ctx, query, startMonth, endMonth, limit, offset)
+func (m *mockQuerier) FindAll(ctx context.Context, query string, startMonth, endMonth, limit, offset int, opts web.ListOptions) (*CountryPopularityList, error) {
+	return m.findAllFunc(ctx, query, startMonth, endMonth, limit, offset, opts)
 }
 
-func (m *mockQuerier) FindSeries(ctx context.Context, identifier string, startMonth, endMonth, limit, offset int) (*CountryPopularityList, error) {
-	return m.findSeriesFunc(ctx, identifier, startMonth, endMonth, limit, offset)
+func (m *mockQuerier) FindSeries(ctx context.Context, identifier string, startMonth, endMonth, limit, offset int, opts web.SeriesOptions) (*CountryPopularityList, error) {
+	return m.findSeriesFunc(ctx, identifier, startMonth, endMonth, limit, offset, opts)
+}
+
+func (m *mockQuerier) FindDiff(ctx context.Context, fromMonth, toMonth, limit, offset int) (*popularity.DiffList, error) {
+	return m.findDiffFunc(ctx, fromMonth, toMonth, limit, offset)
 }
 
 func newTestMux(q *mockQuerier) *http.ServeMux {
@@ -88,7 +94,7 @@ func TestHandleGet_RepositoryError(t *testing.T) {
 
 func TestHandleList_ResponseStructure(t *testing.T) {
 	q := &mockQuerier{
-		findAllFunc: func(_ context.Context, query string, _, _, limit, offset int) (*CountryPopularityList, error) {
+		findAllFunc: func(_ context.Context, query string, _, _, limit, offset int, _ web.ListOptions) (*CountryPopularityList, error) {
 			return &CountryPopularityList{
 				CountryPopularities: []CountryPopularity{},
 				Limit:               limit,
@@ -119,6 +125,41 @@ func TestHandleList_ResponseStructure(t *testing.T) {
 	}
 }
 
+func TestHandleList_ListOptions(t *testing.T) {
+	var captured web.ListOptions
+	q := &mockQuerier{
+		findAllFunc: func(_ context.Context, _ string, _, _, _, _ int, opts web.ListOptions) (*CountryPopularityList, error) {
+			captured = opts
+			return &CountryPopularityList{CountryPopularities: []CountryPopularity{}}, nil
+		},
+	}
+
+	mux := newTestMux(q)
+	req := httptest.NewRequest(http.MethodGet, "/api/countries?sort=growth&minCount=10", nil)
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+
+	want := web.ListOptions{Sort: web.SortGrowth, Order: web.OrderDesc, MinCount: 10}
+	if captured != want {
+		t.Errorf("expected options %+v, got %+v", want, captured)
+	}
+}
+
+func TestHandleList_InvalidSort(t *testing.T) {
+	mux := newTestMux(&mockQuerier{})
+	req := httptest.NewRequest(http.MethodGet, "/api/countries?sort=code", nil)
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, rr.Code)
+	}
+}
+
 func TestHandleList_PaginationValidCases(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -134,7 +175,7 @@ func TestHandleList_PaginationValidCases(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			var capturedLimit, capturedOffset int
 			q := &mockQuerier{
-				findAllFunc: func(_ context.Context, query string, _, _, limit, offset int) (*CountryPopularityList, error) {
+				findAllFunc: func(_ context.Context, query string, _, _, limit, offset int, _ web.ListOptions) (*CountryPopularityList, error) {
 					capturedLimit = limit
 					capturedOffset = offset
 					return &CountryPopularityList{CountryPopularities: []CountryPopularity{}, Limit: limit, Offset: offset, Query: &query}, nil
@@ -187,7 +228,7 @@ func TestHandleList_PaginationInvalidCases(t *testing.T) {
 
 func TestHandleSeries(t *testing.T) {
 	q := &mockQuerier{
-		findSeriesFunc: func(_ context.Context, code string, _, _, limit, _ int) (*CountryPopularityList, error) {
+		findSeriesFunc: func(_ context.Context, code string, _, _, limit, _ int, _ web.SeriesOptions) (*CountryPopularityList, error) {
 			return &CountryPopularityList{
 				Total:               1,
 				Count:               1,
@@ -215,10 +256,40 @@ func TestHandleSeries(t *testing.T) {
 	}
 }
 
+func TestHandleSeries_Granularity(t *testing.T) {
+	var capturedGranularity string
+	q := &mockQuerier{
+		findSeriesFunc: func(_ context.Context, _ string, _, _, _, _ int, opts web.SeriesOptions) (*CountryPopularityList, error) {
+			capturedGranularity = opts.Granularity
+			return &CountryPopularityList{CountryPopularities: []CountryPopularity{}}, nil
+		},
+	}
+
+	mux := newTestMux(q)
+	req := httptest.NewRequest(http.MethodGet, "/api/countries/DE/series?granularity=year", nil)
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+	if capturedGranularity != web.GranularityYear {
+		t.Errorf("expected granularity %q, got %q", web.GranularityYear, capturedGranularity)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/countries/DE/series?granularity=week", nil)
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, rr.Code)
+	}
+}
+
 func TestHandleList_MonthRangeSwap(t *testing.T) {
 	var capturedStart, capturedEnd int
 	q := &mockQuerier{
-		findAllFunc: func(_ context.Context, query string, startMonth, endMonth, limit, offset int) (*CountryPopularityList, error) {
+		findAllFunc: func(_ context.Context, query string, startMonth, endMonth, limit, offset int, _ web.ListOptions) (*CountryPopularityList, error) {
 			capturedStart = startMonth
 			capturedEnd = endMonth
 			return &CountryPopularityList{CountryPopularities: []CountryPopularity{}, Limit: limit, Offset: offset, Query: &query}, nil
@@ -237,3 +308,59 @@ func TestHandleList_MonthRangeSwap(t *testing.T) {
 		t.Errorf("expected endMonth 202501 (not swapped), got %d", capturedEnd)
 	}
 }
+
+func TestHandleDiff(t *testing.T) {
+	var capturedFrom, capturedTo int
+	q := &mockQuerier{
+		findDiffFunc: func(_ context.Context, fromMonth, toMonth, limit, offset int) (*popularity.DiffList, error) {
+			capturedFrom, capturedTo = fromMonth, toMonth
+			return &popularity.DiffList{
+				FromMonth: fromMonth,
+				ToMonth:   toMonth,
+				Total:     1,
+				Count:     1,
+				Entries:   []popularity.DiffEntry{{Identifier: "DE", FromPopularity: 20, ToPopularity: 25, Change: 5}},
+				Limit:     limit,
+				Offset:    offset,
+			}, nil
+		},
+	}
+
+	mux := newTestMux(q)
+	req := httptest.NewRequest(http.MethodGet, "/api/countries/diff?from=202401&to=202501", nil)
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+	if capturedFrom != 202401 || capturedTo != 202501 {
+		t.Errorf("expected months 202401-202501, got %d-%d", capturedFrom, capturedTo)
+	}
+
+	var raw map[string]any
+	if err := json.NewDecoder(rr.Body).Decode(&raw); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+
+	expectedKeys := []string{"fromMonth", "toMonth", "total", "count", "entries", "limit", "offset"}
+	for _, key := range expectedKeys {
+		if _, ok := raw[key]; !ok {
+			t.Errorf("missing key %q in response", key)
+		}
+	}
+}
+
+func TestHandleDiff_InvalidMonths(t *testing.T) {
+	mux := newTestMux(&mockQuerier{})
+
+	for _, url := range []string{"/api/countries/diff?from=202501&to=202501", "/api/countries/diff?to=202513", "/api/countries/diff?limit=-1"} {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d", url, http.StatusBadRequest, rr.Code)
+		}
+	}
+}
@@ -1,14 +1,20 @@
 package countries
 
-import "context"
+import (
+	"context"
+
+	"pkgstatsd/internal/popularity"
+	"pkgstatsd/internal/web"
+)
 
 type CountryPopularity struct {
 	Code       string  `json:"code"`
 	Samples    int     `json:"samples"`
 	Count      int     `json:"count"`
 	Popularity float64 `json:"popularity"`
-	StartMonth int     `json:"startMonth"`
-	EndMonth   int     `json:"endMonth"`
+	*popularity.Interval
+	StartMonth int `json:"startMonth"`
+	EndMonth   int `json:"endMonth"`
 }
 
 type CountryPopularityList struct {
@@ -18,28 +24,30 @@ type CountryPopularityList struct {
 	Limit               int                 `json:"limit"`
 	Offset              int                 `json:"offset"`
 	Query               *string             `json:"query"`
+	Next                *string             `json:"next,omitempty"`
+	Smoothing           string              `json:"smoothing,omitempty"`
 }
 
 type Repository interface {
 	FindByCode(ctx context.Context, code string, startMonth, endMonth int) (*CountryPopularity, error)
-	FindAll(ctx context.Context, query string, startMonth, endMonth, limit, offset int) (*CountryPopularityList, error)
-	FindSeriesByCode(ctx context.Context, code string, startMonth, endMonth, limit, offset int) (*CountryPopularityList, error)
+	FindAll(ctx context.Context, query string, startMonth, endMonth, limit, offset int, opts web.ListOptions) (*CountryPopularityList, error)
+	FindSeriesByCode(ctx context.Context, code string, startMonth, endMonth, limit, offset int, opts web.SeriesOptions) (*CountryPopularityList, error)
 }
 
 func (p CountryPopularity) GetName() string        { return p.Code }
 func (p CountryPopularity) GetStartMonth() int     { return p.StartMonth }
 func (p CountryPopularity) GetPopularity() float64 { return p.Popularity }
 
-func newItem(identifier string, samples, count int, popularity float64, startMonth, endMonth int) CountryPopularity {
+func newItem(identifier string, samples, count int, popularity float64, interval *popularity.Interval, startMonth, endMonth int) CountryPopularity {
 	return CountryPopularity{
 		Code: identifier, Samples: samples, Count: count,
-		Popularity: popularity, StartMonth: startMonth, EndMonth: endMonth,
+		Popularity: popularity, Interval: interval, StartMonth: startMonth, EndMonth: endMonth,
 	}
 }
 
-func newList(total, count int, items []CountryPopularity, limit, offset int, query *string) CountryPopularityList {
+func newList(total, count int, items []CountryPopularity, limit, offset int, query, next *string, smoothing string) CountryPopularityList {
 	return CountryPopularityList{
 		Total: total, Count: count, CountryPopularities: items,
-		Limit: limit, Offset: offset, Query: query,
+		Limit: limit, Offset: offset, Query: query, Next: next, Smoothing: smoothing,
 	}
 }
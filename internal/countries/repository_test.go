@@ -5,16 +5,17 @@ import (
 	"testing"
 
 	"pkgstatsd/internal/database"
+	"pkgstatsd/internal/web"
 )
 
-func TestSQLiteRepository(t *testing.T) {
+func TestSQLRepository(t *testing.T) {
 	db, err := database.New(":memory:")
 	if err != nil {
 		t.Fatalf("failed to create database: %v", err)
 	}
 	defer func() { _ = db.Close() }()
 
-	repo := NewSQLiteRepository(db)
+	repo := NewSQLRepository(db, nil)
 
 	_, _ = db.Exec(`INSERT INTO country (code, month, count) VALUES ('DE', 202501, 100), ('US', 202501, 200)`)
 
@@ -28,7 +29,7 @@ func TestSQLiteRepository(t *testing.T) {
 	}
 
 	// Test FindAll
-	list, err := repo.FindAll(context.Background(), "", 202501, 202501, 10, 0)
+	list, err := repo.FindAll(context.Background(), "", 202501, 202501, 10, 0, web.ListOptions{})
 	if err != nil {
 		t.Fatalf("FindAll error: %v", err)
 	}
@@ -37,7 +38,7 @@ func TestSQLiteRepository(t *testing.T) {
 	}
 
 	// Test FindSeriesByCode
-	series, err := repo.FindSeriesByCode(context.Background(), "DE", 202501, 202501, 10, 0)
+	series, err := repo.FindSeriesByCode(context.Background(), "DE", 202501, 202501, 10, 0, web.SeriesOptions{})
 	if err != nil {
 		t.Fatalf("FindSeriesByCode error: %v", err)
 	}
@@ -11,14 +11,16 @@ import (
 // MonthlySamplesCache caches the result of a monthly aggregation query
 // (e.g. MAX(count) or SUM(count) grouped by month). Matches PHP's Doctrine
 // result cache behavior: loads all months without filtering, caches until
-// the start of next month, filters in Go.
+// the start of next month, filters in Go. It also reloads once the data
+// version changed.
 type MonthlySamplesCache struct {
 	db    *sql.DB
 	query string
 
-	mu     sync.RWMutex
-	cache  map[int]int
-	expiry time.Time
+	mu      sync.RWMutex
+	cache   map[int]int
+	expiry  time.Time
+	version int64
 }
 
 // NewMonthlySamplesCache creates a cache for the given aggregation query.
@@ -45,9 +47,10 @@ func (c *MonthlySamplesCache) Get(ctx context.Context, startMonth, endMonth int)
 
 func (c *MonthlySamplesCache) load(ctx context.Context) (map[int]int, error) {
 	c.mu.RLock()
-	if c.cache != nil && time.Now().Before(c.expiry) {
+	if c.fresh() {
 		cache := c.cache
 		c.mu.RUnlock()
+		samplesCacheHits.Inc()
 		return cache, nil
 	}
 	c.mu.RUnlock()
@@ -56,10 +59,13 @@ func (c *MonthlySamplesCache) load(ctx context.Context) (map[int]int, error) {
 	defer c.mu.Unlock()
 
 	// Double-check after acquiring write lock
-	if c.cache != nil && time.Now().Before(c.expiry) {
+	if c.fresh() {
+		samplesCacheHits.Inc()
 		return c.cache, nil
 	}
 
+	samplesCacheLoads.Inc()
+	version := CurrentDataVersion().Version
 	rows, err := c.db.QueryContext(ctx, c.query)
 	if err != nil {
 		return nil, fmt.Errorf("query monthly samples: %w", err)
@@ -80,17 +86,25 @@ func (c *MonthlySamplesCache) load(ctx context.Context) (map[int]int, error) {
 	}
 
 	c.cache = cache
-	c.expiry = startOfNextMonth()
+	c.expiry = StartOfNextMonth()
+	c.version = version
 
 	return cache, nil
 }
 
+// fresh reports whether the cached result is current. c.mu must be held.
+func (c *MonthlySamplesCache) fresh() bool {
+	return c.cache != nil && time.Now().Before(c.expiry) && c.version == CurrentDataVersion().Version
+}
+
 func (c *MonthlySamplesCache) Warmup(ctx context.Context) error {
 	_, err := c.load(ctx)
 	return err
 }
 
-func startOfNextMonth() time.Time {
+// StartOfNextMonth is when data cached for the current month expires. Other
+// monthly caches use it to refresh in step with MonthlySamplesCache.
+func StartOfNextMonth() time.Time {
 	now := time.Now()
 	return time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, now.Location())
 }
@@ -53,7 +53,7 @@ func TestMonthlySamplesCache(t *testing.T) {
 
 func TestStartOfNextMonth(t *testing.T) {
 	now := time.Now()
-	next := startOfNextMonth()
+	next := StartOfNextMonth()
 
 	if next.Month() == now.Month() {
 		t.Errorf("expected different month, got %v", next.Month())
@@ -1,37 +1,65 @@
 package database
 
 import (
+	"context"
 	"database/sql"
-	"embed"
 	"errors"
 	"fmt"
+	"runtime"
+	"strings"
 
-	"github.com/golang-migrate/migrate/v4"
-	"github.com/golang-migrate/migrate/v4/database/sqlite"
-	"github.com/golang-migrate/migrate/v4/source/iofs"
+	_ "github.com/lib/pq"
 	_ "modernc.org/sqlite"
 )
 
-//go:embed migrations/*.sql
-var embedMigrations embed.FS
-
-func New(path string) (*sql.DB, error) {
-	// Apply SQLite pragmas via DSN so they are set on every connection in the pool.
-	dsn := path +
-		"?_pragma=journal_mode(WAL)" +
-		"&_pragma=busy_timeout(5000)" +
+const (
+	// connectionPragmas are set on every connection of both pools.
+	connectionPragmas = "_pragma=busy_timeout(5000)" +
 		"&_pragma=foreign_keys(ON)" +
-		"&_pragma=synchronous(NORMAL)" +
 		"&_pragma=cache_size(-65536)" + // 64 MB page cache
 		"&_pragma=mmap_size(1073741824)" + // 1 GB memory-mapped I/O
 		"&_pragma=temp_store(MEMORY)"
+	// writerPragmas are set on connections that may write. auto_vacuum only
+	// takes effect on new databases; db-maintenance converts existing ones.
+	writerPragmas = "&_pragma=auto_vacuum(INCREMENTAL)" +
+		"&_pragma=journal_mode(WAL)" +
+		"&_pragma=synchronous(NORMAL)"
 
-	db, err := sql.Open("sqlite", dsn)
+	minReaderConnections = 4
+	// maxPostgresConnections bounds the pool of each PostgreSQL client, so
+	// several replicas stay below the server's max_connections.
+	maxPostgresConnections = 16
+)
+
+// Pools holds separate connection pools for writing and reading, so long
+// read queries never hold the connection a write is waiting for. With
+// PostgreSQL, which handles concurrent writers itself, both are the same
+// pool.
+type Pools struct {
+	// Writer is a single connection whose transactions begin with BEGIN
+	// IMMEDIATE: they take the write lock upfront and wait for it via the
+	// busy timeout instead of failing with SQLITE_BUSY when upgrading a
+	// read transaction. Writes are serialized in the pool.
+	Writer *sql.DB
+	// Reader is a read-only pool for queries.
+	Reader *sql.DB
+}
+
+// IsPostgres reports whether the DATABASE setting dsn refers to a PostgreSQL
+// server rather than an SQLite file.
+func IsPostgres(dsn string) bool {
+	return strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://")
+}
+
+// New opens a single read-write pool and runs the migrations. It suits
+// command line tools; the server uses Open.
+func New(path string) (*sql.DB, error) {
+	db, err := Connect(path)
 	if err != nil {
-		return nil, fmt.Errorf("open database: %w", err)
+		return nil, err
 	}
 
-	if err := runMigrations(db); err != nil {
+	if err := Migrate(db); err != nil {
 		_ = db.Close()
 		return nil, fmt.Errorf("run migrations: %w", err)
 	}
@@ -39,25 +67,97 @@ func New(path string) (*sql.DB, error) {
 	return db, nil
 }
 
-func runMigrations(db *sql.DB) error {
-	source, err := iofs.New(embedMigrations, "migrations")
+// Connect opens a single read-write pool like New without running the
+// migrations.
+func Connect(path string) (*sql.DB, error) {
+	if IsPostgres(path) {
+		return openPostgres(path)
+	}
+
+	// Apply SQLite pragmas via DSN so they are set on every connection in the pool.
+	db, err := sql.Open("sqlite", path+"?"+connectionPragmas+writerPragmas)
 	if err != nil {
-		return fmt.Errorf("create migration source: %w", err)
+		return nil, fmt.Errorf("open database: %w", err)
+	}
+
+	return db, nil
+}
+
+// Open opens the writer and reader pools. It does not run the migrations;
+// the caller applies them to the writer with Migrate or verifies them with
+// CheckMigrations. An in-memory database exists only within its connection,
+// so ":memory:" shares the writer for reading.
+func Open(path string) (*Pools, error) {
+	if IsPostgres(path) {
+		db, err := openPostgres(path)
+		if err != nil {
+			return nil, err
+		}
+
+		return &Pools{Writer: db, Reader: db}, nil
 	}
 
-	driver, err := sqlite.WithInstance(db, &sqlite.Config{})
+	writer, err := sql.Open("sqlite", path+"?"+connectionPragmas+writerPragmas+"&_txlock=immediate")
 	if err != nil {
-		return fmt.Errorf("create migration driver: %w", err)
+		return nil, fmt.Errorf("open writer: %w", err)
 	}
+	writer.SetMaxOpenConns(1)
 
-	m, err := migrate.NewWithInstance("iofs", source, "sqlite", driver)
+	if path == ":memory:" {
+		return &Pools{Writer: writer, Reader: writer}, nil
+	}
+
+	// The writer creates a missing database file, which the read-only
+	// reader cannot.
+	if err := writer.Ping(); err != nil {
+		_ = writer.Close()
+		return nil, fmt.Errorf("connect writer: %w", err)
+	}
+
+	reader, err := sql.Open("sqlite", "file:"+path+"?mode=ro&"+connectionPragmas+"&_pragma=query_only(ON)")
 	if err != nil {
-		return fmt.Errorf("create migrator: %w", err)
+		_ = writer.Close()
+		return nil, fmt.Errorf("open reader: %w", err)
+	}
+	reader.SetMaxOpenConns(max(minReaderConnections, runtime.GOMAXPROCS(0)))
+
+	if err := reader.Ping(); err != nil {
+		_ = reader.Close()
+		_ = writer.Close()
+		return nil, fmt.Errorf("connect reader: %w", err)
+	}
+
+	return &Pools{Writer: writer, Reader: reader}, nil
+}
+
+// Ping checks that both pools can reach the database.
+func (p *Pools) Ping(ctx context.Context) error {
+	if err := p.Writer.PingContext(ctx); err != nil {
+		return err
+	}
+	if p.Reader == p.Writer {
+		return nil
+	}
+
+	return p.Reader.PingContext(ctx)
+}
+
+// Close closes both pools.
+func (p *Pools) Close() error {
+	if p.Reader == p.Writer {
+		return p.Writer.Close()
 	}
 
-	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
-		return fmt.Errorf("apply migrations: %w", err)
+	return errors.Join(p.Reader.Close(), p.Writer.Close())
+}
+
+func openPostgres(dsn string) (*sql.DB, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("open database: %w", err)
 	}
+	db.SetMaxOpenConns(maxPostgresConnections)
+	db.SetMaxIdleConns(maxPostgresConnections)
 
-	return nil
+	return db, nil
 }
@@ -1,6 +1,7 @@
 package database
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"testing"
@@ -25,11 +26,13 @@ func TestNew(t *testing.T) {
 	// Verify tables exist
 	tables := []string{
 		"package",
+		"package_name",
 		"country",
 		"mirror",
 		"system_architecture",
 		"operating_system_architecture",
 		"rate_limit",
+		"data_version",
 	}
 
 	for _, table := range tables {
@@ -52,13 +55,13 @@ func TestNew_InMemory(t *testing.T) {
 	defer func() { _ = db.Close() }()
 
 	// Verify we can insert and query data
-	_, err = db.Exec("INSERT INTO package (name, month, count) VALUES (?, ?, ?)", "pacman", 202501, 100)
+	_, err = db.Exec("INSERT INTO country (code, month, count) VALUES (?, ?, ?)", "DE", 202501, 100)
 	if err != nil {
 		t.Fatalf("insert error = %v", err)
 	}
 
 	var count int
-	err = db.QueryRow("SELECT count FROM package WHERE name = ?", "pacman").Scan(&count)
+	err = db.QueryRow("SELECT count FROM country WHERE code = ?", "DE").Scan(&count)
 	if err != nil {
 		t.Fatalf("query error = %v", err)
 	}
@@ -86,3 +89,91 @@ func TestNew_MigrationsIdempotent(t *testing.T) {
 	}
 	_ = db2.Close()
 }
+
+func TestOpen(t *testing.T) {
+	pools, err := Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer func() { _ = pools.Close() }()
+
+	if err := Migrate(pools.Writer); err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+
+	if maxConns := pools.Writer.Stats().MaxOpenConnections; maxConns != 1 {
+		t.Errorf("expected a single writer connection, got %d", maxConns)
+	}
+
+	if _, err := pools.Writer.Exec("INSERT INTO country (code, month, count) VALUES (?, ?, ?)", "DE", 202501, 100); err != nil {
+		t.Fatalf("writer insert error = %v", err)
+	}
+
+	var count int
+	if err := pools.Reader.QueryRow("SELECT count FROM country WHERE code = ?", "DE").Scan(&count); err != nil {
+		t.Fatalf("reader query error = %v", err)
+	}
+	if count != 100 {
+		t.Errorf("expected count 100, got %d", count)
+	}
+
+	if _, err := pools.Reader.Exec("INSERT INTO country (code, month, count) VALUES (?, ?, ?)", "FR", 202501, 1); err == nil {
+		t.Error("expected reader insert to fail")
+	}
+}
+
+func TestPools_Ping(t *testing.T) {
+	pools, err := Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	if err := pools.Ping(context.Background()); err != nil {
+		t.Errorf("Ping() error = %v", err)
+	}
+
+	_ = pools.Close()
+	if err := pools.Ping(context.Background()); err == nil {
+		t.Error("expected Ping() to fail after Close()")
+	}
+}
+
+func TestOpen_ConcurrentReadAndWrite(t *testing.T) {
+	pools, err := Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer func() { _ = pools.Close() }()
+
+	if err := Migrate(pools.Writer); err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+
+	// A read transaction left open must not block writes.
+	tx, err := pools.Reader.Begin()
+	if err != nil {
+		t.Fatalf("begin read transaction: %v", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var count int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM country").Scan(&count); err != nil {
+		t.Fatalf("read error = %v", err)
+	}
+
+	if _, err := pools.Writer.Exec("INSERT INTO country (code, month, count) VALUES (?, ?, ?)", "DE", 202501, 100); err != nil {
+		t.Fatalf("write during read error = %v", err)
+	}
+}
+
+func TestOpen_InMemory(t *testing.T) {
+	pools, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer func() { _ = pools.Close() }()
+
+	if pools.Reader != pools.Writer {
+		t.Error("expected an in-memory database to share its pool")
+	}
+}
@@ -0,0 +1,158 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// Dialect captures the SQL differences between the supported backends.
+// Queries are written with ? placeholders and passed through Rebind.
+type Dialect int
+
+const (
+	SQLite Dialect = iota
+	Postgres
+)
+
+// DialectOf returns the dialect of the driver behind db.
+func DialectOf(db *sql.DB) Dialect {
+	if _, ok := db.Driver().(*pq.Driver); ok {
+		return Postgres
+	}
+
+	return SQLite
+}
+
+func (d Dialect) String() string {
+	if d == Postgres {
+		return "postgres"
+	}
+
+	return "sqlite"
+}
+
+// Rebind rewrites the ? placeholders of query into the dialect's bind
+// variables. Question marks inside quoted strings are left alone.
+func (d Dialect) Rebind(query string) string {
+	if d != Postgres || !strings.Contains(query, "?") {
+		return query
+	}
+
+	var b strings.Builder
+	b.Grow(len(query) + len(query)/8)
+
+	n := 0
+	var quote byte
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '?':
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteByte(c)
+	}
+
+	return b.String()
+}
+
+// Like returns the operator for case-insensitive LIKE matching, which is
+// SQLite's default.
+func (d Dialect) Like() string {
+	if d == Postgres {
+		return "ILIKE"
+	}
+
+	return "LIKE"
+}
+
+// Glob returns a condition matching column against a case-sensitive glob
+// pattern, together with its argument.
+func (d Dialect) Glob(column, pattern string) (string, any) {
+	if d == Postgres {
+		return fmt.Sprintf(`%s ~ ?`, column), globRegexp(pattern)
+	}
+
+	return fmt.Sprintf(`%s GLOB ?`, column), pattern
+}
+
+// Instr returns an expression for the 1-based position of the ? argument in
+// expr, or 0 if it does not occur.
+func (d Dialect) Instr(expr string) string {
+	if d == Postgres {
+		return fmt.Sprintf(`strpos(%s, ?)`, expr)
+	}
+
+	return fmt.Sprintf(`instr(%s, ?)`, expr)
+}
+
+// GroupConcat returns an aggregate joining the values of expr with commas.
+func (d Dialect) GroupConcat(expr string) string {
+	if d == Postgres {
+		return fmt.Sprintf(`string_agg(%s, ',')`, expr)
+	}
+
+	return fmt.Sprintf(`GROUP_CONCAT(%s)`, expr)
+}
+
+// HasTrigramIndex reports whether the package_name_trigram FTS5 index
+// exists. PostgreSQL databases match fuzzy queries without it.
+func (d Dialect) HasTrigramIndex() bool {
+	return d == SQLite
+}
+
+// globRegexp translates an SQLite glob pattern into an anchored POSIX
+// regular expression.
+func globRegexp(pattern string) string {
+	var b strings.Builder
+	b.WriteByte('^')
+
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteByte('.')
+		case '[':
+			// A leading ^ negates the class and a ] right after the
+			// opening bracket (or the ^) is a literal.
+			j := i + 1
+			if j < len(pattern) && pattern[j] == '^' {
+				j++
+			}
+			if j < len(pattern) && pattern[j] == ']' {
+				j++
+			}
+
+			end := strings.IndexByte(pattern[j:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+
+			class := pattern[i+1 : j+end]
+			b.WriteByte('[')
+			b.WriteString(strings.ReplaceAll(class, `\`, `\\`))
+			b.WriteByte(']')
+			i = j + end
+		default:
+			b.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		}
+	}
+
+	b.WriteByte('$')
+
+	return b.String()
+}
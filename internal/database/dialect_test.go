@@ -0,0 +1,89 @@
+package database
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestRebind(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{`SELECT 1`, `SELECT 1`},
+		{`SELECT * FROM package WHERE name = ? AND month = ?`, `SELECT * FROM package WHERE name = $1 AND month = $2`},
+		{`SELECT '?' FROM t WHERE a = ?`, `SELECT '?' FROM t WHERE a = $1`},
+		{`SELECT "a?" FROM t WHERE a = ? OR b = 'it''s ?' OR c = ?`, `SELECT "a?" FROM t WHERE a = $1 OR b = 'it''s ?' OR c = $2`},
+	}
+
+	for _, tt := range tests {
+		if got := Postgres.Rebind(tt.query); got != tt.want {
+			t.Errorf("Rebind(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+		if got := SQLite.Rebind(tt.query); got != tt.query {
+			t.Errorf("SQLite Rebind(%q) = %q, want it unchanged", tt.query, got)
+		}
+	}
+}
+
+func TestGlobRegexp(t *testing.T) {
+	tests := []struct {
+		pattern string
+		matches []string
+		rejects []string
+	}{
+		{"python-*", []string{"python-", "python-requests"}, []string{"python", "xpython-a"}},
+		{"lib?2", []string{"libx2"}, []string{"lib2", "libxx2"}},
+		{"gcc[0-9]*", []string{"gcc13", "gcc1"}, []string{"gcc", "gccx"}},
+		{"[^a-z]*", []string{"0ad", "Z"}, []string{"pacman"}},
+		{"a[]]b", []string{"a]b"}, []string{"ab"}},
+		{"a.b+c", []string{"a.b+c"}, []string{"axb+c", "a.bbc"}},
+		{"open[", []string{"open["}, []string{"openx"}},
+		{"Qt*", []string{"Qt6"}, []string{"qt6"}},
+	}
+
+	for _, tt := range tests {
+		re, err := regexp.Compile(globRegexp(tt.pattern))
+		if err != nil {
+			t.Fatalf("globRegexp(%q) = %q does not compile: %v", tt.pattern, globRegexp(tt.pattern), err)
+		}
+		for _, s := range tt.matches {
+			if !re.MatchString(s) {
+				t.Errorf("globRegexp(%q) = %q should match %q", tt.pattern, re, s)
+			}
+		}
+		for _, s := range tt.rejects {
+			if re.MatchString(s) {
+				t.Errorf("globRegexp(%q) = %q should not match %q", tt.pattern, re, s)
+			}
+		}
+	}
+}
+
+func TestDialectOf(t *testing.T) {
+	db, err := New(":memory:")
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	if d := DialectOf(db); d != SQLite {
+		t.Errorf("expected sqlite dialect, got %s", d)
+	}
+}
+
+func TestIsPostgres(t *testing.T) {
+	tests := map[string]bool{
+		"pkgstats.db":                                     false,
+		":memory:":                                        false,
+		"/var/lib/pkgstatsd/pkgstats.db":                  false,
+		"postgres://pkgstats@localhost/pkgstats":          true,
+		"postgresql://localhost/pkgstats?sslmode=disable": true,
+	}
+
+	for dsn, want := range tests {
+		if got := IsPostgres(dsn); got != want {
+			t.Errorf("IsPostgres(%q) = %v, want %v", dsn, got, want)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package database
+
+import (
+	"database/sql"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// poolLabel distinguishes the connection pools in their metrics.
+const poolLabel = "pool"
+
+var (
+	samplesCacheHits = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "pkgstatsd_samples_cache_hits_total",
+		Help: "Monthly samples lookups served from the cache.",
+	})
+	samplesCacheLoads = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "pkgstatsd_samples_cache_loads_total",
+		Help: "Monthly samples queries run to fill or refresh the cache.",
+	})
+)
+
+// RegisterMetrics exposes the connection statistics of pools and the
+// counters of results, which may be nil, as metrics in the default
+// registry. It is called once by the server.
+func RegisterMetrics(pools *Pools, results *ResultCache) {
+	if pools.Reader == pools.Writer {
+		registerPoolMetrics(pools.Writer, "shared")
+	} else {
+		registerPoolMetrics(pools.Writer, "writer")
+		registerPoolMetrics(pools.Reader, "reader")
+	}
+
+	if results == nil {
+		return
+	}
+
+	promauto.NewCounterFunc(prometheus.CounterOpts{
+		Name: "pkgstatsd_result_cache_hits_total",
+		Help: "Query results served from the result cache.",
+	}, func() float64 { return float64(results.Stats().Hits) })
+	promauto.NewCounterFunc(prometheus.CounterOpts{
+		Name: "pkgstatsd_result_cache_misses_total",
+		Help: "Query results not found in the result cache.",
+	}, func() float64 { return float64(results.Stats().Misses) })
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "pkgstatsd_result_cache_hit_ratio",
+		Help: "Share of result cache lookups that were hits.",
+	}, func() float64 { return results.Stats().HitRate() })
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "pkgstatsd_result_cache_entries",
+		Help: "Query results held in the result cache.",
+	}, func() float64 { return float64(results.Stats().Entries) })
+}
+
+func registerPoolMetrics(db *sql.DB, pool string) {
+	labels := prometheus.Labels{poolLabel: pool}
+
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "pkgstatsd_db_connections_max", Help: "Maximum number of open database connections.", ConstLabels: labels,
+	}, func() float64 { return float64(db.Stats().MaxOpenConnections) })
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "pkgstatsd_db_connections_open", Help: "Open database connections.", ConstLabels: labels,
+	}, func() float64 { return float64(db.Stats().OpenConnections) })
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "pkgstatsd_db_connections_in_use", Help: "Database connections in use.", ConstLabels: labels,
+	}, func() float64 { return float64(db.Stats().InUse) })
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "pkgstatsd_db_connections_idle", Help: "Idle database connections.", ConstLabels: labels,
+	}, func() float64 { return float64(db.Stats().Idle) })
+	promauto.NewCounterFunc(prometheus.CounterOpts{
+		Name: "pkgstatsd_db_connection_waits_total", Help: "Times a query waited for a free database connection.", ConstLabels: labels,
+	}, func() float64 { return float64(db.Stats().WaitCount) })
+	promauto.NewCounterFunc(prometheus.CounterOpts{
+		Name: "pkgstatsd_db_connection_wait_seconds_total", Help: "Time spent waiting for a free database connection.", ConstLabels: labels,
+	}, func() float64 { return db.Stats().WaitDuration.Seconds() })
+}
@@ -0,0 +1,333 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/golang-migrate/migrate/v4"
+	migratedb "github.com/golang-migrate/migrate/v4/database"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/database/sqlite"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+
+	"pkgstatsd/internal/config"
+)
+
+// Each dialect has its own set of migrations, numbered independently.
+//
+//go:embed migrations/sqlite/*.sql migrations/postgres/*.sql
+var embedMigrations embed.FS
+
+// MigrationStatus describes the schema version of a database.
+type MigrationStatus struct {
+	// Version is the last applied migration, 0 if there is none.
+	Version uint
+	// Dirty is set when migration Version failed halfway and the schema
+	// needs to be repaired by hand.
+	Dirty bool
+	// Pending lists the embedded migrations newer than Version.
+	Pending []uint
+}
+
+// Migrate applies all pending migrations.
+func Migrate(db *sql.DB) error {
+	m, release, err := newMigrator(db)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("apply migrations: %w", err)
+	}
+
+	return nil
+}
+
+// migrationsTable is the version table the migrator maintains.
+const migrationsTable = "schema_migrations"
+
+// CheckMigrations returns an error unless all migrations are applied
+// cleanly. It is used instead of Migrate when migrations run as a separate
+// deployment step, and by the readiness probe.
+func CheckMigrations(ctx context.Context, db *sql.DB) error {
+	status, err := Migrations(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	if status.Dirty {
+		return fmt.Errorf("migration %d is dirty; repair the schema and run migrate force", status.Version)
+	}
+	if len(status.Pending) > 0 {
+		return fmt.Errorf("schema is at version %d, migrations up to %d are pending; run migrate up",
+			status.Version, status.Pending[len(status.Pending)-1])
+	}
+
+	return nil
+}
+
+// Migrations returns the migration status of db. It reads the version table
+// directly instead of building a migrator, which would hold a connection of
+// its own.
+func Migrations(ctx context.Context, db *sql.DB) (MigrationStatus, error) {
+	var status MigrationStatus
+	var err error
+	status.Version, status.Dirty, err = schemaVersion(ctx, db)
+	if err != nil {
+		return MigrationStatus{}, fmt.Errorf("read schema version: %w", err)
+	}
+
+	versions, err := migrationVersions(DialectOf(db))
+	if err != nil {
+		return MigrationStatus{}, err
+	}
+	for _, version := range versions {
+		if version > status.Version {
+			status.Pending = append(status.Pending, version)
+		}
+	}
+
+	return status, nil
+}
+
+// schemaVersion returns the version recorded in the version table, 0 if the
+// table is missing or empty.
+func schemaVersion(ctx context.Context, db *sql.DB) (version uint, dirty bool, err error) {
+	dialect := DialectOf(db)
+
+	query := `SELECT COUNT(*) > 0 FROM sqlite_master WHERE type = 'table' AND name = ?`
+	if dialect == Postgres {
+		query = `SELECT to_regclass(?) IS NOT NULL`
+	}
+	var exists bool
+	if err := db.QueryRowContext(ctx, dialect.Rebind(query), migrationsTable).Scan(&exists); err != nil {
+		return 0, false, err
+	}
+	if !exists {
+		return 0, false, nil
+	}
+
+	// A failed migration down to no version leaves NilVersion marked dirty.
+	var v int64
+	err = db.QueryRowContext(ctx, `SELECT version, dirty FROM `+migrationsTable+` LIMIT 1`).Scan(&v, &dirty)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+
+	return uint(max(v, 0)), dirty, nil
+}
+
+// RunMigrate executes the migrate subcommand, which shows and changes the
+// schema version with the embedded migrations, and returns the process exit
+// code:
+//
+//	migrate status   show the applied version and pending migrations
+//	migrate up       apply all pending migrations
+//	migrate down N   roll back the last N migrations
+//	migrate force V  mark version V as applied and clean after a failed
+//	                 migration was repaired by hand (-1 for none)
+func RunMigrate(args []string, cfg config.Config) int {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: pkgstatsd migrate status|up|down N|force V")
+	}
+	_ = fs.Parse(args)
+
+	command, number, ok := parseMigrateArgs(fs.Args())
+	if !ok {
+		fs.Usage()
+		return 2
+	}
+
+	db, err := Connect(cfg.Database)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	defer func() { _ = db.Close() }()
+
+	if err := runMigrateCommand(db, command, number); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	return 0
+}
+
+func parseMigrateArgs(args []string) (command string, number int, ok bool) {
+	if len(args) == 0 {
+		return "", 0, false
+	}
+
+	switch command = args[0]; command {
+	case "status", "up":
+		return command, 0, len(args) == 1
+	case "down", "force":
+		if len(args) != 2 {
+			return "", 0, false
+		}
+		number, err := strconv.Atoi(args[1])
+		if err != nil || (command == "down" && number < 1) || number < migratedb.NilVersion {
+			return "", 0, false
+		}
+		return command, number, true
+	default:
+		return "", 0, false
+	}
+}
+
+func runMigrateCommand(db *sql.DB, command string, number int) error {
+	if command == "status" {
+		status, err := Migrations(context.Background(), db)
+		if err != nil {
+			return err
+		}
+		printMigrationStatus(status)
+		return nil
+	}
+
+	m, release, err := newMigrator(db)
+	if err != nil {
+		return err
+	}
+	defer release()
+	m.Log = migrateLogger{}
+
+	switch command {
+	case "up":
+		err = m.Up()
+	case "down":
+		err = m.Steps(-number)
+	case "force":
+		err = m.Force(number)
+	}
+
+	if errors.Is(err, migrate.ErrNoChange) {
+		fmt.Println("No migrations to apply.")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	version, dirty, err := m.Version()
+	switch {
+	case errors.Is(err, migrate.ErrNilVersion):
+		fmt.Println("Schema has no migrations applied.")
+	case err != nil:
+		return fmt.Errorf("read schema version: %w", err)
+	case dirty:
+		fmt.Printf("Schema is at version %d, dirty.\n", version)
+	default:
+		fmt.Printf("Schema is at version %d.\n", version)
+	}
+
+	return nil
+}
+
+func printMigrationStatus(status MigrationStatus) {
+	fmt.Printf("Version: %d\n", status.Version)
+	if status.Dirty {
+		fmt.Printf("Migration %d failed halfway. Repair the schema, then run migrate force with the version it is in.\n", status.Version)
+	}
+
+	if len(status.Pending) == 0 {
+		fmt.Println("Pending: none")
+		return
+	}
+
+	fmt.Print("Pending:")
+	for _, version := range status.Pending {
+		fmt.Printf(" %d", version)
+	}
+	fmt.Println()
+}
+
+// migrateLogger prints each applied migration.
+type migrateLogger struct{}
+
+func (migrateLogger) Printf(format string, v ...any) { fmt.Printf(format, v...) }
+func (migrateLogger) Verbose() bool                  { return false }
+
+func migrationSource(dialect Dialect) (source.Driver, error) {
+	src, err := iofs.New(embedMigrations, "migrations/"+dialect.String())
+	if err != nil {
+		return nil, fmt.Errorf("create migration source: %w", err)
+	}
+
+	return src, nil
+}
+
+// migrationVersions returns the versions of the embedded migrations of
+// dialect in ascending order.
+func migrationVersions(dialect Dialect) ([]uint, error) {
+	src, err := migrationSource(dialect)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = src.Close() }()
+
+	var versions []uint
+	version, err := src.First()
+	for err == nil {
+		versions = append(versions, version)
+		version, err = src.Next(version)
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("list migrations: %w", err)
+	}
+
+	return versions, nil
+}
+
+// newMigrator returns a migrator for the embedded migrations of db's
+// dialect and a function that releases it. The migrator itself must not be
+// closed, as that closes db. On PostgreSQL it runs on a dedicated connection,
+// which it keeps until released.
+func newMigrator(db *sql.DB) (m *migrate.Migrate, release func(), err error) {
+	dialect := DialectOf(db)
+
+	src, err := migrationSource(dialect)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var driver migratedb.Driver
+	release = func() { _ = src.Close() }
+	if dialect == Postgres {
+		ctx := context.Background()
+		var conn *sql.Conn
+		conn, err = db.Conn(ctx)
+		if err != nil {
+			release()
+			return nil, nil, fmt.Errorf("acquire migration connection: %w", err)
+		}
+		release = func() { _ = src.Close(); _ = conn.Close() }
+		driver, err = postgres.WithConnection(ctx, conn, &postgres.Config{})
+	} else {
+		driver, err = sqlite.WithInstance(db, &sqlite.Config{})
+	}
+	if err != nil {
+		release()
+		return nil, nil, fmt.Errorf("create migration driver: %w", err)
+	}
+
+	m, err = migrate.NewWithInstance("iofs", src, dialect.String(), driver)
+	if err != nil {
+		release()
+		return nil, nil, fmt.Errorf("create migrator: %w", err)
+	}
+
+	return m, release, nil
+}
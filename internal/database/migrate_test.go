@@ -0,0 +1,171 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestMigrations(t *testing.T) {
+	db, err := Connect(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	versions, err := migrationVersions(SQLite)
+	if err != nil {
+		t.Fatalf("migrationVersions() error = %v", err)
+	}
+	if len(versions) == 0 {
+		t.Fatal("expected embedded migrations")
+	}
+	latest := versions[len(versions)-1]
+
+	status, err := Migrations(t.Context(), db)
+	if err != nil {
+		t.Fatalf("Migrations() error = %v", err)
+	}
+	if status.Version != 0 || status.Dirty || len(status.Pending) != len(versions) {
+		t.Errorf("expected all migrations pending on a new database, got %+v", status)
+	}
+	if err := CheckMigrations(t.Context(), db); err == nil {
+		t.Error("expected CheckMigrations to fail with pending migrations")
+	}
+
+	if err := Migrate(db); err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+	if err := CheckMigrations(t.Context(), db); err != nil {
+		t.Errorf("CheckMigrations() error = %v", err)
+	}
+
+	if err := runMigrateCommand(db, "down", 1); err != nil {
+		t.Fatalf("migrate down error = %v", err)
+	}
+	status, err = Migrations(t.Context(), db)
+	if err != nil {
+		t.Fatalf("Migrations() error = %v", err)
+	}
+	if status.Version != versions[len(versions)-2] || len(status.Pending) != 1 || status.Pending[0] != latest {
+		t.Errorf("expected the latest migration pending after down 1, got %+v", status)
+	}
+
+	if err := runMigrateCommand(db, "up", 0); err != nil {
+		t.Fatalf("migrate up error = %v", err)
+	}
+	if err := runMigrateCommand(db, "up", 0); err != nil {
+		t.Fatalf("migrate up without pending migrations error = %v", err)
+	}
+
+	// Simulate a migration that failed halfway.
+	if _, err := db.Exec(`UPDATE schema_migrations SET dirty = 1`); err != nil {
+		t.Fatalf("mark dirty: %v", err)
+	}
+	if err := CheckMigrations(t.Context(), db); err == nil {
+		t.Error("expected CheckMigrations to fail for a dirty schema")
+	}
+	if err := runMigrateCommand(db, "force", int(latest)); err != nil {
+		t.Fatalf("migrate force error = %v", err)
+	}
+	if err := CheckMigrations(t.Context(), db); err != nil {
+		t.Errorf("CheckMigrations() after force error = %v", err)
+	}
+}
+
+func TestMigrations_PackageName(t *testing.T) {
+	db, err := Connect(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	m, release, err := newMigrator(db)
+	if err != nil {
+		t.Fatalf("newMigrator() error = %v", err)
+	}
+	defer release()
+	if err := m.Migrate(5); err != nil {
+		t.Fatalf("migrate to 5 error = %v", err)
+	}
+
+	if _, err := db.Exec(`
+		INSERT INTO package (name, month, count) VALUES ('pacman', 202401, 10), ('pacman', 202501, 20), ('linux', 202501, 5);
+		INSERT INTO package_year (name, year, count) VALUES ('pacman', 2024, 10), ('old-package', 2023, 3)
+	`); err != nil {
+		t.Fatalf("insert test data: %v", err)
+	}
+
+	if err := Migrate(db); err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+
+	var count int
+	if err := db.QueryRow(`
+		SELECT SUM(count) FROM package JOIN package_name ON package_name.id = package.name_id WHERE name = 'pacman'
+	`).Scan(&count); err != nil {
+		t.Fatalf("query package count: %v", err)
+	}
+	if count != 30 {
+		t.Errorf("expected pacman count 30, got %d", count)
+	}
+
+	var name string
+	if err := db.QueryRow(`
+		SELECT name FROM package_year JOIN package_name ON package_name.id = package_year.name_id WHERE year = 2023
+	`).Scan(&name); err != nil {
+		t.Fatalf("query package_year: %v", err)
+	}
+	if name != "old-package" {
+		t.Errorf("expected old-package in package_year, got %q", name)
+	}
+
+	// The trigram index covers migrated and new names.
+	if _, err := db.Exec(`INSERT INTO package_name (name) VALUES ('pacman-contrib')`); err != nil {
+		t.Fatalf("insert package name: %v", err)
+	}
+	if err := db.QueryRow(`SELECT COUNT(*) FROM package_name_trigram WHERE package_name_trigram MATCH '"acm"'`).Scan(&count); err != nil {
+		t.Fatalf("query trigram index: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("expected 2 trigram matches, got %d", count)
+	}
+
+	if err := m.Migrate(5); err != nil {
+		t.Fatalf("migrate down to 5 error = %v", err)
+	}
+	if err := db.QueryRow(`SELECT count FROM package WHERE name = 'linux' AND month = 202501`).Scan(&count); err != nil {
+		t.Fatalf("query package count after down: %v", err)
+	}
+	if count != 5 {
+		t.Errorf("expected linux count 5 after down, got %d", count)
+	}
+}
+
+func TestParseMigrateArgs(t *testing.T) {
+	tests := []struct {
+		args    []string
+		command string
+		number  int
+		ok      bool
+	}{
+		{[]string{"status"}, "status", 0, true},
+		{[]string{"up"}, "up", 0, true},
+		{[]string{"down", "2"}, "down", 2, true},
+		{[]string{"force", "5"}, "force", 5, true},
+		{[]string{"force", "-1"}, "force", -1, true},
+		{nil, "", 0, false},
+		{[]string{"up", "1"}, "", 0, false},
+		{[]string{"down"}, "", 0, false},
+		{[]string{"down", "0"}, "", 0, false},
+		{[]string{"down", "x"}, "", 0, false},
+		{[]string{"force", "-2"}, "", 0, false},
+		{[]string{"drop"}, "", 0, false},
+	}
+
+	for _, tt := range tests {
+		command, number, ok := parseMigrateArgs(tt.args)
+		if ok != tt.ok || (ok && (command != tt.command || number != tt.number)) {
+			t.Errorf("parseMigrateArgs(%v) = %q, %d, %v, want %q, %d, %v", tt.args, command, number, ok, tt.command, tt.number, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,16 @@
+DROP TABLE IF EXISTS rollup;
+DROP TABLE IF EXISTS operating_system_id_year;
+DROP TABLE IF EXISTS operating_system_architecture_year;
+DROP TABLE IF EXISTS system_architecture_year;
+DROP TABLE IF EXISTS mirror_year;
+DROP TABLE IF EXISTS country_year;
+DROP TABLE IF EXISTS package_year;
+DROP TABLE IF EXISTS submission_dedup;
+DROP TABLE IF EXISTS submission_log;
+DROP TABLE IF EXISTS rate_limit;
+DROP TABLE IF EXISTS operating_system_id;
+DROP TABLE IF EXISTS operating_system_architecture;
+DROP TABLE IF EXISTS system_architecture;
+DROP TABLE IF EXISTS mirror;
+DROP TABLE IF EXISTS country;
+DROP TABLE IF EXISTS package;
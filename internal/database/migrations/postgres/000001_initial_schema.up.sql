@@ -0,0 +1,149 @@
+-- PostgreSQL schema, equivalent to the SQLite migrations up to
+-- 000005_yearly_rollup. Fuzzy package search matches names directly, so
+-- there is no package_name_trigram index.
+
+-- Package statistics
+CREATE TABLE package (
+    name TEXT NOT NULL,
+    month INTEGER NOT NULL,
+    count INTEGER NOT NULL DEFAULT 1,
+    PRIMARY KEY (name, month)
+);
+CREATE INDEX idx_package_month_name ON package(month, name);
+CREATE INDEX idx_package_month_count ON package(month, count DESC);
+
+-- Country statistics
+CREATE TABLE country (
+    code TEXT NOT NULL,
+    month INTEGER NOT NULL,
+    count INTEGER NOT NULL DEFAULT 1,
+    PRIMARY KEY (code, month)
+);
+CREATE INDEX idx_country_month_code ON country(month, code);
+CREATE INDEX idx_country_month_count ON country(month, count DESC);
+
+-- Mirror statistics
+CREATE TABLE mirror (
+    url TEXT NOT NULL,
+    month INTEGER NOT NULL,
+    count INTEGER NOT NULL DEFAULT 1,
+    PRIMARY KEY (url, month)
+);
+CREATE INDEX idx_mirror_month_url ON mirror(month, url);
+CREATE INDEX idx_mirror_month_count ON mirror(month, count DESC);
+
+-- System architecture statistics (CPU architecture)
+CREATE TABLE system_architecture (
+    name TEXT NOT NULL,
+    month INTEGER NOT NULL,
+    count INTEGER NOT NULL DEFAULT 1,
+    PRIMARY KEY (name, month)
+);
+CREATE INDEX idx_system_architecture_month_name ON system_architecture(month, name);
+CREATE INDEX idx_system_architecture_month_count ON system_architecture(month, count DESC);
+
+-- Operating system architecture statistics
+CREATE TABLE operating_system_architecture (
+    name TEXT NOT NULL,
+    month INTEGER NOT NULL,
+    count INTEGER NOT NULL DEFAULT 1,
+    PRIMARY KEY (name, month)
+);
+CREATE INDEX idx_os_architecture_month_name ON operating_system_architecture(month, name);
+CREATE INDEX idx_os_architecture_month_count ON operating_system_architecture(month, count DESC);
+
+-- Operating system ID statistics (os-release ID)
+CREATE TABLE operating_system_id (
+    id TEXT NOT NULL,
+    month INTEGER NOT NULL,
+    count INTEGER NOT NULL DEFAULT 1,
+    PRIMARY KEY (id, month)
+);
+CREATE INDEX idx_operating_system_id_month_id ON operating_system_id(month, id);
+CREATE INDEX idx_operating_system_id_month_count ON operating_system_id(month, count DESC);
+
+-- Rate limiting table (sliding window)
+CREATE TABLE rate_limit (
+    id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    key TEXT NOT NULL,
+    timestamp BIGINT NOT NULL
+);
+CREATE INDEX idx_rate_limit_key_timestamp ON rate_limit(key, timestamp);
+
+-- Raw log of accepted submissions for abuse analysis and recovery.
+-- Rows are pruned after the retention window.
+CREATE TABLE submission_log (
+    id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    month INTEGER NOT NULL,
+    timestamp BIGINT NOT NULL,
+    ip TEXT NOT NULL,
+    headers TEXT NOT NULL,
+    payload TEXT NOT NULL,
+    payload_hash TEXT NOT NULL,
+    country TEXT NOT NULL
+);
+CREATE INDEX idx_submission_log_month ON submission_log(month);
+
+-- Short-lived fingerprints prevent accepted submissions from being counted
+-- again when a client retries after losing the response.
+CREATE TABLE submission_dedup (
+    fingerprint BYTEA PRIMARY KEY,
+    expires_at BIGINT NOT NULL
+);
+CREATE INDEX idx_submission_dedup_expires_at ON submission_dedup(expires_at);
+
+-- Yearly rollups of the count tables, filled by the rollup command.
+CREATE TABLE package_year (
+    name TEXT NOT NULL,
+    year INTEGER NOT NULL,
+    count BIGINT NOT NULL,
+    PRIMARY KEY (name, year)
+);
+CREATE INDEX idx_package_year_year_name ON package_year(year, name);
+
+CREATE TABLE country_year (
+    code TEXT NOT NULL,
+    year INTEGER NOT NULL,
+    count BIGINT NOT NULL,
+    PRIMARY KEY (code, year)
+);
+CREATE INDEX idx_country_year_year_code ON country_year(year, code);
+
+CREATE TABLE mirror_year (
+    url TEXT NOT NULL,
+    year INTEGER NOT NULL,
+    count BIGINT NOT NULL,
+    PRIMARY KEY (url, year)
+);
+CREATE INDEX idx_mirror_year_year_url ON mirror_year(year, url);
+
+CREATE TABLE system_architecture_year (
+    name TEXT NOT NULL,
+    year INTEGER NOT NULL,
+    count BIGINT NOT NULL,
+    PRIMARY KEY (name, year)
+);
+CREATE INDEX idx_system_architecture_year_year_name ON system_architecture_year(year, name);
+
+CREATE TABLE operating_system_architecture_year (
+    name TEXT NOT NULL,
+    year INTEGER NOT NULL,
+    count BIGINT NOT NULL,
+    PRIMARY KEY (name, year)
+);
+CREATE INDEX idx_os_architecture_year_year_name ON operating_system_architecture_year(year, name);
+
+CREATE TABLE operating_system_id_year (
+    id TEXT NOT NULL,
+    year INTEGER NOT NULL,
+    count BIGINT NOT NULL,
+    PRIMARY KEY (id, year)
+);
+CREATE INDEX idx_operating_system_id_year_year_id ON operating_system_id_year(year, id);
+
+-- Years whose rollup of a table is complete.
+CREATE TABLE rollup (
+    name TEXT NOT NULL,
+    year INTEGER NOT NULL,
+    PRIMARY KEY (name, year)
+);
@@ -0,0 +1,32 @@
+CREATE TABLE package_old (
+    name TEXT NOT NULL,
+    month INTEGER NOT NULL,
+    count INTEGER NOT NULL DEFAULT 1,
+    PRIMARY KEY (name, month)
+);
+INSERT INTO package_old (name, month, count)
+SELECT package_name.name, package.month, package.count
+FROM package JOIN package_name ON package_name.id = package.name_id;
+
+DROP TABLE package;
+ALTER TABLE package_old RENAME TO package;
+ALTER TABLE package RENAME CONSTRAINT package_old_pkey TO package_pkey;
+CREATE INDEX idx_package_month_name ON package(month, name);
+CREATE INDEX idx_package_month_count ON package(month, count DESC);
+
+CREATE TABLE package_year_old (
+    name TEXT NOT NULL,
+    year INTEGER NOT NULL,
+    count BIGINT NOT NULL,
+    PRIMARY KEY (name, year)
+);
+INSERT INTO package_year_old (name, year, count)
+SELECT package_name.name, package_year.year, package_year.count
+FROM package_year JOIN package_name ON package_name.id = package_year.name_id;
+
+DROP TABLE package_year;
+ALTER TABLE package_year_old RENAME TO package_year;
+ALTER TABLE package_year RENAME CONSTRAINT package_year_old_pkey TO package_year_pkey;
+CREATE INDEX idx_package_year_year_name ON package_year(year, name);
+
+DROP TABLE package_name;
@@ -0,0 +1,40 @@
+-- Package names are stored once in package_name and referenced by id, so
+-- the package rows and their indexes hold integers instead of repeating the
+-- name for every month.
+CREATE TABLE package_name (
+    id INTEGER GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE
+);
+INSERT INTO package_name (name)
+SELECT name FROM package UNION SELECT name FROM package_year ORDER BY name;
+
+CREATE TABLE package_new (
+    name_id INTEGER NOT NULL REFERENCES package_name(id),
+    month INTEGER NOT NULL,
+    count INTEGER NOT NULL DEFAULT 1,
+    PRIMARY KEY (name_id, month)
+);
+INSERT INTO package_new (name_id, month, count)
+SELECT package_name.id, package.month, package.count
+FROM package JOIN package_name ON package_name.name = package.name;
+
+DROP TABLE package;
+ALTER TABLE package_new RENAME TO package;
+ALTER TABLE package RENAME CONSTRAINT package_new_pkey TO package_pkey;
+CREATE INDEX idx_package_month_name_id ON package(month, name_id);
+CREATE INDEX idx_package_month_count ON package(month, count DESC);
+
+CREATE TABLE package_year_new (
+    name_id INTEGER NOT NULL REFERENCES package_name(id),
+    year INTEGER NOT NULL,
+    count BIGINT NOT NULL,
+    PRIMARY KEY (name_id, year)
+);
+INSERT INTO package_year_new (name_id, year, count)
+SELECT package_name.id, package_year.year, package_year.count
+FROM package_year JOIN package_name ON package_name.name = package_year.name;
+
+DROP TABLE package_year;
+ALTER TABLE package_year_new RENAME TO package_year;
+ALTER TABLE package_year RENAME CONSTRAINT package_year_new_pkey TO package_year_pkey;
+CREATE INDEX idx_package_year_year_name_id ON package_year(year, name_id);
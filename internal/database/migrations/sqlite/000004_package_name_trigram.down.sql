@@ -0,0 +1,2 @@
+DROP TRIGGER IF EXISTS package_name_trigram_insert;
+DROP TABLE IF EXISTS package_name_trigram;
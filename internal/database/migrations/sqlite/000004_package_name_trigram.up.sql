@@ -0,0 +1,12 @@
+-- Trigram index over distinct package names for fuzzy search.
+-- Names are added when their first package row is inserted; upserts of
+-- existing rows take the UPDATE path and do not fire the trigger.
+CREATE VIRTUAL TABLE package_name_trigram USING fts5(name, tokenize = 'trigram');
+
+INSERT INTO package_name_trigram (name) SELECT DISTINCT name FROM package;
+
+CREATE TRIGGER package_name_trigram_insert AFTER INSERT ON package
+WHEN NOT EXISTS (SELECT 1 FROM package WHERE name = NEW.name AND month <> NEW.month)
+BEGIN
+    INSERT INTO package_name_trigram (name) VALUES (NEW.name);
+END;
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS rollup;
+DROP TABLE IF EXISTS operating_system_id_year;
+DROP TABLE IF EXISTS operating_system_architecture_year;
+DROP TABLE IF EXISTS system_architecture_year;
+DROP TABLE IF EXISTS mirror_year;
+DROP TABLE IF EXISTS country_year;
+DROP TABLE IF EXISTS package_year;
@@ -0,0 +1,58 @@
+-- Yearly rollups of the count tables. Each row sums an identifier's counts
+-- over the twelve months of a complete year, so multi-year ranges read one
+-- row per year instead of twelve. Filled by the rollup command.
+CREATE TABLE package_year (
+    name TEXT NOT NULL,
+    year INTEGER NOT NULL,
+    count INTEGER NOT NULL,
+    PRIMARY KEY (name, year)
+);
+CREATE INDEX idx_package_year_year_name ON package_year(year, name);
+
+CREATE TABLE country_year (
+    code TEXT NOT NULL,
+    year INTEGER NOT NULL,
+    count INTEGER NOT NULL,
+    PRIMARY KEY (code, year)
+);
+CREATE INDEX idx_country_year_year_code ON country_year(year, code);
+
+CREATE TABLE mirror_year (
+    url TEXT NOT NULL,
+    year INTEGER NOT NULL,
+    count INTEGER NOT NULL,
+    PRIMARY KEY (url, year)
+);
+CREATE INDEX idx_mirror_year_year_url ON mirror_year(year, url);
+
+CREATE TABLE system_architecture_year (
+    name TEXT NOT NULL,
+    year INTEGER NOT NULL,
+    count INTEGER NOT NULL,
+    PRIMARY KEY (name, year)
+);
+CREATE INDEX idx_system_architecture_year_year_name ON system_architecture_year(year, name);
+
+CREATE TABLE operating_system_architecture_year (
+    name TEXT NOT NULL,
+    year INTEGER NOT NULL,
+    count INTEGER NOT NULL,
+    PRIMARY KEY (name, year)
+);
+CREATE INDEX idx_os_architecture_year_year_name ON operating_system_architecture_year(year, name);
+
+CREATE TABLE operating_system_id_year (
+    id TEXT NOT NULL,
+    year INTEGER NOT NULL,
+    count INTEGER NOT NULL,
+    PRIMARY KEY (id, year)
+);
+CREATE INDEX idx_operating_system_id_year_year_id ON operating_system_id_year(year, id);
+
+-- Years whose rollup of a table is complete. Queries only read rollup rows
+-- of years listed here and fall back to the monthly rows otherwise.
+CREATE TABLE rollup (
+    name TEXT NOT NULL,
+    year INTEGER NOT NULL,
+    PRIMARY KEY (name, year)
+);
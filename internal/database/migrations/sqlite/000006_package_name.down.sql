@@ -0,0 +1,42 @@
+DROP TRIGGER IF EXISTS package_name_trigram_insert;
+DROP TABLE IF EXISTS package_name_trigram;
+
+CREATE TABLE package_old (
+    name TEXT NOT NULL,
+    month INTEGER NOT NULL,
+    count INTEGER NOT NULL DEFAULT 1,
+    PRIMARY KEY (name, month)
+);
+INSERT INTO package_old (name, month, count)
+SELECT package_name.name, package.month, package.count
+FROM package JOIN package_name ON package_name.id = package.name_id;
+
+DROP TABLE package;
+ALTER TABLE package_old RENAME TO package;
+CREATE INDEX idx_package_month_name ON package(month, name);
+CREATE INDEX idx_package_month_count ON package(month, count DESC);
+
+CREATE TABLE package_year_old (
+    name TEXT NOT NULL,
+    year INTEGER NOT NULL,
+    count INTEGER NOT NULL,
+    PRIMARY KEY (name, year)
+);
+INSERT INTO package_year_old (name, year, count)
+SELECT package_name.name, package_year.year, package_year.count
+FROM package_year JOIN package_name ON package_name.id = package_year.name_id;
+
+DROP TABLE package_year;
+ALTER TABLE package_year_old RENAME TO package_year;
+CREATE INDEX idx_package_year_year_name ON package_year(year, name);
+
+DROP TABLE package_name;
+
+CREATE VIRTUAL TABLE package_name_trigram USING fts5(name, tokenize = 'trigram');
+INSERT INTO package_name_trigram (name) SELECT DISTINCT name FROM package;
+
+CREATE TRIGGER package_name_trigram_insert AFTER INSERT ON package
+WHEN NOT EXISTS (SELECT 1 FROM package WHERE name = NEW.name AND month <> NEW.month)
+BEGIN
+    INSERT INTO package_name_trigram (name) VALUES (NEW.name);
+END;
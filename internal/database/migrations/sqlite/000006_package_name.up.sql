@@ -0,0 +1,53 @@
+-- Package names are stored once in package_name and referenced by id, so
+-- the package rows and their indexes hold integers instead of repeating the
+-- name for every month.
+CREATE TABLE package_name (
+    id INTEGER PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE
+);
+INSERT INTO package_name (name)
+SELECT name FROM package UNION SELECT name FROM package_year ORDER BY name;
+
+CREATE TABLE package_new (
+    name_id INTEGER NOT NULL REFERENCES package_name(id),
+    month INTEGER NOT NULL,
+    count INTEGER NOT NULL DEFAULT 1,
+    PRIMARY KEY (name_id, month)
+) WITHOUT ROWID;
+INSERT INTO package_new (name_id, month, count)
+SELECT package_name.id, package.month, package.count
+FROM package JOIN package_name ON package_name.name = package.name;
+
+DROP TRIGGER package_name_trigram_insert;
+DROP TABLE package;
+ALTER TABLE package_new RENAME TO package;
+CREATE INDEX idx_package_month_name_id ON package(month, name_id);
+CREATE INDEX idx_package_month_count ON package(month, count DESC);
+
+CREATE TABLE package_year_new (
+    name_id INTEGER NOT NULL REFERENCES package_name(id),
+    year INTEGER NOT NULL,
+    count INTEGER NOT NULL,
+    PRIMARY KEY (name_id, year)
+) WITHOUT ROWID;
+INSERT INTO package_year_new (name_id, year, count)
+SELECT package_name.id, package_year.year, package_year.count
+FROM package_year JOIN package_name ON package_name.name = package_year.name;
+
+DROP TABLE package_year;
+ALTER TABLE package_year_new RENAME TO package_year;
+CREATE INDEX idx_package_year_year_name_id ON package_year(year, name_id);
+
+-- The trigram index reads the names from package_name instead of keeping a
+-- copy. Names are never changed or removed, so only inserts need to be
+-- mirrored into it.
+DROP TABLE package_name_trigram;
+CREATE VIRTUAL TABLE package_name_trigram USING fts5(
+    name, content = 'package_name', content_rowid = 'id', tokenize = 'trigram'
+);
+INSERT INTO package_name_trigram (package_name_trigram) VALUES ('rebuild');
+
+CREATE TRIGGER package_name_trigram_insert AFTER INSERT ON package_name
+BEGIN
+    INSERT INTO package_name_trigram (rowid, name) VALUES (NEW.id, NEW.name);
+END;
@@ -0,0 +1,9 @@
+-- A single row counting changes to existing data, such as imports into
+-- months that were already served. Servers poll it to expire their caches
+-- and validators; submissions to the current month are not counted.
+CREATE TABLE data_version (
+    id INTEGER PRIMARY KEY CHECK (id = 1),
+    version INTEGER NOT NULL,
+    updated_at INTEGER NOT NULL
+);
+INSERT INTO data_version (id, version, updated_at) VALUES (1, 0, 0);
@@ -0,0 +1,56 @@
+package database
+
+import (
+	"os"
+	"testing"
+)
+
+// TestOpen_Postgres runs against the server in POSTGRES_TEST_URL, e.g.
+// postgres://postgres@localhost/pkgstats_test?sslmode=disable, and is
+// skipped without it.
+func TestOpen_Postgres(t *testing.T) {
+	dsn := os.Getenv("POSTGRES_TEST_URL")
+	if dsn == "" {
+		t.Skip("POSTGRES_TEST_URL not set")
+	}
+
+	pools, err := Open(dsn)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer func() { _ = pools.Close() }()
+
+	if err := Migrate(pools.Writer); err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+
+	if pools.Reader != pools.Writer {
+		t.Error("expected PostgreSQL pools to share one connection pool")
+	}
+	if d := DialectOf(pools.Writer); d != Postgres {
+		t.Errorf("expected postgres dialect, got %s", d)
+	}
+
+	for _, table := range []string{"package", "package_name", "country", "mirror", "rate_limit", "submission_log", "submission_dedup", "package_year", "rollup"} {
+		var exists bool
+		if err := pools.Reader.QueryRow(`SELECT to_regclass($1) IS NOT NULL`, table).Scan(&exists); err != nil {
+			t.Fatalf("query table %s: %v", table, err)
+		}
+		if !exists {
+			t.Errorf("table %s does not exist", table)
+		}
+	}
+
+	// Migrations are idempotent.
+	if err := Migrate(pools.Writer); err != nil {
+		t.Fatalf("second Migrate() error = %v", err)
+	}
+	if err := CheckMigrations(t.Context(), pools.Reader); err != nil {
+		t.Errorf("CheckMigrations() error = %v", err)
+	}
+
+	// The migrators release their connections.
+	if inUse := pools.Writer.Stats().InUse; inUse != 0 {
+		t.Errorf("expected no connections in use after migrating, got %d", inUse)
+	}
+}
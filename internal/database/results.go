@@ -0,0 +1,240 @@
+package database
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"pkgstatsd/internal/tracing"
+)
+
+// cacheHitKey records on repository spans whether the result was cached.
+const cacheHitKey = attribute.Key("pkgstatsd.cache.hit")
+
+// ResultCache is a bounded LRU cache for query results. Each entry records
+// the month range and invalidation generation it was computed at, so writes
+// can invalidate the entries covering the written month while results for
+// completed months stay cached. Invalidated entries, and entries loaded under
+// another data version, are dropped when read. A nil *ResultCache disables
+// caching.
+type ResultCache struct {
+	capacity int
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List
+	// generation counts invalidations; invalidated records the generation
+	// at which each month was last written, so results computed before or
+	// concurrently with a write are neither served nor stored.
+	generation  uint64
+	invalidated map[int]uint64
+
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+type resultEntry struct {
+	key                  string
+	startMonth, endMonth int
+	generation           uint64
+	version              int64
+	value                any
+}
+
+// ResultCacheStats reports the hits and misses since the cache was created.
+type ResultCacheStats struct {
+	Hits    uint64
+	Misses  uint64
+	Entries int
+}
+
+// HitRate returns the share of lookups served from the cache.
+func (s ResultCacheStats) HitRate() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+// NewResultCache creates a cache holding up to capacity results, or returns
+// nil to disable caching if capacity is not positive.
+func NewResultCache(capacity int) *ResultCache {
+	if capacity <= 0 {
+		return nil
+	}
+
+	return &ResultCache{
+		capacity:    capacity,
+		entries:     make(map[string]*list.Element),
+		order:       list.New(),
+		invalidated: make(map[int]uint64),
+	}
+}
+
+// CacheKey builds a result cache key from a method name and its arguments.
+// Arguments are JSON encoded, so pointers are keyed by the value they point
+// to.
+func CacheKey(method string, args ...any) string {
+	b, err := json.Marshal(args)
+	if err != nil {
+		panic("database: unencodable cache key argument: " + err.Error())
+	}
+
+	return method + string(b)
+}
+
+// Cached returns the result cached under key, or calls load and caches its
+// result for the months startMonth to endMonth. A startMonth of 0 means no
+// lower bound. Cached results are shared and must not be modified. The call
+// is traced in a span named after method, the repository method key was
+// built for.
+func Cached[T any](ctx context.Context, c *ResultCache, method, key string, startMonth, endMonth int, load func(ctx context.Context) (T, error)) (value T, err error) {
+	ctx, span := tracing.Start(ctx, method)
+	defer func() { tracing.End(span, err) }()
+
+	if c == nil {
+		return load(ctx)
+	}
+
+	if value, ok := c.get(key); ok {
+		span.SetAttributes(cacheHitKey.Bool(true))
+		return value.(T), nil
+	}
+	span.SetAttributes(cacheHitKey.Bool(false))
+
+	generation := c.currentGeneration()
+	version := CurrentDataVersion().Version
+	value, err = load(ctx)
+	if err != nil {
+		return value, err
+	}
+
+	c.put(key, startMonth, endMonth, version, value, generation)
+
+	return value, nil
+}
+
+// InvalidateMonth invalidates all results covering month. Call it after
+// writing to month. It takes constant time; the results are dropped when
+// next read or evicted.
+func (c *ResultCache) InvalidateMonth(month int) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	c.invalidated[month] = c.generation
+}
+
+// Stats returns the cache's hit and miss counters and its current size.
+func (c *ResultCache) Stats() ResultCacheStats {
+	if c == nil {
+		return ResultCacheStats{}
+	}
+
+	c.mu.Lock()
+	entries := c.order.Len()
+	c.mu.Unlock()
+
+	return ResultCacheStats{Hits: c.hits.Load(), Misses: c.misses.Load(), Entries: entries}
+}
+
+// LogStats logs the cache statistics every interval. It blocks and is meant
+// to run in its own goroutine for the lifetime of the process.
+func (c *ResultCache) LogStats(interval time.Duration) {
+	if c == nil {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		slog.Info("result cache", "stats", c.Stats())
+	}
+}
+
+func (c *ResultCache) get(key string) (any, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if ok && c.stale(e.Value.(*resultEntry)) {
+		c.order.Remove(e)
+		delete(c.entries, key)
+		ok = false
+	}
+	if !ok {
+		c.misses.Add(1)
+		return nil, false
+	}
+
+	c.hits.Add(1)
+	c.order.MoveToFront(e)
+
+	return e.Value.(*resultEntry).value, true
+}
+
+func (c *ResultCache) currentGeneration() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.generation
+}
+
+func (c *ResultCache) put(key string, startMonth, endMonth int, version int64, value any, generation uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.invalidatedSince(startMonth, endMonth, generation) {
+		return
+	}
+
+	entry := &resultEntry{key: key, startMonth: startMonth, endMonth: endMonth, generation: generation, version: version, value: value}
+	if e, ok := c.entries[key]; ok {
+		e.Value = entry
+		c.order.MoveToFront(e)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(entry)
+	if c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*resultEntry).key)
+	}
+}
+
+// stale reports whether entry was loaded under another data version or
+// before a write to a month it covers. c.mu must be held.
+func (c *ResultCache) stale(entry *resultEntry) bool {
+	return entry.version != CurrentDataVersion().Version ||
+		c.invalidatedSince(entry.startMonth, entry.endMonth, entry.generation)
+}
+
+// invalidatedSince reports whether a month from startMonth to endMonth was
+// written after generation. It walks the written months, of which there are
+// few. c.mu must be held.
+func (c *ResultCache) invalidatedSince(startMonth, endMonth int, generation uint64) bool {
+	for month, invalidatedAt := range c.invalidated {
+		if invalidatedAt > generation && covers(startMonth, endMonth, month) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func covers(startMonth, endMonth, month int) bool {
+	return (startMonth == 0 || startMonth <= month) && month <= endMonth
+}
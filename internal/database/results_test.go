@@ -0,0 +1,188 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func loadCounting(calls *int, value int) func(context.Context) (int, error) {
+	return func(context.Context) (int, error) {
+		*calls++
+		return value, nil
+	}
+}
+
+func TestResultCache_HitsAndMisses(t *testing.T) {
+	cache := NewResultCache(10)
+	calls := 0
+
+	for range 3 {
+		value, err := Cached(context.Background(), cache, "test.Find", CacheKey("test.Find", "a"), 202501, 202502, loadCounting(&calls, 42))
+		if err != nil {
+			t.Fatalf("Cached error: %v", err)
+		}
+		if value != 42 {
+			t.Errorf("expected 42, got %d", value)
+		}
+	}
+
+	if calls != 1 {
+		t.Errorf("expected 1 load, got %d", calls)
+	}
+
+	stats := cache.Stats()
+	if stats.Hits != 2 || stats.Misses != 1 || stats.Entries != 1 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+	if rate := stats.HitRate(); rate < 0.66 || rate > 0.67 {
+		t.Errorf("expected hit rate 2/3, got %f", rate)
+	}
+}
+
+func TestResultCache_ErrorsAreNotCached(t *testing.T) {
+	cache := NewResultCache(10)
+	calls := 0
+	load := func(context.Context) (int, error) {
+		calls++
+		return 0, errors.New("boom")
+	}
+
+	for range 2 {
+		if _, err := Cached(context.Background(), cache, "test", "key", 202501, 202501, load); err == nil {
+			t.Fatal("expected error, got nil")
+		}
+	}
+
+	if calls != 2 {
+		t.Errorf("expected 2 loads, got %d", calls)
+	}
+}
+
+func TestResultCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	cache := NewResultCache(2)
+	calls := 0
+
+	_, _ = Cached(context.Background(), cache, "test", "a", 202501, 202501, loadCounting(&calls, 1))
+	_, _ = Cached(context.Background(), cache, "test", "b", 202501, 202501, loadCounting(&calls, 2))
+	_, _ = Cached(context.Background(), cache, "test", "a", 202501, 202501, loadCounting(&calls, 1))
+	_, _ = Cached(context.Background(), cache, "test", "c", 202501, 202501, loadCounting(&calls, 3))
+
+	if calls != 3 {
+		t.Fatalf("expected 3 loads, got %d", calls)
+	}
+
+	// "b" was least recently used and must have been evicted.
+	_, _ = Cached(context.Background(), cache, "test", "a", 202501, 202501, loadCounting(&calls, 1))
+	if calls != 3 {
+		t.Errorf("expected a to be cached, got %d loads", calls)
+	}
+	_, _ = Cached(context.Background(), cache, "test", "b", 202501, 202501, loadCounting(&calls, 2))
+	if calls != 4 {
+		t.Errorf("expected b to be reloaded, got %d loads", calls)
+	}
+}
+
+func TestResultCache_InvalidateMonth(t *testing.T) {
+	cache := NewResultCache(10)
+	calls := 0
+
+	_, _ = Cached(context.Background(), cache, "test", "past", 202501, 202502, loadCounting(&calls, 1))
+	_, _ = Cached(context.Background(), cache, "test", "current", 202501, 202503, loadCounting(&calls, 2))
+	_, _ = Cached(context.Background(), cache, "test", "unbounded", 0, 202503, loadCounting(&calls, 3))
+
+	cache.InvalidateMonth(202503)
+
+	calls = 0
+	_, _ = Cached(context.Background(), cache, "test", "past", 202501, 202502, loadCounting(&calls, 1))
+	_, _ = Cached(context.Background(), cache, "test", "current", 202501, 202503, loadCounting(&calls, 2))
+	_, _ = Cached(context.Background(), cache, "test", "unbounded", 0, 202503, loadCounting(&calls, 3))
+
+	if calls != 2 {
+		t.Errorf("expected 2 reloads, got %d", calls)
+	}
+	if entries := cache.Stats().Entries; entries != 3 {
+		t.Errorf("expected the reloaded results to replace the invalidated ones, got %d entries", entries)
+	}
+
+	calls = 0
+	_, _ = Cached(context.Background(), cache, "test", "current", 202501, 202503, loadCounting(&calls, 2))
+	if calls != 0 {
+		t.Errorf("expected the reloaded result to be cached, got %d loads", calls)
+	}
+}
+
+func TestResultCache_SkipsResultsRacingWrites(t *testing.T) {
+	cache := NewResultCache(10)
+
+	_, _ = Cached(context.Background(), cache, "test", "racing", 202503, 202503, func(context.Context) (int, error) {
+		// A write lands while the result is being computed.
+		cache.InvalidateMonth(202503)
+		return 1, nil
+	})
+	_, _ = Cached(context.Background(), cache, "test", "other", 202502, 202502, func(context.Context) (int, error) {
+		cache.InvalidateMonth(202503)
+		return 2, nil
+	})
+
+	calls := 0
+	_, _ = Cached(context.Background(), cache, "test", "racing", 202503, 202503, loadCounting(&calls, 1))
+	_, _ = Cached(context.Background(), cache, "test", "other", 202502, 202502, loadCounting(&calls, 2))
+
+	if calls != 1 {
+		t.Errorf("expected only the racing result to be reloaded, got %d loads", calls)
+	}
+}
+
+func TestResultCache_DataVersion(t *testing.T) {
+	setDataVersion(t, DataVersion{Version: 1})
+	cache := NewResultCache(10)
+	calls := 0
+
+	_, _ = Cached(context.Background(), cache, "test", "past", 202501, 202502, loadCounting(&calls, 1))
+	setDataVersion(t, DataVersion{Version: 2})
+	_, _ = Cached(context.Background(), cache, "test", "past", 202501, 202502, loadCounting(&calls, 1))
+	_, _ = Cached(context.Background(), cache, "test", "past", 202501, 202502, loadCounting(&calls, 1))
+
+	if calls != 2 {
+		t.Errorf("expected one reload after the data version changed, got %d loads", calls)
+	}
+	if entries := cache.Stats().Entries; entries != 1 {
+		t.Errorf("expected the stale entry to be replaced, got %d entries", entries)
+	}
+}
+
+func TestResultCache_Nil(t *testing.T) {
+	if cache := NewResultCache(0); cache != nil {
+		t.Fatalf("expected nil cache for capacity 0, got %v", cache)
+	}
+
+	var cache *ResultCache
+	calls := 0
+
+	for range 2 {
+		_, _ = Cached(context.Background(), cache, "test", "key", 202501, 202501, loadCounting(&calls, 1))
+	}
+	cache.InvalidateMonth(202501)
+
+	if calls != 2 {
+		t.Errorf("expected 2 loads without cache, got %d", calls)
+	}
+	if stats := cache.Stats(); stats != (ResultCacheStats{}) {
+		t.Errorf("expected empty stats, got %+v", stats)
+	}
+}
+
+func TestCacheKey(t *testing.T) {
+	a, b := 1, 1
+
+	if CacheKey("m", &a, "x") != CacheKey("m", &b, "x") {
+		t.Error("expected pointers to equal values to share a key")
+	}
+	if CacheKey("m", 1, "x") == CacheKey("n", 1, "x") {
+		t.Error("expected method names to be part of the key")
+	}
+	if CacheKey("m", 12, "3") == CacheKey("m", 1, "23") {
+		t.Error("expected argument boundaries to be part of the key")
+	}
+}
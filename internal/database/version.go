@@ -0,0 +1,95 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+// DataVersion counts changes to data that is cached as final, such as
+// imports into months that were already served. It is kept in the
+// database, so every replica sees the same version across restarts.
+type DataVersion struct {
+	Version int64
+	// Modified is when the version last changed, or the zero time if it
+	// never did.
+	Modified time.Time
+}
+
+// currentDataVersion is the version last read by WatchDataVersion. Caches
+// holding final data record it on load and reload once it changed.
+var currentDataVersion atomic.Pointer[DataVersion]
+
+// CurrentDataVersion returns the version last read by WatchDataVersion, or
+// the zero version if it is not running.
+func CurrentDataVersion() DataVersion {
+	if v := currentDataVersion.Load(); v != nil {
+		return *v
+	}
+
+	return DataVersion{}
+}
+
+// ReadDataVersion reads the data version from db.
+func ReadDataVersion(ctx context.Context, db *sql.DB) (DataVersion, error) {
+	var version, updatedAt int64
+	if err := db.QueryRowContext(ctx, `SELECT version, updated_at FROM data_version WHERE id = 1`).Scan(&version, &updatedAt); err != nil {
+		return DataVersion{}, fmt.Errorf("query data version: %w", err)
+	}
+
+	v := DataVersion{Version: version}
+	if updatedAt > 0 {
+		v.Modified = time.Unix(updatedAt, 0).UTC()
+	}
+
+	return v, nil
+}
+
+// BumpDataVersion increments the data version in tx. Call it in the
+// transaction changing data of months that may already have been served.
+func BumpDataVersion(ctx context.Context, tx *sql.Tx, dialect Dialect) error {
+	if _, err := tx.ExecContext(ctx, dialect.Rebind(`UPDATE data_version SET version = version + 1, updated_at = ? WHERE id = 1`), time.Now().Unix()); err != nil {
+		return fmt.Errorf("bump data version: %w", err)
+	}
+
+	return nil
+}
+
+// WatchDataVersion reads the data version from db and then polls it every
+// interval until ctx is done, updating CurrentDataVersion. Only the first
+// read fails; later failures are logged and keep the last version.
+func WatchDataVersion(ctx context.Context, db *sql.DB, interval time.Duration) error {
+	v, err := ReadDataVersion(ctx, db)
+	if err != nil {
+		return err
+	}
+	currentDataVersion.Store(&v)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			v, err := ReadDataVersion(ctx, db)
+			if err != nil {
+				slog.Warn("failed to read data version", "error", err)
+				continue
+			}
+			if v.Version != CurrentDataVersion().Version {
+				slog.Info("data version changed", "version", v.Version)
+			}
+			currentDataVersion.Store(&v)
+		}
+	}()
+
+	return nil
+}
@@ -0,0 +1,88 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// setDataVersion makes v the current data version for the rest of the test.
+func setDataVersion(t *testing.T, v DataVersion) {
+	t.Helper()
+
+	previous := currentDataVersion.Load()
+	currentDataVersion.Store(&v)
+	t.Cleanup(func() { currentDataVersion.Store(previous) })
+}
+
+func TestDataVersion(t *testing.T) {
+	db, err := New(":memory:")
+	if err != nil {
+		t.Fatalf("New error: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	ctx := context.Background()
+	v, err := ReadDataVersion(ctx, db)
+	if err != nil {
+		t.Fatalf("ReadDataVersion error: %v", err)
+	}
+	if v.Version != 0 || !v.Modified.IsZero() {
+		t.Errorf("expected the initial version, got %+v", v)
+	}
+
+	setDataVersion(t, DataVersion{})
+	if err := WatchDataVersion(t.Context(), db, time.Hour); err != nil {
+		t.Fatalf("WatchDataVersion error: %v", err)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("begin transaction: %v", err)
+	}
+	if err := BumpDataVersion(ctx, tx, SQLite); err != nil {
+		t.Fatalf("BumpDataVersion error: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("commit transaction: %v", err)
+	}
+
+	v, err = ReadDataVersion(ctx, db)
+	if err != nil {
+		t.Fatalf("ReadDataVersion error: %v", err)
+	}
+	if v.Version != 1 || time.Since(v.Modified) > time.Minute {
+		t.Errorf("expected version 1 modified just now, got %+v", v)
+	}
+	if current := CurrentDataVersion(); current.Version != 0 {
+		t.Errorf("expected the watched version to change only when polled, got %+v", current)
+	}
+}
+
+func TestMonthlySamplesCache_DataVersion(t *testing.T) {
+	db, err := New(":memory:")
+	if err != nil {
+		t.Fatalf("New error: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	_, _ = db.Exec(`CREATE TABLE test_samples (month INTEGER, count INTEGER)`)
+	_, _ = db.Exec(`INSERT INTO test_samples (month, count) VALUES (202501, 100)`)
+
+	setDataVersion(t, DataVersion{Version: 1})
+	cache := NewMonthlySamplesCache(db, "SELECT month, count FROM test_samples")
+	if _, err := cache.Get(context.Background(), 202501, 202501); err != nil {
+		t.Fatalf("Get error: %v", err)
+	}
+
+	_, _ = db.Exec(`UPDATE test_samples SET count = 300 WHERE month = 202501`)
+	setDataVersion(t, DataVersion{Version: 2})
+
+	res, err := cache.Get(context.Background(), 202501, 202501)
+	if err != nil {
+		t.Fatalf("Get error: %v", err)
+	}
+	if res[202501] != 300 {
+		t.Errorf("expected value 300 after the data version changed, got %d", res[202501])
+	}
+}
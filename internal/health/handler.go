@@ -0,0 +1,75 @@
+// Package health serves the liveness and readiness probes of the server.
+package health
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"pkgstatsd/internal/web"
+)
+
+const (
+	statusOK          = "ok"
+	statusReady       = "ready"
+	statusUnavailable = "unavailable"
+
+	checkTimeout = 5 * time.Second
+)
+
+// Check is a dependency that has to be available before the server can take
+// traffic. Run returns why it is not.
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+type Handler struct {
+	checks []Check
+}
+
+func NewHandler(checks ...Check) *Handler {
+	return &Handler{checks: checks}
+}
+
+// ReadinessResponse reports the overall status and the result of each check,
+// "ok" or the error it returned.
+type ReadinessResponse struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
+
+// HandleHealth reports that the process is alive and serving requests.
+func (h *Handler) HandleHealth(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	web.WriteJSON(w, http.StatusOK, map[string]string{"status": statusOK})
+}
+
+// HandleReady runs all checks and responds with 503 if any of them fails.
+func (h *Handler) HandleReady(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), checkTimeout)
+	defer cancel()
+
+	response := ReadinessResponse{Status: statusReady, Checks: make(map[string]string, len(h.checks))}
+	status := http.StatusOK
+
+	for _, check := range h.checks {
+		if err := check.Run(ctx); err != nil {
+			slog.Warn("readiness check failed", "check", check.Name, "error", err)
+			response.Checks[check.Name] = err.Error()
+			response.Status = statusUnavailable
+			status = http.StatusServiceUnavailable
+			continue
+		}
+		response.Checks[check.Name] = statusOK
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	web.WriteJSON(w, status, response)
+}
+
+func (h *Handler) RegisterRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("GET /healthz", h.HandleHealth)
+	mux.HandleFunc("GET /readyz", h.HandleReady)
+}
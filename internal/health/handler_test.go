@@ -0,0 +1,78 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func serve(t *testing.T, handler *Handler, path string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+
+	if cc := rec.Header().Get("Cache-Control"); cc != "no-store" {
+		t.Errorf("expected Cache-Control no-store, got %q", cc)
+	}
+
+	return rec
+}
+
+func TestHandleHealth(t *testing.T) {
+	failing := Check{Name: "database", Run: func(context.Context) error { return errors.New("unreachable") }}
+
+	rec := serve(t, NewHandler(failing), "/healthz")
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected 200 regardless of checks, got %d", rec.Code)
+	}
+}
+
+func TestHandleReady(t *testing.T) {
+	ok := Check{Name: "database", Run: func(context.Context) error { return nil }}
+	failing := Check{Name: "caches", Run: func(context.Context) error { return errors.New("caches not warmed up") }}
+
+	tests := []struct {
+		name     string
+		checks   []Check
+		code     int
+		response ReadinessResponse
+	}{
+		{"ready", []Check{ok}, http.StatusOK, ReadinessResponse{
+			Status: "ready",
+			Checks: map[string]string{"database": "ok"},
+		}},
+		{"unavailable", []Check{ok, failing}, http.StatusServiceUnavailable, ReadinessResponse{
+			Status: "unavailable",
+			Checks: map[string]string{"database": "ok", "caches": "caches not warmed up"},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(t, NewHandler(tt.checks...), "/readyz")
+			if rec.Code != tt.code {
+				t.Errorf("expected %d, got %d", tt.code, rec.Code)
+			}
+
+			var response ReadinessResponse
+			if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if response.Status != tt.response.Status {
+				t.Errorf("expected status %q, got %q", tt.response.Status, response.Status)
+			}
+			for name, want := range tt.response.Checks {
+				if got := response.Checks[name]; got != want {
+					t.Errorf("expected check %s to be %q, got %q", name, want, got)
+				}
+			}
+		})
+	}
+}
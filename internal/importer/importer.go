@@ -0,0 +1,479 @@
+package importer
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"pkgstatsd/internal/config"
+	"pkgstatsd/internal/database"
+	"pkgstatsd/internal/rollup"
+	"pkgstatsd/internal/web"
+)
+
+// identifierField names the identifier in input files regardless of the
+// table's own column name, which is accepted as well.
+const identifierField = "identifier"
+
+// columns maps the aggregate tables to their identifier columns, as named in
+// input files.
+var columns = map[string]string{
+	"package":                       "name",
+	"country":                       "code",
+	"mirror":                        "url",
+	"system_architecture":           "name",
+	"operating_system_architecture": "name",
+	"operating_system_id":           "id",
+}
+
+// dictionaries maps the tables that reference their identifiers by id to the
+// table holding the identifiers. The referencing column is the identifier
+// column with an _id suffix.
+var dictionaries = map[string]string{
+	"package": "package_name",
+}
+
+// lowercase lists the tables whose identifiers submissions store in lower
+// case; imported identifiers are normalized the same way.
+var lowercase = map[string]bool{
+	"package":             true,
+	"operating_system_id": true,
+}
+
+// Conflict policies for rows that already exist for an identifier and month.
+const (
+	PolicyReplace = "replace"
+	PolicyAdd     = "add"
+	PolicySkip    = "skip"
+)
+
+type (
+	// Record is one monthly count of an identifier.
+	Record struct {
+		Identifier string
+		Month      int
+		Count      int
+	}
+
+	// Summary counts what an import did, or would do in a dry run.
+	Summary struct {
+		Read     int
+		Inserted int
+		// Updated counts existing rows that were replaced or added to.
+		Updated int
+		// Skipped counts existing rows left alone by PolicySkip.
+		Skipped    int
+		FirstMonth int
+		LastMonth  int
+		// RolledUpYears lists the affected years whose rollup is rebuilt.
+		RolledUpYears []int
+	}
+
+	// ReadFunc reads records from a source and passes each to add.
+	ReadFunc func(add func(Record) error) error
+)
+
+// Run executes the import subcommand. It loads monthly counts from CSV or
+// JSON files into one of the aggregate tables and returns the process exit
+// code.
+func Run(args []string, cfg config.Config) int {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	formatFlag := fs.String("format", "csv", "Input format: csv or json")
+	tableFlag := fs.String("table", "package", "Table to import into: "+strings.Join(slices.Sorted(maps.Keys(columns)), ", "))
+	conflictFlag := fs.String("on-conflict", PolicySkip, "What to do with existing rows: replace, add or skip")
+	dryRunFlag := fs.Bool("dry-run", false, "Report what would change without writing")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: pkgstatsd import [flags] <files>")
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+
+	column, ok := columns[*tableFlag]
+	if !ok || fs.NArg() == 0 || !validPolicy(*conflictFlag) || (*formatFlag != "csv" && *formatFlag != "json") {
+		fs.Usage()
+		return 2
+	}
+
+	db, err := database.Connect(cfg.Database)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	defer func() { _ = db.Close() }()
+
+	// Migrations are left to the server and the migrate subcommand.
+	if err := database.CheckMigrations(context.Background(), db); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	read := func(add func(Record) error) error {
+		for _, path := range fs.Args() {
+			if err := readFile(path, *formatFlag, column, add); err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+		}
+		return nil
+	}
+
+	summary, err := Import(context.Background(), db, *tableFlag, *conflictFlag, *dryRunFlag, read)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	printSummary(*tableFlag, *conflictFlag, *dryRunFlag, summary)
+	return 0
+}
+
+// Import writes the records from read into table, resolving existing rows
+// with policy. read is called twice: all records are validated before the
+// first is written, so an invalid record leaves the tables unchanged. They
+// are then written in transactions of batchSize records, so the SQLite write
+// lock is never held long enough for concurrent submissions to time out.
+// Each batch is rolled back with dryRun. Rollups of affected years that had
+// been rolled up are rebuilt afterwards in a final transaction, which also
+// increments the data version. If a batch fails, the error reports how many
+// records were committed before it and the final transaction still runs for
+// them.
+func Import(ctx context.Context, db *sql.DB, table, policy string, dryRun bool, read ReadFunc) (*Summary, error) {
+	column, ok := columns[table]
+	if !ok {
+		return nil, fmt.Errorf("unknown table %q", table)
+	}
+	if !validPolicy(policy) {
+		return nil, fmt.Errorf("unknown conflict policy %q", policy)
+	}
+
+	n := 0
+	if err := read(func(record Record) error {
+		n++
+		if err := validate(record); err != nil {
+			return fmt.Errorf("record %d: %w", n, err)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	w, err := newWriter(db, table, column, policy)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = w.close() }()
+
+	summary := &Summary{}
+	years := make(map[int]bool)
+	var current *batch
+
+	err = read(func(record Record) error {
+		summary.Read++
+		if lowercase[table] {
+			record.Identifier = strings.ToLower(record.Identifier)
+		}
+
+		if current == nil {
+			b, err := w.begin(ctx)
+			if err != nil {
+				return err
+			}
+			current = b
+		}
+
+		result, err := current.write(ctx, record)
+		if err != nil {
+			return fmt.Errorf("write record %d: %w", summary.Read, err)
+		}
+
+		switch result {
+		case inserted:
+			summary.Inserted++
+		case skipped:
+			summary.Skipped++
+		case updated:
+			summary.Updated++
+		}
+
+		if result != skipped {
+			if summary.FirstMonth == 0 || record.Month < summary.FirstMonth {
+				summary.FirstMonth = record.Month
+			}
+			summary.LastMonth = max(summary.LastMonth, record.Month)
+			year, _ := web.SplitYearMonth(record.Month)
+			years[year] = true
+		}
+
+		if current.records < batchSize {
+			return nil
+		}
+		b := current
+		current = nil
+		return b.end(dryRun)
+	})
+	if current != nil {
+		if err == nil {
+			err = current.end(dryRun)
+		} else {
+			_ = current.end(true)
+		}
+	}
+
+	if err != nil && w.committed > 0 {
+		err = fmt.Errorf("%w; the %d records before it were committed", err, w.committed)
+	}
+	if err == nil || w.changed {
+		if finishErr := finish(ctx, db, table, dryRun, w.changed, summary, years); err == nil {
+			err = finishErr
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return summary, nil
+}
+
+// finish rebuilds the rollups of the affected years that had been rolled up
+// and, if changed is set, increments the data version in one transaction.
+// It lists the years in summary, and with dryRun rolls back.
+func finish(ctx context.Context, db *sql.DB, table string, dryRun, changed bool, summary *Summary, years map[int]bool) error {
+	dialect := database.DialectOf(db)
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if summary.RolledUpYears, err = rolledUpYears(ctx, tx, dialect, table, years); err != nil {
+		return err
+	}
+
+	if dryRun {
+		return nil
+	}
+
+	for _, year := range summary.RolledUpYears {
+		if err := rollup.Rollup(ctx, tx, dialect, year); err != nil {
+			return fmt.Errorf("rebuild rollup of %d: %w", year, err)
+		}
+	}
+
+	// Servers reload their caches of complete months once they see the new
+	// data version.
+	if changed {
+		if err := database.BumpDataVersion(ctx, tx, dialect); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// updateQuery returns the statement resolving a conflict with an existing
+// row according to policy. identifier is the expression column is matched
+// against.
+func updateQuery(table, column, identifier, policy string) string {
+	if policy == PolicyAdd {
+		return `UPDATE ` + table + ` SET count = count + ? WHERE ` + column + ` = ` + identifier + ` AND month = ?`
+	}
+
+	return `UPDATE ` + table + ` SET count = ? WHERE ` + column + ` = ` + identifier + ` AND month = ?`
+}
+
+// rolledUpYears returns the years of years that have a rollup of table, in
+// ascending order.
+func rolledUpYears(ctx context.Context, tx *sql.Tx, dialect database.Dialect, table string, years map[int]bool) ([]int, error) {
+	rows, err := tx.QueryContext(ctx, dialect.Rebind(`SELECT year FROM rollup WHERE name = ? ORDER BY year`), table)
+	if err != nil {
+		return nil, fmt.Errorf("query %s rollups: %w", table, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var rolledUp []int
+	for rows.Next() {
+		var year int
+		if err := rows.Scan(&year); err != nil {
+			return nil, fmt.Errorf("scan rollup: %w", err)
+		}
+		if years[year] {
+			rolledUp = append(rolledUp, year)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate rollups: %w", err)
+	}
+
+	return rolledUp, nil
+}
+
+func validate(record Record) error {
+	if record.Identifier == "" {
+		return errors.New("identifier is empty")
+	}
+	if err := web.ValidateMonth(record.Month, web.GetCurrentMonth()); err != nil {
+		return fmt.Errorf("invalid month %d: %w", record.Month, err)
+	}
+	if record.Count < 1 {
+		return fmt.Errorf("count must be positive, got %d", record.Count)
+	}
+
+	return nil
+}
+
+func validPolicy(policy string) bool {
+	return policy == PolicyReplace || policy == PolicyAdd || policy == PolicySkip
+}
+
+func readFile(path, format, column string, add func(Record) error) error {
+	f, err := os.Open(path) //nolint:gosec // path is given by the operator
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	if format == "json" {
+		return ReadJSON(f, column, add)
+	}
+
+	return ReadCSV(f, column, add)
+}
+
+// ReadCSV reads records from CSV with a header row naming the identifier
+// (as "identifier" or the table's column), month and count columns, in any
+// order. Other columns are ignored.
+func ReadCSV(r io.Reader, column string, add func(Record) error) error {
+	reader := csv.NewReader(r)
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("read header: %w", err)
+	}
+
+	idIndex, monthIndex, countIndex := -1, -1, -1
+	for i, name := range header {
+		switch strings.TrimSpace(name) {
+		case identifierField, column:
+			idIndex = i
+		case "month":
+			monthIndex = i
+		case "count":
+			countIndex = i
+		}
+	}
+	if idIndex < 0 || monthIndex < 0 || countIndex < 0 {
+		return fmt.Errorf("header must name the %s (or %s), month and count columns", identifierField, column)
+	}
+
+	for {
+		fields, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		line, _ := reader.FieldPos(0)
+		month, err := strconv.Atoi(strings.TrimSpace(fields[monthIndex]))
+		if err != nil {
+			return fmt.Errorf("line %d: invalid month: %w", line, err)
+		}
+		count, err := strconv.Atoi(strings.TrimSpace(fields[countIndex]))
+		if err != nil {
+			return fmt.Errorf("line %d: invalid count: %w", line, err)
+		}
+
+		if err := add(Record{Identifier: strings.TrimSpace(fields[idIndex]), Month: month, Count: count}); err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+}
+
+// ReadJSON reads records from a JSON array of objects with the identifier
+// (as "identifier" or the table's column), month and count. The array is
+// decoded one object at a time.
+func ReadJSON(r io.Reader, column string, add func(Record) error) error {
+	decoder := json.NewDecoder(r)
+
+	if token, err := decoder.Token(); err != nil || token != json.Delim('[') {
+		return errors.New("expected a JSON array of records")
+	}
+
+	for i := 1; decoder.More(); i++ {
+		var object map[string]json.RawMessage
+		if err := decoder.Decode(&object); err != nil {
+			return fmt.Errorf("record %d: %w", i, err)
+		}
+
+		var record Record
+		id, ok := object[identifierField]
+		if !ok {
+			id = object[column]
+		}
+		if err := json.Unmarshal(id, &record.Identifier); err != nil {
+			return fmt.Errorf("record %d: invalid %s: %w", i, column, err)
+		}
+		if err := json.Unmarshal(object["month"], &record.Month); err != nil {
+			return fmt.Errorf("record %d: invalid month: %w", i, err)
+		}
+		if err := json.Unmarshal(object["count"], &record.Count); err != nil {
+			return fmt.Errorf("record %d: invalid count: %w", i, err)
+		}
+
+		if err := add(record); err != nil {
+			return fmt.Errorf("record %d: %w", i, err)
+		}
+	}
+
+	if _, err := decoder.Token(); err != nil {
+		return fmt.Errorf("read end of array: %w", err)
+	}
+
+	return nil
+}
+
+func printSummary(table, policy string, dryRun bool, summary *Summary) {
+	if dryRun {
+		fmt.Println("Dry run, nothing was written.")
+	}
+
+	fmt.Printf("Table: %s\n", table)
+	fmt.Printf("Records read: %d\n", summary.Read)
+	if summary.FirstMonth != 0 {
+		fmt.Printf("Months: %d to %d\n", summary.FirstMonth, summary.LastMonth)
+	}
+	fmt.Printf("New rows: %d\n", summary.Inserted)
+	switch policy {
+	case PolicyReplace:
+		fmt.Printf("Replaced rows: %d\n", summary.Updated)
+	case PolicyAdd:
+		fmt.Printf("Rows added to: %d\n", summary.Updated)
+	default:
+		fmt.Printf("Skipped existing rows: %d\n", summary.Skipped)
+	}
+
+	for _, year := range summary.RolledUpYears {
+		if dryRun {
+			fmt.Printf("Would rebuild rollup of %d.\n", year)
+		} else {
+			fmt.Printf("Rebuilt rollup of %d.\n", year)
+		}
+	}
+}
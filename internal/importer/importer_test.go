@@ -0,0 +1,333 @@
+package importer
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"pkgstatsd/internal/database"
+	"pkgstatsd/internal/rollup"
+	"pkgstatsd/internal/web"
+)
+
+func setupTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, err := database.New(":memory:")
+	if err != nil {
+		t.Fatalf("create database: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	if _, err := db.Exec(`INSERT INTO package_name (id, name) VALUES (1, 'pacman'); INSERT INTO package (name_id, month, count) VALUES (1, 202501, 10)`); err != nil {
+		t.Fatalf("insert test data: %v", err)
+	}
+
+	return db
+}
+
+func readCSV(data string) ReadFunc {
+	return func(add func(Record) error) error {
+		return ReadCSV(strings.NewReader(data), "name", add)
+	}
+}
+
+func packageCount(t *testing.T, db *sql.DB, name string, month int) int {
+	t.Helper()
+
+	var count int
+	err := db.QueryRow(`SELECT count FROM package JOIN package_name ON package_name.id = package.name_id WHERE name = ? AND month = ?`, name, month).Scan(&count)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("query %s: %v", name, err)
+	}
+
+	return count
+}
+
+func TestImport_Policies(t *testing.T) {
+	const data = "name,month,count\npacman,202501,5\nlinux,202501,3\n"
+
+	tests := []struct {
+		policy  string
+		pacman  int
+		summary Summary
+	}{
+		{PolicySkip, 10, Summary{Read: 2, Inserted: 1, Skipped: 1, FirstMonth: 202501, LastMonth: 202501}},
+		{PolicyAdd, 15, Summary{Read: 2, Inserted: 1, Updated: 1, FirstMonth: 202501, LastMonth: 202501}},
+		{PolicyReplace, 5, Summary{Read: 2, Inserted: 1, Updated: 1, FirstMonth: 202501, LastMonth: 202501}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			db := setupTestDB(t)
+
+			summary, err := Import(context.Background(), db, "package", tt.policy, false, readCSV(data))
+			if err != nil {
+				t.Fatalf("Import error: %v", err)
+			}
+
+			if summary.Read != tt.summary.Read || summary.Inserted != tt.summary.Inserted ||
+				summary.Updated != tt.summary.Updated || summary.Skipped != tt.summary.Skipped ||
+				summary.FirstMonth != tt.summary.FirstMonth || summary.LastMonth != tt.summary.LastMonth {
+				t.Errorf("expected summary %+v, got %+v", tt.summary, *summary)
+			}
+			if count := packageCount(t, db, "pacman", 202501); count != tt.pacman {
+				t.Errorf("expected pacman count %d, got %d", tt.pacman, count)
+			}
+			if count := packageCount(t, db, "linux", 202501); count != 3 {
+				t.Errorf("expected linux count 3, got %d", count)
+			}
+		})
+	}
+}
+
+func TestImport_DryRun(t *testing.T) {
+	db := setupTestDB(t)
+
+	summary, err := Import(context.Background(), db, "package", PolicyReplace, true, readCSV("name,month,count\npacman,202501,5\nlinux,202501,3\n"))
+	if err != nil {
+		t.Fatalf("Import error: %v", err)
+	}
+
+	if summary.Inserted != 1 || summary.Updated != 1 {
+		t.Errorf("expected 1 insert and 1 update in the summary, got %+v", *summary)
+	}
+	if count := packageCount(t, db, "pacman", 202501); count != 10 {
+		t.Errorf("expected pacman count to stay 10, got %d", count)
+	}
+	if count := packageCount(t, db, "linux", 202501); count != 0 {
+		t.Errorf("expected linux not to be written, got %d", count)
+	}
+}
+
+func TestImport_InvalidRecordRollsBack(t *testing.T) {
+	db := setupTestDB(t)
+
+	_, err := Import(context.Background(), db, "package", PolicyAdd, false, readCSV("name,month,count\nlinux,202501,3\nzsh,202513,1\n"))
+	if err == nil || !strings.Contains(err.Error(), "line 3") {
+		t.Fatalf("expected an error for line 3, got %v", err)
+	}
+
+	if count := packageCount(t, db, "linux", 202501); count != 0 {
+		t.Errorf("expected nothing to be written, got linux count %d", count)
+	}
+}
+
+func TestImport_Batches(t *testing.T) {
+	db := setupTestDB(t)
+
+	var data strings.Builder
+	data.WriteString("name,month,count\n")
+	for i := range 2*batchSize + 1 {
+		fmt.Fprintf(&data, "pkg%d,202501,%d\n", i, i+1)
+	}
+	data.WriteString("pacman,202501,5\n")
+
+	summary, err := Import(context.Background(), db, "package", PolicyAdd, false, readCSV(data.String()))
+	if err != nil {
+		t.Fatalf("Import error: %v", err)
+	}
+
+	if summary.Read != 2*batchSize+2 || summary.Inserted != 2*batchSize+1 || summary.Updated != 1 {
+		t.Errorf("unexpected summary %+v", *summary)
+	}
+	if count := packageCount(t, db, "pkg0", 202501); count != 1 {
+		t.Errorf("expected pkg0 count 1, got %d", count)
+	}
+	if count := packageCount(t, db, fmt.Sprintf("pkg%d", 2*batchSize), 202501); count != 2*batchSize+1 {
+		t.Errorf("expected the last batch to be written, got count %d", count)
+	}
+	if count := packageCount(t, db, "pacman", 202501); count != 15 {
+		t.Errorf("expected pacman count 15, got %d", count)
+	}
+
+	v, err := database.ReadDataVersion(context.Background(), db)
+	if err != nil {
+		t.Fatalf("ReadDataVersion error: %v", err)
+	}
+	if v.Version != 1 {
+		t.Errorf("expected the data version to be incremented once, got %d", v.Version)
+	}
+}
+
+func TestImport_InvalidRecordAfterBatches(t *testing.T) {
+	db := setupTestDB(t)
+
+	var data strings.Builder
+	data.WriteString("name,month,count\n")
+	for i := range batchSize + 1 {
+		fmt.Fprintf(&data, "pkg%d,202501,1\n", i)
+	}
+	data.WriteString("zsh,202501,0\n")
+
+	if _, err := Import(context.Background(), db, "package", PolicyAdd, false, readCSV(data.String())); err == nil {
+		t.Fatal("expected an error for the invalid record")
+	}
+
+	if count := packageCount(t, db, "pkg0", 202501); count != 0 {
+		t.Errorf("expected nothing to be written, got pkg0 count %d", count)
+	}
+}
+
+func TestImport_BumpsDataVersion(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+
+	imports := []struct {
+		name    string
+		policy  string
+		dryRun  bool
+		version int64
+	}{
+		{"dry run", PolicyAdd, true, 0},
+		{"only skipped", PolicySkip, false, 0},
+		{"written", PolicyAdd, false, 1},
+	}
+
+	for _, tt := range imports {
+		if _, err := Import(ctx, db, "package", tt.policy, tt.dryRun, readCSV("name,month,count\npacman,202501,5\n")); err != nil {
+			t.Fatalf("%s: Import error: %v", tt.name, err)
+		}
+
+		v, err := database.ReadDataVersion(ctx, db)
+		if err != nil {
+			t.Fatalf("ReadDataVersion error: %v", err)
+		}
+		if v.Version != tt.version {
+			t.Errorf("%s: expected data version %d, got %d", tt.name, tt.version, v.Version)
+		}
+	}
+}
+
+func TestImport_LowercasesIdentifiers(t *testing.T) {
+	db := setupTestDB(t)
+
+	summary, err := Import(context.Background(), db, "package", PolicyAdd, false, readCSV("name,month,count\nPacman,202501,5\nLinux,202501,3\n"))
+	if err != nil {
+		t.Fatalf("Import error: %v", err)
+	}
+
+	if summary.Inserted != 1 || summary.Updated != 1 {
+		t.Errorf("expected 1 insert and 1 update, got %+v", *summary)
+	}
+	if count := packageCount(t, db, "pacman", 202501); count != 15 {
+		t.Errorf("expected pacman count 15, got %d", count)
+	}
+	if count := packageCount(t, db, "linux", 202501); count != 3 {
+		t.Errorf("expected linux count 3, got %d", count)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	nextMonth := web.OffsetMonth(web.GetCurrentMonth(), 1)
+
+	tests := []struct {
+		name   string
+		record Record
+		valid  bool
+	}{
+		{"valid", Record{Identifier: "pacman", Month: 202501, Count: 1}, true},
+		{"first year", Record{Identifier: "pacman", Month: 200201, Count: 1}, true},
+		{"current month", Record{Identifier: "pacman", Month: web.GetCurrentMonth(), Count: 1}, true},
+		{"empty identifier", Record{Month: 202501, Count: 1}, false},
+		{"before the first year", Record{Identifier: "pacman", Month: 200112, Count: 1}, false},
+		{"early year", Record{Identifier: "pacman", Month: 100001, Count: 1}, false},
+		{"future month", Record{Identifier: "pacman", Month: nextMonth, Count: 1}, false},
+		{"invalid month", Record{Identifier: "pacman", Month: 202513, Count: 1}, false},
+		{"zero count", Record{Identifier: "pacman", Month: 202501}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validate(tt.record); (err == nil) != tt.valid {
+				t.Errorf("validate(%+v) = %v, expected valid %v", tt.record, err, tt.valid)
+			}
+		})
+	}
+}
+
+func TestImport_RebuildsRollups(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+
+	_, _ = db.Exec(`INSERT INTO package (name_id, month, count) VALUES (1, 202403, 10)`)
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("begin transaction: %v", err)
+	}
+	if err := rollup.Rollup(ctx, tx, database.DialectOf(db), 2024); err != nil {
+		t.Fatalf("Rollup error: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("commit transaction: %v", err)
+	}
+
+	summary, err := Import(ctx, db, "package", PolicyAdd, false, readCSV("name,month,count\npacman,202406,7\npacman,202501,1\n"))
+	if err != nil {
+		t.Fatalf("Import error: %v", err)
+	}
+	if !slices.Equal(summary.RolledUpYears, []int{2024}) {
+		t.Errorf("expected rollup of 2024 to be rebuilt, got %v", summary.RolledUpYears)
+	}
+
+	var count int
+	if err := db.QueryRow(`SELECT count FROM package_year WHERE name_id = 1 AND year = 2024`).Scan(&count); err != nil {
+		t.Fatalf("query rollup: %v", err)
+	}
+	if count != 17 {
+		t.Errorf("expected rolled up count 17, got %d", count)
+	}
+}
+
+func TestReadCSV(t *testing.T) {
+	var records []Record
+	add := func(record Record) error {
+		records = append(records, record)
+		return nil
+	}
+
+	data := "count,month,identifier,source\n10, 202501 ,pacman,php\n"
+	if err := ReadCSV(strings.NewReader(data), "name", add); err != nil {
+		t.Fatalf("ReadCSV error: %v", err)
+	}
+	if want := []Record{{"pacman", 202501, 10}}; !slices.Equal(records, want) {
+		t.Errorf("expected %v, got %v", want, records)
+	}
+
+	if err := ReadCSV(strings.NewReader("name,count\npacman,1\n"), "name", add); err == nil {
+		t.Error("expected error for a header without month")
+	}
+	if err := ReadCSV(strings.NewReader("code,month,count\nDE,2025-01,1\n"), "code", add); err == nil {
+		t.Error("expected error for an invalid month")
+	}
+}
+
+func TestReadJSON(t *testing.T) {
+	var records []Record
+	add := func(record Record) error {
+		records = append(records, record)
+		return nil
+	}
+
+	data := `[{"code": "DE", "month": 202501, "count": 3}, {"identifier": "FR", "month": 202502, "count": 4}]`
+	if err := ReadJSON(strings.NewReader(data), "code", add); err != nil {
+		t.Fatalf("ReadJSON error: %v", err)
+	}
+	if want := []Record{{"DE", 202501, 3}, {"FR", 202502, 4}}; !slices.Equal(records, want) {
+		t.Errorf("expected %v, got %v", want, records)
+	}
+
+	for _, data := range []string{
+		`{"code": "DE"}`,
+		`[{"code": "DE", "month": "202501", "count": 3}]`,
+		`[{"code": "DE", "month": 202501}]`,
+	} {
+		if err := ReadJSON(strings.NewReader(data), "code", add); err == nil {
+			t.Errorf("expected error for %s", data)
+		}
+	}
+}
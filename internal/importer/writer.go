@@ -0,0 +1,166 @@
+package importer
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"pkgstatsd/internal/database"
+)
+
+// batchSize is the number of records written per transaction.
+const batchSize = 1000
+
+// Results of writing a record.
+const (
+	inserted = iota
+	updated
+	skipped
+)
+
+// writer writes records to a table in batches, each in its own
+// transaction.
+type writer struct {
+	db *sql.DB
+	// addIdentifier adds identifiers to the table's dictionary, if it has
+	// one; insert and update look them up there.
+	addIdentifier, insert, update *sql.Stmt
+	// committed counts the records of committed batches; changed is set
+	// once one of them inserted or updated rows.
+	committed int
+	changed   bool
+}
+
+func newWriter(db *sql.DB, table, column, policy string) (*writer, error) {
+	dialect := database.DialectOf(db)
+	w := &writer{db: db}
+
+	// Identifiers kept in a dictionary are added to it first and looked up
+	// by the statements below.
+	identifier := "?"
+	if dictionary, ok := dictionaries[table]; ok {
+		var err error
+		//nolint:gosec // table and column names come from the dictionaries and columns maps
+		if w.addIdentifier, err = db.Prepare(dialect.Rebind(fmt.Sprintf(
+			`INSERT INTO %[1]s (%[2]s) VALUES (?) ON CONFLICT (%[2]s) DO NOTHING`,
+			dictionary, column,
+		))); err != nil {
+			return nil, fmt.Errorf("prepare %s insert: %w", dictionary, err)
+		}
+
+		identifier = `(SELECT id FROM ` + dictionary + ` WHERE ` + column + ` = ?)`
+		column += "_id"
+	}
+
+	// Rows are inserted before any existing row is read, so each
+	// transaction takes the SQLite write lock with its first statement and
+	// cannot fail to upgrade a read snapshot that a concurrent submission
+	// made stale.
+	var err error
+	//nolint:gosec // table and column names come from the columns map
+	if w.insert, err = db.Prepare(dialect.Rebind(fmt.Sprintf(
+		`INSERT INTO %[1]s (%[2]s, month, count) VALUES (%[3]s, ?, ?) ON CONFLICT (%[2]s, month) DO NOTHING`,
+		table, column, identifier,
+	))); err != nil {
+		_ = w.close()
+		return nil, fmt.Errorf("prepare insert: %w", err)
+	}
+
+	if policy != PolicySkip {
+		if w.update, err = db.Prepare(dialect.Rebind(updateQuery(table, column, identifier, policy))); err != nil {
+			_ = w.close()
+			return nil, fmt.Errorf("prepare update: %w", err)
+		}
+	}
+
+	return w, nil
+}
+
+func (w *writer) close() error {
+	for _, stmt := range []*sql.Stmt{w.addIdentifier, w.insert, w.update} {
+		if stmt != nil {
+			_ = stmt.Close()
+		}
+	}
+
+	return nil
+}
+
+// batch is a transaction of up to batchSize records.
+type batch struct {
+	w                             *writer
+	tx                            *sql.Tx
+	addIdentifier, insert, update *sql.Stmt
+	records, changes              int
+}
+
+func (w *writer) begin(ctx context.Context) (*batch, error) {
+	tx, err := w.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+
+	b := &batch{w: w, tx: tx, insert: tx.StmtContext(ctx, w.insert)}
+	if w.addIdentifier != nil {
+		b.addIdentifier = tx.StmtContext(ctx, w.addIdentifier)
+	}
+	if w.update != nil {
+		b.update = tx.StmtContext(ctx, w.update)
+	}
+
+	return b, nil
+}
+
+// write writes record and reports whether it was inserted, updated or
+// skipped.
+func (b *batch) write(ctx context.Context, record Record) (int, error) {
+	b.records++
+
+	if b.addIdentifier != nil {
+		if _, err := b.addIdentifier.ExecContext(ctx, record.Identifier); err != nil {
+			return 0, err
+		}
+	}
+
+	result, err := b.insert.ExecContext(ctx, record.Identifier, record.Month, record.Count)
+	if err != nil {
+		return 0, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	switch {
+	case rows > 0:
+		b.changes++
+		return inserted, nil
+	case b.update == nil:
+		return skipped, nil
+	}
+
+	if _, err := b.update.ExecContext(ctx, record.Count, record.Identifier, record.Month); err != nil {
+		return 0, err
+	}
+	b.changes++
+
+	return updated, nil
+}
+
+// end commits the batch, or rolls it back with rollback.
+func (b *batch) end(rollback bool) error {
+	if rollback {
+		_ = b.tx.Rollback()
+		return nil
+	}
+
+	if err := b.tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	b.w.committed += b.records
+	if b.changes > 0 {
+		b.w.changed = true
+	}
+
+	return nil
+}
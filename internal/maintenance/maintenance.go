@@ -0,0 +1,294 @@
+package maintenance
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"text/tabwriter"
+
+	"pkgstatsd/internal/config"
+	"pkgstatsd/internal/database"
+)
+
+const autoVacuumIncremental = 2
+
+// monthlyTables lists the tables whose rows are reported per month.
+var monthlyTables = []string{
+	"package",
+	"country",
+	"mirror",
+	"system_architecture",
+	"operating_system_architecture",
+	"operating_system_id",
+	"submission_log",
+}
+
+type (
+	// ObjectSize is the space used by a table or index.
+	ObjectSize struct {
+		Name  string
+		Bytes int64
+	}
+
+	// MonthRows holds the number of rows of each monthly table in a month.
+	MonthRows struct {
+		Month int
+		Rows  map[string]int
+	}
+
+	// Report is the outcome of Maintain. Sizes are in bytes.
+	Report struct {
+		SizeBefore int64
+		SizeAfter  int64
+		// Converted is set when the database was switched to incremental
+		// auto-vacuum, which rewrites it with a full VACUUM.
+		Converted bool
+		// NotIncremental is set when the database does not use incremental
+		// auto-vacuum and was not converted, so no free pages were
+		// reclaimed.
+		NotIncremental bool
+		// CheckpointBusy is set when readers kept the WAL checkpoint from
+		// completing.
+		CheckpointBusy bool
+		Objects        []ObjectSize
+		Months         []MonthRows
+	}
+)
+
+// Run executes the db-maintenance subcommand. It compacts and optimizes the
+// database, checkpoints the WAL, prints where the space goes and returns the
+// process exit code. Meant to be run periodically by an external scheduler,
+// after prune-submission-log.
+func Run(args []string, cfg config.Config) int {
+	fs := flag.NewFlagSet("db-maintenance", flag.ExitOnError)
+	convertFlag := fs.Bool("convert-incremental", false, "Switch a database without incremental auto-vacuum to it with a full VACUUM, which blocks writes while it runs")
+	_ = fs.Parse(args)
+
+	if database.IsPostgres(cfg.Database) {
+		fmt.Fprintln(os.Stderr, "Error: db-maintenance supports SQLite databases only, PostgreSQL is maintained by autovacuum")
+		return 1
+	}
+
+	db, err := database.Connect(cfg.Database)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	defer func() { _ = db.Close() }()
+
+	// Migrations are left to the server and the migrate subcommand.
+	if err := database.CheckMigrations(context.Background(), db); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	report, err := Maintain(context.Background(), db, *convertFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	printReport(report)
+	return 0
+}
+
+// Maintain reclaims the pages freed since the last run, refreshes the query
+// planner statistics and checkpoints the WAL into the database file. A
+// database created without incremental auto-vacuum has no free pages
+// reclaimed unless convert is set, which converts it first with a full
+// VACUUM that blocks writes while it runs. All steps share one connection,
+// as the auto-vacuum mode only changes for the connection that runs the
+// VACUUM.
+func Maintain(ctx context.Context, db *sql.DB, convert bool) (*Report, error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get connection: %w", err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	report := &Report{}
+
+	if report.SizeBefore, err = databaseSize(ctx, conn); err != nil {
+		return nil, err
+	}
+
+	var autoVacuum int
+	if err := conn.QueryRowContext(ctx, `PRAGMA auto_vacuum`).Scan(&autoVacuum); err != nil {
+		return nil, fmt.Errorf("query auto_vacuum: %w", err)
+	}
+
+	switch {
+	case autoVacuum != autoVacuumIncremental && !convert:
+		report.NotIncremental = true
+	case autoVacuum != autoVacuumIncremental:
+		if _, err := conn.ExecContext(ctx, `PRAGMA auto_vacuum = INCREMENTAL`); err != nil {
+			return nil, fmt.Errorf("enable incremental auto_vacuum: %w", err)
+		}
+		if _, err := conn.ExecContext(ctx, `VACUUM`); err != nil {
+			return nil, fmt.Errorf("vacuum: %w", err)
+		}
+		report.Converted = true
+	default:
+		if _, err := conn.ExecContext(ctx, `PRAGMA incremental_vacuum`); err != nil {
+			return nil, fmt.Errorf("incremental vacuum: %w", err)
+		}
+	}
+
+	if _, err := conn.ExecContext(ctx, `ANALYZE`); err != nil {
+		return nil, fmt.Errorf("analyze: %w", err)
+	}
+	if _, err := conn.ExecContext(ctx, `PRAGMA optimize`); err != nil {
+		return nil, fmt.Errorf("optimize: %w", err)
+	}
+
+	var busy, walPages, checkpointed int
+	if err := conn.QueryRowContext(ctx, `PRAGMA wal_checkpoint(TRUNCATE)`).Scan(&busy, &walPages, &checkpointed); err != nil {
+		return nil, fmt.Errorf("checkpoint wal: %w", err)
+	}
+	report.CheckpointBusy = busy != 0
+
+	if report.SizeAfter, err = databaseSize(ctx, conn); err != nil {
+		return nil, err
+	}
+	if report.Objects, err = objectSizes(ctx, conn); err != nil {
+		return nil, err
+	}
+	if report.Months, err = monthlyRows(ctx, conn); err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+func databaseSize(ctx context.Context, conn *sql.Conn) (int64, error) {
+	var size int64
+	if err := conn.QueryRowContext(ctx,
+		`SELECT page_count * page_size FROM pragma_page_count(), pragma_page_size()`,
+	).Scan(&size); err != nil {
+		return 0, fmt.Errorf("query database size: %w", err)
+	}
+
+	return size, nil
+}
+
+// objectSizes returns the space used by every table and index, largest
+// first.
+func objectSizes(ctx context.Context, conn *sql.Conn) ([]ObjectSize, error) {
+	rows, err := conn.QueryContext(ctx, `SELECT name, SUM(pgsize) AS size FROM dbstat GROUP BY name ORDER BY size DESC, name`)
+	if err != nil {
+		return nil, fmt.Errorf("query object sizes: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var objects []ObjectSize
+	for rows.Next() {
+		var object ObjectSize
+		if err := rows.Scan(&object.Name, &object.Bytes); err != nil {
+			return nil, fmt.Errorf("scan object size: %w", err)
+		}
+		objects = append(objects, object)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate object sizes: %w", err)
+	}
+
+	return objects, nil
+}
+
+// monthlyRows returns the row counts of the monthly tables per month, in
+// ascending order of month.
+func monthlyRows(ctx context.Context, conn *sql.Conn) ([]MonthRows, error) {
+	byMonth := make(map[int]map[string]int)
+
+	for _, table := range monthlyTables {
+		//nolint:gosec // table names are constants
+		rows, err := conn.QueryContext(ctx, `SELECT month, COUNT(*) FROM `+table+` GROUP BY month`)
+		if err != nil {
+			return nil, fmt.Errorf("count %s rows: %w", table, err)
+		}
+
+		for rows.Next() {
+			var month, count int
+			if err := rows.Scan(&month, &count); err != nil {
+				_ = rows.Close()
+				return nil, fmt.Errorf("scan %s rows: %w", table, err)
+			}
+			if byMonth[month] == nil {
+				byMonth[month] = make(map[string]int)
+			}
+			byMonth[month][table] = count
+		}
+
+		err = rows.Err()
+		_ = rows.Close()
+		if err != nil {
+			return nil, fmt.Errorf("iterate %s rows: %w", table, err)
+		}
+	}
+
+	months := make([]MonthRows, 0, len(byMonth))
+	for month, counts := range byMonth {
+		months = append(months, MonthRows{Month: month, Rows: counts})
+	}
+	slices.SortFunc(months, func(a, b MonthRows) int { return a.Month - b.Month })
+
+	return months, nil
+}
+
+func printReport(report *Report) {
+	fmt.Println("Database Maintenance Report")
+	fmt.Println("===========================")
+	if report.Converted {
+		fmt.Println("Switched to incremental auto-vacuum.")
+	}
+	if report.NotIncremental {
+		fmt.Println("Incremental vacuum unavailable: the database does not use incremental auto-vacuum.")
+		fmt.Println("Run with --convert-incremental to convert it with a full VACUUM, which blocks writes while it runs.")
+	}
+	fmt.Printf("Size: %s before, %s after\n", formatBytes(report.SizeBefore), formatBytes(report.SizeAfter))
+	if report.CheckpointBusy {
+		fmt.Println("WAL checkpoint incomplete: the database was busy.")
+	}
+
+	fmt.Println()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Table or index\tSize\t")
+	for _, object := range report.Objects {
+		fmt.Fprintf(w, "%s\t%s\t\n", object.Name, formatBytes(object.Bytes))
+	}
+	_ = w.Flush()
+
+	fmt.Println()
+	fmt.Fprint(w, "Month\t")
+	for _, table := range monthlyTables {
+		fmt.Fprintf(w, "%s\t", table)
+	}
+	fmt.Fprintln(w)
+	for _, month := range report.Months {
+		fmt.Fprintf(w, "%d\t", month.Month)
+		for _, table := range monthlyTables {
+			fmt.Fprintf(w, "%d\t", month.Rows[table])
+		}
+		fmt.Fprintln(w)
+	}
+	_ = w.Flush()
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
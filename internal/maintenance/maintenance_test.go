@@ -0,0 +1,140 @@
+package maintenance
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"pkgstatsd/internal/database"
+)
+
+func TestMaintain(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pkgstats.db")
+
+	// Databases created before incremental auto-vacuum was enabled.
+	legacy, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("create database: %v", err)
+	}
+	if _, err := legacy.Exec(`PRAGMA auto_vacuum = NONE; CREATE TABLE legacy (x)`); err != nil {
+		t.Fatalf("create legacy table: %v", err)
+	}
+	_ = legacy.Close()
+
+	db, err := database.New(path)
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	_, _ = db.Exec(`INSERT INTO package_name (id, name) VALUES (1, 'pacman'), (2, 'linux'); INSERT INTO package (name_id, month, count) VALUES (1, 202501, 10), (2, 202501, 8), (1, 202412, 9)`)
+	_, _ = db.Exec(`INSERT INTO country (code, month, count) VALUES ('DE', 202501, 3)`)
+
+	// Without --convert-incremental, the database is left as it is.
+	report, err := Maintain(context.Background(), db, false)
+	if err != nil {
+		t.Fatalf("Maintain error: %v", err)
+	}
+	if report.Converted || !report.NotIncremental {
+		t.Errorf("expected the database to be reported as not incremental, got %+v", report)
+	}
+
+	var autoVacuum int
+	if err := db.QueryRow(`PRAGMA auto_vacuum`).Scan(&autoVacuum); err != nil {
+		t.Fatalf("query auto_vacuum: %v", err)
+	}
+	if autoVacuum == autoVacuumIncremental {
+		t.Error("expected auto_vacuum to stay unchanged without convert")
+	}
+
+	report, err = Maintain(context.Background(), db, true)
+	if err != nil {
+		t.Fatalf("Maintain error: %v", err)
+	}
+	if !report.Converted || report.NotIncremental {
+		t.Error("expected the database to be switched to incremental auto-vacuum")
+	}
+
+	if err := db.QueryRow(`PRAGMA auto_vacuum`).Scan(&autoVacuum); err != nil {
+		t.Fatalf("query auto_vacuum: %v", err)
+	}
+	if autoVacuum != autoVacuumIncremental {
+		t.Errorf("expected incremental auto_vacuum, got %d", autoVacuum)
+	}
+
+	if len(report.Months) != 2 || report.Months[0].Month != 202412 || report.Months[1].Month != 202501 {
+		t.Fatalf("unexpected months: %+v", report.Months)
+	}
+	if rows := report.Months[1].Rows; rows["package"] != 2 || rows["country"] != 1 || rows["mirror"] != 0 {
+		t.Errorf("unexpected rows for 202501: %v", rows)
+	}
+
+	found := false
+	for _, object := range report.Objects {
+		if object.Name == "package" && object.Bytes > 0 {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected the package table in object sizes, got %+v", report.Objects)
+	}
+
+	// Free some pages for the incremental vacuum of the next run.
+	_, _ = db.Exec(`INSERT INTO submission_log (month, timestamp, ip, headers, payload, payload_hash, country)
+		VALUES (202501, 0, '', '{}', ?, '', '')`, strings.Repeat("x", 1<<20))
+	_, _ = db.Exec(`DELETE FROM submission_log`)
+
+	report, err = Maintain(context.Background(), db, false)
+	if err != nil {
+		t.Fatalf("Maintain error: %v", err)
+	}
+	if report.Converted || report.NotIncremental {
+		t.Error("expected no conversion on the second run")
+	}
+	if report.SizeAfter >= report.SizeBefore {
+		t.Errorf("expected the database to shrink, got %d before and %d after", report.SizeBefore, report.SizeAfter)
+	}
+
+	var free int
+	if err := db.QueryRow(`PRAGMA freelist_count`).Scan(&free); err != nil {
+		t.Fatalf("query freelist: %v", err)
+	}
+	if free != 0 {
+		t.Errorf("expected an empty freelist, got %d pages", free)
+	}
+}
+
+func TestNew_IncrementalAutoVacuum(t *testing.T) {
+	db, err := database.New(filepath.Join(t.TempDir(), "pkgstats.db"))
+	if err != nil {
+		t.Fatalf("create database: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	report, err := Maintain(context.Background(), db, false)
+	if err != nil {
+		t.Fatalf("Maintain error: %v", err)
+	}
+	if report.Converted || report.NotIncremental {
+		t.Error("expected new databases to use incremental auto-vacuum already")
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := map[int64]string{
+		0:       "0 B",
+		1023:    "1023 B",
+		1024:    "1.0 KiB",
+		1536:    "1.5 KiB",
+		5 << 20: "5.0 MiB",
+		3 << 30: "3.0 GiB",
+	}
+
+	for n, want := range tests {
+		if got := formatBytes(n); got != want {
+			t.Errorf("formatBytes(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
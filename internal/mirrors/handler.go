@@ -5,36 +5,39 @@ import (
 	"database/sql"
 	"net/http"
 
+	"pkgstatsd/internal/database"
 	"pkgstatsd/internal/popularity"
+	"pkgstatsd/internal/web"
 )
 
-type SQLiteRepository struct {
+type SQLRepository struct {
 	*popularity.Repository[MirrorPopularity, MirrorPopularityList]
 }
 
-func NewSQLiteRepository(db *sql.DB) *SQLiteRepository {
-	return &SQLiteRepository{
+func NewSQLRepository(db *sql.DB, results *database.ResultCache) *SQLRepository {
+	return &SQLRepository{
 		Repository: popularity.NewRepository(db, popularity.Config{
 			Table:         "mirror",
 			Column:        "url",
 			QueryContains: true,
+			Results:       results,
 		}, newItem, newList),
 	}
 }
 
-func (r *SQLiteRepository) FindByURL(ctx context.Context, url string, startMonth, endMonth int) (*MirrorPopularity, error) {
+func (r *SQLRepository) FindByURL(ctx context.Context, url string, startMonth, endMonth int) (*MirrorPopularity, error) {
 	return r.FindByIdentifier(ctx, url, startMonth, endMonth)
 }
 
-func (r *SQLiteRepository) FindSeriesByURL(ctx context.Context, url string, startMonth, endMonth, limit, offset int) (*MirrorPopularityList, error) {
-	return r.FindSeries(ctx, url, startMonth, endMonth, limit, offset)
+func (r *SQLRepository) FindSeriesByURL(ctx context.Context, url string, startMonth, endMonth, limit, offset int, opts web.SeriesOptions) (*MirrorPopularityList, error) {
+	return r.FindSeries(ctx, url, startMonth, endMonth, limit, offset, opts)
 }
 
 type Handler struct {
 	pop *popularity.Handler[MirrorPopularity, MirrorPopularityList]
 }
 
-func NewHandler(repo *SQLiteRepository) *Handler {
+func NewHandler(repo *SQLRepository) *Handler {
 	return &Handler{
 		pop: popularity.NewHandler[MirrorPopularity, MirrorPopularityList](
 			repo, "/api/mirrors", "url", "mirror url required",
@@ -1,14 +1,20 @@
 package mirrors
 
-import "context"
+import (
+	"context"
+
+	"pkgstatsd/internal/popularity"
+	"pkgstatsd/internal/web"
+)
 
 type MirrorPopularity struct {
 	URL        string  `json:"url"`
 	Samples    int     `json:"samples"`
 	Count      int     `json:"count"`
 	Popularity float64 `json:"popularity"`
-	StartMonth int     `json:"startMonth"`
-	EndMonth   int     `json:"endMonth"`
+	*popularity.Interval
+	StartMonth int `json:"startMonth"`
+	EndMonth   int `json:"endMonth"`
 }
 
 type MirrorPopularityList struct {
@@ -18,24 +24,26 @@ type MirrorPopularityList struct {
 	Limit              int                `json:"limit"`
 	Offset             int                `json:"offset"`
 	Query              *string            `json:"query"`
+	Next               *string            `json:"next,omitempty"`
+	Smoothing          string             `json:"smoothing,omitempty"`
 }
 
 type Repository interface {
 	FindByURL(ctx context.Context, url string, startMonth, endMonth int) (*MirrorPopularity, error)
-	FindAll(ctx context.Context, query string, startMonth, endMonth, limit, offset int) (*MirrorPopularityList, error)
-	FindSeriesByURL(ctx context.Context, url string, startMonth, endMonth, limit, offset int) (*MirrorPopularityList, error)
+	FindAll(ctx context.Context, query string, startMonth, endMonth, limit, offset int, opts web.ListOptions) (*MirrorPopularityList, error)
+	FindSeriesByURL(ctx context.Context, url string, startMonth, endMonth, limit, offset int, opts web.SeriesOptions) (*MirrorPopularityList, error)
 }
 
-func newItem(identifier string, samples, count int, popularity float64, startMonth, endMonth int) MirrorPopularity {
+func newItem(identifier string, samples, count int, popularity float64, interval *popularity.Interval, startMonth, endMonth int) MirrorPopularity {
 	return MirrorPopularity{
 		URL: identifier, Samples: samples, Count: count,
-		Popularity: popularity, StartMonth: startMonth, EndMonth: endMonth,
+		Popularity: popularity, Interval: interval, StartMonth: startMonth, EndMonth: endMonth,
 	}
 }
 
-func newList(total, count int, items []MirrorPopularity, limit, offset int, query *string) MirrorPopularityList {
+func newList(total, count int, items []MirrorPopularity, limit, offset int, query, next *string, smoothing string) MirrorPopularityList {
 	return MirrorPopularityList{
 		Total: total, Count: count, MirrorPopularities: items,
-		Limit: limit, Offset: offset, Query: query,
+		Limit: limit, Offset: offset, Query: query, Next: next, Smoothing: smoothing,
 	}
 }
@@ -5,36 +5,39 @@ import (
 	"database/sql"
 	"net/http"
 
+	"pkgstatsd/internal/database"
 	"pkgstatsd/internal/popularity"
+	"pkgstatsd/internal/web"
 )
 
-type SQLiteRepository struct {
+type SQLRepository struct {
 	*popularity.Repository[OperatingSystemIdPopularity, OperatingSystemIdPopularityList]
 }
 
-func NewSQLiteRepository(db *sql.DB) *SQLiteRepository {
-	return &SQLiteRepository{
+func NewSQLRepository(db *sql.DB, results *database.ResultCache) *SQLRepository {
+	return &SQLRepository{
 		Repository: popularity.NewRepository(db, popularity.Config{
 			Table:         "operating_system_id",
 			Column:        "id",
 			QueryContains: true,
+			Results:       results,
 		}, newItem, newList),
 	}
 }
 
-func (r *SQLiteRepository) FindByID(ctx context.Context, id string, startMonth, endMonth int) (*OperatingSystemIdPopularity, error) {
+func (r *SQLRepository) FindByID(ctx context.Context, id string, startMonth, endMonth int) (*OperatingSystemIdPopularity, error) {
 	return r.FindByIdentifier(ctx, id, startMonth, endMonth)
 }
 
-func (r *SQLiteRepository) FindSeriesByID(ctx context.Context, id string, startMonth, endMonth, limit, offset int) (*OperatingSystemIdPopularityList, error) {
-	return r.FindSeries(ctx, id, startMonth, endMonth, limit, offset)
+func (r *SQLRepository) FindSeriesByID(ctx context.Context, id string, startMonth, endMonth, limit, offset int, opts web.SeriesOptions) (*OperatingSystemIdPopularityList, error) {
+	return r.FindSeries(ctx, id, startMonth, endMonth, limit, offset, opts)
 }
 
 type Handler struct {
 	pop *popularity.Handler[OperatingSystemIdPopularity, OperatingSystemIdPopularityList]
 }
 
-func NewHandler(repo *SQLiteRepository) *Handler {
+func NewHandler(repo *SQLRepository) *Handler {
 	return &Handler{
 		pop: popularity.NewHandler[OperatingSystemIdPopularity, OperatingSystemIdPopularityList](
 			repo, "/api/operating-systems", "id", "operating system id required",
@@ -9,25 +9,31 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"pkgstatsd/internal/popularity"
 	"pkgstatsd/internal/web"
 )
 
 type mockQuerier struct {
 	findByIdentifierFunc func(ctx context.Context, identifier string, startMonth, endMonth int) (*OperatingSystemIdPopularity, error)
-	findAllFunc          func(ctx context.Context, query string, startMonth, endMonth, limit, offset int) (*OperatingSystemIdPopularityList, error)
-	findSeriesFunc       func(ctx context.Context, identifier string, startMonth, endMonth, limit, offset int) (*OperatingSystemIdPopularityList, error)
+	findAllFunc          func(ctx context.Context, query string, startMonth, endMonth, limit, offset int, opts web.ListOptions) (*OperatingSystemIdPopularityList, error)
+	findSeriesFunc       func(ctx context.Context, identifier string, startMonth, endMonth, limit, offset int, opts web.SeriesOptions) (*OperatingSystemIdPopularityList, error)
+	findDiffFunc         func(ctx context.Context, fromMonth, toMonth, limit, offset int) (*popularity.DiffList, error)
 }
 
 func (m *mockQuerier) FindByIdentifier(ctx context.Context, identifier string, startMonth, endMonth int) (*OperatingSystemIdPopularity, error) {
 	return m.findByIdentifierFunc(ctx, identifier, startMonth, endMonth)
 }
 
-func (m *mockQuerier) FindAll(ctx context.Context, query string, startMonth, endMonth, limit, offset int) (*OperatingSystemIdPopularityList, error) {
-	return m.findAllFunc(ctx, query, startMonth, endMonth, limit, offset)
+func (m *mockQuerier) FindAll(ctx context.Context, query string, startMonth, endMonth, limit, offset int, opts web.ListOptions) (*OperatingSystemIdPopularityList, error) {
+	return m.findAllFunc(ctx, query, startMonth, endMonth, limit, offset, opts)
 }
 
-func (m *mockQuerier) FindSeries(ctx context.Context, identifier string, startMonth, endMonth, limit, offset int) (*OperatingSystemIdPopularityList, error) {
-	return m.findSeriesFunc(ctx, identifier, startMonth, endMonth, limit, offset)
+func (m *mockQuerier) FindSeries(ctx context.Context, identifier string, startMonth, endMonth, limit, offset int, opts web.SeriesOptions) (*OperatingSystemIdPopularityList, error) {
+	return m.findSeriesFunc(ctx, identifier, startMonth, endMonth, limit, offset, opts)
+}
+
+func (m *mockQuerier) FindDiff(ctx context.Context, fromMonth, toMonth, limit, offset int) (*popularity.DiffList, error) {
+	return m.findDiffFunc(ctx, fromMonth, toMonth, limit, offset)
 }
 
 func newTestMux(q *mockQuerier) *http.ServeMux {
@@ -88,7 +94,7 @@ func TestHandleGet_RepositoryError(t *testing.T) {
 
 func TestHandleList_ResponseStructure(t *testing.T) {
 	q := &mockQuerier{
-		findAllFunc: func(_ context.Context, query string, _, _, limit, offset int) (*OperatingSystemIdPopularityList, error) {
+		findAllFunc: func(_ context.Context, query string, _, _, limit, offset int, _ web.ListOptions) (*OperatingSystemIdPopularityList, error) {
 			return &OperatingSystemIdPopularityList{
 				OperatingSystemIdPopularities: []OperatingSystemIdPopularity{},
 				Limit:                         limit,
@@ -134,7 +140,7 @@ func TestHandleList_PaginationValidCases(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			var capturedLimit, capturedOffset int
 			q := &mockQuerier{
-				findAllFunc: func(_ context.Context, query string, _, _, limit, offset int) (*OperatingSystemIdPopularityList, error) {
+				findAllFunc: func(_ context.Context, query string, _, _, limit, offset int, _ web.ListOptions) (*OperatingSystemIdPopularityList, error) {
 					capturedLimit = limit
 					capturedOffset = offset
 					return &OperatingSystemIdPopularityList{
@@ -192,7 +198,7 @@ func TestHandleList_PaginationInvalidCases(t *testing.T) {
 
 func TestHandleSeries(t *testing.T) {
 	q := &mockQuerier{
-		findSeriesFunc: func(_ context.Context, id string, _, _, limit, _ int) (*OperatingSystemIdPopularityList, error) {
+		findSeriesFunc: func(_ context.Context, id string, _, _, limit, _ int, _ web.SeriesOptions) (*OperatingSystemIdPopularityList, error) {
 			return &OperatingSystemIdPopularityList{
 				Total:                         1,
 				Count:                         1,
@@ -9,25 +9,31 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"pkgstatsd/internal/popularity"
 	"pkgstatsd/internal/web"
 )
 
 type mockQuerier struct {
 	findByIdentifierFunc func(ctx context.Context, identifier string, startMonth, endMonth int) (*OperatingSystemArchitecturePopularity, error)
-	findAllFunc          func(ctx context.Context, query string, startMonth, endMonth, limit, offset int) (*OperatingSystemArchitecturePopularityList, error)
-	findSeriesFunc       func(ctx context.Context, identifier string, startMonth, endMonth, limit, offset int) (*OperatingSystemArchitecturePopularityList, error)
+	findAllFunc          func(ctx context.Context, query string, startMonth, endMonth, limit, offset int, opts web.ListOptions) (*OperatingSystemArchitecturePopularityList, error)
+	findSeriesFunc       func(ctx context.Context, identifier string, startMonth, endMonth, limit, offset int, opts web.SeriesOptions) (*OperatingSystemArchitecturePopularityList, error)
+	findDiffFunc         func(ctx context.Context, fromMonth, toMonth, limit, offset int) (*popularity.DiffList, error)
 }
 
 func (m *mockQuerier) FindByIdentifier(ctx context.Context, identifier string, startMonth, endMonth int) (*OperatingSystemArchitecturePopularity, error) {
 	return m.findByIdentifierFunc(ctx, identifier, startMonth, endMonth)
 }
 
-func (m *mockQuerier) FindAll(ctx context.Context, query string, startMonth, endMonth, limit, offset int) (*OperatingSystemArchitecturePopularityList, error) {
-	return m.findAllFunc(ctx, query, startMonth, endMonth, limit, offset)
+func (m *mockQuerier) FindAll(ctx context.Context, query string, startMonth, endMonth, limit, offset int, opts web.ListOptions) (*OperatingSystemArchitecturePopularityList, error) {
+	return m.findAllFunc(ctx, query, startMonth, endMonth, limit, offset, opts)
 }
 
-func (m *mockQuerier) FindSeries(ctx context.Context, identifier string, startMonth, endMonth, limit, offset int) (*OperatingSystemArchitecturePopularityList, error) {
-	return m.findSeriesFunc(ctx, identifier, startMonth, endMonth, limit, offset)
+func (m *mockQuerier) FindSeries(ctx context.Context, identifier string, startMonth, endMonth, limit, offset int, opts web.SeriesOptions) (*OperatingSystemArchitecturePopularityList, error) {
+	return m.findSeriesFunc(ctx, identifier, startMonth, endMonth, limit, offset, opts)
+}
+
+func (m *mockQuerier) FindDiff(ctx context.Context, fromMonth, toMonth, limit, offset int) (*popularity.DiffList, error) {
+	return m.findDiffFunc(ctx, fromMonth, toMonth, limit, offset)
 }
 
 func newTestMux(q *mockQuerier) *http.ServeMux {
@@ -85,7 +91,7 @@ func TestHandleGet_RepositoryError(t *testing.T) {
 
 func TestHandleList_ResponseStructure(t *testing.T) {
 	q := &mockQuerier{
-		findAllFunc: func(_ context.Context, query string, _, _, limit, offset int) (*OperatingSystemArchitecturePopularityList, error) {
+		findAllFunc: func(_ context.Context, query string, _, _, limit, offset int, _ web.ListOptions) (*OperatingSystemArchitecturePopularityList, error) {
 			return &OperatingSystemArchitecturePopularityList{
 				OperatingSystemArchitecturePopularities: []OperatingSystemArchitecturePopularity{},
 				Limit:                                   limit,
@@ -131,7 +137,7 @@ func TestHandleList_PaginationValidCases(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			var capturedLimit, capturedOffset int
 			q := &mockQuerier{
-				findAllFunc: func(_ context.Context, query string, _, _, limit, offset int) (*OperatingSystemArchitecturePopularityList, error) {
+				findAllFunc: func(_ context.Context, query string, _, _, limit, offset int, _ web.ListOptions) (*OperatingSystemArchitecturePopularityList, error) {
 					capturedLimit = limit
 					capturedOffset = offset
 					return &OperatingSystemArchitecturePopularityList{
@@ -189,7 +195,7 @@ func TestHandleList_PaginationInvalidCases(t *testing.T) {
 
 func TestHandleSeries(t *testing.T) {
 	q := &mockQuerier{
-		findSeriesFunc: func(_ context.Context, name string, _, _, limit, _ int) (*OperatingSystemArchitecturePopularityList, error) {
+		findSeriesFunc: func(_ context.Context, name string, _, _, limit, _ int, _ web.SeriesOptions) (*OperatingSystemArchitecturePopularityList, error) {
 			return &OperatingSystemArchitecturePopularityList{
 				Total:                                   1,
 				Count:                                   1,
@@ -1,14 +1,20 @@
 package osarchitectures
 
-import "context"
+import (
+	"context"
+
+	"pkgstatsd/internal/popularity"
+	"pkgstatsd/internal/web"
+)
 
 type OperatingSystemArchitecturePopularity struct {
 	Name       string  `json:"name"`
 	Samples    int     `json:"samples"`
 	Count      int     `json:"count"`
 	Popularity float64 `json:"popularity"`
-	StartMonth int     `json:"startMonth"`
-	EndMonth   int     `json:"endMonth"`
+	*popularity.Interval
+	StartMonth int `json:"startMonth"`
+	EndMonth   int `json:"endMonth"`
 }
 
 type OperatingSystemArchitecturePopularityList struct {
@@ -18,24 +24,26 @@ type OperatingSystemArchitecturePopularityList struct {
 	Limit                                   int                                     `json:"limit"`
 	Offset                                  int                                     `json:"offset"`
 	Query                                   *string                                 `json:"query"`
+	Next                                    *string                                 `json:"next,omitempty"`
+	Smoothing                               string                                  `json:"smoothing,omitempty"`
 }
 
 type Repository interface {
 	FindByName(ctx context.Context, name string, startMonth, endMonth int) (*OperatingSystemArchitecturePopularity, error)
-	FindAll(ctx context.Context, query string, startMonth, endMonth, limit, offset int) (*OperatingSystemArchitecturePopularityList, error)
-	FindSeriesByName(ctx context.Context, name string, startMonth, endMonth, limit, offset int) (*OperatingSystemArchitecturePopularityList, error)
+	FindAll(ctx context.Context, query string, startMonth, endMonth, limit, offset int, opts web.ListOptions) (*OperatingSystemArchitecturePopularityList, error)
+	FindSeriesByName(ctx context.Context, name string, startMonth, endMonth, limit, offset int, opts web.SeriesOptions) (*OperatingSystemArchitecturePopularityList, error)
 }
 
-func newItem(identifier string, samples, count int, popularity float64, startMonth, endMonth int) OperatingSystemArchitecturePopularity {
+func newItem(identifier string, samples, count int, popularity float64, interval *popularity.Interval, startMonth, endMonth int) OperatingSystemArchitecturePopularity {
 	return OperatingSystemArchitecturePopularity{
 		Name: identifier, Samples: samples, Count: count,
-		Popularity: popularity, StartMonth: startMonth, EndMonth: endMonth,
+		Popularity: popularity, Interval: interval, StartMonth: startMonth, EndMonth: endMonth,
 	}
 }
 
-func newList(total, count int, items []OperatingSystemArchitecturePopularity, limit, offset int, query *string) OperatingSystemArchitecturePopularityList {
+func newList(total, count int, items []OperatingSystemArchitecturePopularity, limit, offset int, query, next *string, smoothing string) OperatingSystemArchitecturePopularityList {
 	return OperatingSystemArchitecturePopularityList{
 		Total: total, Count: count, OperatingSystemArchitecturePopularities: items,
-		Limit: limit, Offset: offset, Query: query,
+		Limit: limit, Offset: offset, Query: query, Next: next, Smoothing: smoothing,
 	}
 }
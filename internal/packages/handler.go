@@ -1,11 +1,19 @@
 package packages
 
 import (
+	"fmt"
 	"net/http"
 
 	"pkgstatsd/internal/web"
 )
 
+const (
+	defaultSuggestLimit   = 10
+	maxSuggestLimit       = 100
+	defaultForecastMonths = 6
+	maxForecastMonths     = 24
+)
+
 type Handler struct {
 	repo Repository
 }
@@ -27,13 +35,18 @@ func (h *Handler) HandleGet(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	validators, done := web.CheckNotModified(w, r, endMonth)
+	if done {
+		return
+	}
+
 	pkg, err := h.repo.FindByName(r.Context(), name, startMonth, endMonth)
 	if err != nil {
-		web.ServerError(w, "failed to find package", err)
+		web.ServerError(w, r, "failed to find package", err)
 		return
 	}
 
-	web.WriteEntityJSON(w, pkg)
+	web.WriteEntityJSON(w, r, validators, pkg)
 }
 
 func (h *Handler) HandleList(w http.ResponseWriter, r *http.Request) {
@@ -55,13 +68,24 @@ func (h *Handler) HandleList(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	list, err := h.repo.FindAll(r.Context(), query, startMonth, endMonth, limit, offset)
+	opts, err := web.ParseListOptions(r)
 	if err != nil {
-		web.ServerError(w, "failed to list packages", err)
+		web.BadRequest(w, err.Error())
+		return
+	}
+
+	validators, done := web.CheckNotModified(w, r, endMonth)
+	if done {
 		return
 	}
 
-	web.WriteEntityJSON(w, list)
+	list, err := h.repo.FindAll(r.Context(), query, startMonth, endMonth, limit, offset, opts)
+	if err != nil {
+		web.ServerError(w, r, "failed to list packages", err)
+		return
+	}
+
+	web.WriteEntityJSON(w, r, validators, list)
 }
 
 func (h *Handler) HandleSeries(w http.ResponseWriter, r *http.Request) {
@@ -83,17 +107,196 @@ func (h *Handler) HandleSeries(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	list, err := h.repo.FindSeriesByName(r.Context(), name, startMonth, endMonth, limit, offset)
+	opts, err := web.ParseSeriesOptions(r)
+	if err != nil {
+		web.BadRequest(w, err.Error())
+		return
+	}
+
+	validators, done := web.CheckNotModified(w, r, endMonth)
+	if done {
+		return
+	}
+
+	list, err := h.repo.FindSeriesByName(r.Context(), name, startMonth, endMonth, limit, offset, opts)
+	if err != nil {
+		web.ServerError(w, r, "failed to find package series", err)
+		return
+	}
+
+	web.WriteEntityJSON(w, r, validators, list)
+}
+
+func (h *Handler) HandleForecast(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if name == "" {
+		web.BadRequest(w, "package name required")
+		return
+	}
+
+	months, err := web.ParseIntParam(r, "months", defaultForecastMonths)
+	if err != nil {
+		web.BadRequest(w, err.Error())
+		return
+	}
+
+	if months < 1 || months > maxForecastMonths {
+		web.BadRequest(w, fmt.Sprintf("months must be between 1 and %d", maxForecastMonths))
+		return
+	}
+
+	// Forecasts extrapolate the complete months.
+	validators, done := web.CheckNotModified(w, r, web.GetLastCompleteMonth())
+	if done {
+		return
+	}
+
+	forecast, err := h.repo.FindForecastByName(r.Context(), name, months)
+	if err != nil {
+		web.ServerError(w, r, "failed to forecast package", err)
+		return
+	}
+
+	web.WriteEntityJSON(w, r, validators, forecast)
+}
+
+// HandleNew lists the packages first listed in a month. Unlike the other
+// lists it takes no ListOptions: being listed depends on the fixed
+// threshold, and the report is ordered by count.
+func (h *Handler) HandleNew(w http.ResponseWriter, r *http.Request) {
+	month, err := web.ParseMonth(r)
+	if err != nil {
+		web.BadRequest(w, err.Error())
+		return
+	}
+
+	limit, offset, err := web.ParsePagination(r)
+	if err != nil {
+		web.BadRequest(w, err.Error())
+		return
+	}
+
+	validators, done := web.CheckNotModified(w, r, month)
+	if done {
+		return
+	}
+
+	list, err := h.repo.FindNew(r.Context(), month, limit, offset)
+	if err != nil {
+		web.ServerError(w, r, "failed to list new packages", err)
+		return
+	}
+
+	web.WriteEntityJSON(w, r, validators, list)
+}
+
+// HandleGone lists the packages last listed in the month before. Like
+// HandleNew, it takes no ListOptions.
+func (h *Handler) HandleGone(w http.ResponseWriter, r *http.Request) {
+	month, err := web.ParseMonth(r)
+	if err != nil {
+		web.BadRequest(w, err.Error())
+		return
+	}
+
+	limit, offset, err := web.ParsePagination(r)
+	if err != nil {
+		web.BadRequest(w, err.Error())
+		return
+	}
+
+	// Whether a package is gone depends on all months since, up to the
+	// current one.
+	validators, done := web.CheckNotModified(w, r, web.GetCurrentMonth())
+	if done {
+		return
+	}
+
+	list, err := h.repo.FindGone(r.Context(), month, limit, offset)
+	if err != nil {
+		web.ServerError(w, r, "failed to list gone packages", err)
+		return
+	}
+
+	web.WriteEntityJSON(w, r, validators, list)
+}
+
+func (h *Handler) HandleDiff(w http.ResponseWriter, r *http.Request) {
+	fromMonth, toMonth, err := web.ParseDiffMonths(r)
+	if err != nil {
+		web.BadRequest(w, err.Error())
+		return
+	}
+
+	limit, offset, err := web.ParsePagination(r)
+	if err != nil {
+		web.BadRequest(w, err.Error())
+		return
+	}
+
+	validators, done := web.CheckNotModified(w, r, toMonth)
+	if done {
+		return
+	}
+
+	list, err := h.repo.FindDiff(r.Context(), fromMonth, toMonth, limit, offset)
+	if err != nil {
+		web.ServerError(w, r, "failed to compare packages", err)
+		return
+	}
+
+	web.WriteEntityJSON(w, r, validators, list)
+}
+
+func (h *Handler) HandleSuggest(w http.ResponseWriter, r *http.Request) {
+	prefix, err := web.ParseQueryParam(r, "q")
+	if err != nil {
+		web.BadRequest(w, err.Error())
+		return
+	}
+
+	if prefix == "" {
+		web.BadRequest(w, "q parameter required")
+		return
+	}
+
+	limit, err := web.ParseIntParam(r, "limit", defaultSuggestLimit)
+	if err != nil {
+		web.BadRequest(w, err.Error())
+		return
+	}
+
+	if limit < 1 || limit > maxSuggestLimit {
+		web.BadRequest(w, fmt.Sprintf("limit must be between 1 and %d", maxSuggestLimit))
+		return
+	}
+
+	// Suggestions are ranked by the last complete month.
+	validators, done := web.CheckNotModified(w, r, web.GetLastCompleteMonth())
+	if done {
+		return
+	}
+
+	suggestions, err := h.repo.Suggest(r.Context(), prefix, limit)
 	if err != nil {
-		web.ServerError(w, "failed to find package series", err)
+		web.ServerError(w, r, "failed to suggest packages", err)
 		return
 	}
 
-	web.WriteEntityJSON(w, list)
+	web.WriteEntityJSON(w, r, validators, suggestions)
 }
 
+// RegisterRoutes registers the package API. The report routes are more
+// specific than GET /api/packages/{name}, so the mux prefers them: packages
+// of the same name are still counted and have series, but cannot be fetched
+// as a single item.
 func (h *Handler) RegisterRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("GET /api/packages", h.HandleList)
+	mux.HandleFunc("GET /api/packages/new", h.HandleNew)
+	mux.HandleFunc("GET /api/packages/gone", h.HandleGone)
+	mux.HandleFunc("GET /api/packages/suggest", h.HandleSuggest)
+	mux.HandleFunc("GET /api/packages/diff", h.HandleDiff)
 	mux.HandleFunc("GET /api/packages/{name}", h.HandleGet)
 	mux.HandleFunc("GET /api/packages/{name}/series", h.HandleSeries)
+	mux.HandleFunc("GET /api/packages/{name}/forecast", h.HandleForecast)
 }
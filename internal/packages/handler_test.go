@@ -9,26 +9,52 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"pkgstatsd/internal/popularity"
 	"pkgstatsd/internal/web"
 )
 
 // mockRepository implements Repository for testing
 type mockRepository struct {
 	findByNameFunc       func(ctx context.Context, name string, startMonth, endMonth int) (*PackagePopularity, error)
-	findAllFunc          func(ctx context.Context, query string, startMonth, endMonth, limit, offset int) (*PackagePopularityList, error)
-	findSeriesByNameFunc func(ctx context.Context, name string, startMonth, endMonth, limit, offset int) (*PackagePopularityList, error)
+	findAllFunc          func(ctx context.Context, query string, startMonth, endMonth, limit, offset int, opts web.ListOptions) (*PackagePopularityList, error)
+	findSeriesByNameFunc func(ctx context.Context, name string, startMonth, endMonth, limit, offset int, opts web.SeriesOptions) (*PackagePopularityList, error)
+	findNewFunc          func(ctx context.Context, month, limit, offset int) (*PackagePopularityList, error)
+	findGoneFunc         func(ctx context.Context, month, limit, offset int) (*GonePackageList, error)
+	suggestFunc          func(ctx context.Context, prefix string, limit int) (*PackageSuggestions, error)
+	findForecastFunc     func(ctx context.Context, name string, months int) (*PackageForecast, error)
+	findDiffFunc         func(ctx context.Context, fromMonth, toMonth, limit, offset int) (*popularity.DiffList, error)
 }
 
 func (m *mockRepository) FindByName(ctx context.Context, name string, startMonth, endMonth int) (*PackagePopularity, error) {
 	return m.findByNameFunc(ctx, name, startMonth, endMonth)
 }
 
-func (m *mockRepository) FindAll(ctx context.Context, query string, startMonth, endMonth, limit, offset int) (*PackagePopularityList, error) {
-	return m.findAllFunc(ctx, query, startMonth, endMonth, limit, offset)
+func (m *mockRepository) FindAll(ctx context.Context, query string, startMonth, endMonth, limit, offset int, opts web.ListOptions) (*PackagePopularityList, error) {
+	return m.findAllFunc(ctx, query, startMonth, endMonth, limit, offset, opts)
 }
 
-func (m *mockRepository) FindSeriesByName(ctx context.Context, name string, startMonth, endMonth, limit, offset int) (*PackagePopularityList, error) {
-	return m.findSeriesByNameFunc(ctx, name, startMonth, endMonth, limit, offset)
+func (m *mockRepository) FindSeriesByName(ctx context.Context, name string, startMonth, endMonth, limit, offset int, opts web.SeriesOptions) (*PackagePopularityList, error) {
+	return m.findSeriesByNameFunc(ctx, name, startMonth, endMonth, limit, offset, opts)
+}
+
+func (m *mockRepository) FindNew(ctx context.Context, month, limit, offset int) (*PackagePopularityList, error) {
+	return m.findNewFunc(ctx, month, limit, offset)
+}
+
+func (m *mockRepository) FindGone(ctx context.Context, month, limit, offset int) (*GonePackageList, error) {
+	return m.findGoneFunc(ctx, month, limit, offset)
+}
+
+func (m *mockRepository) Suggest(ctx context.Context, prefix string, limit int) (*PackageSuggestions, error) {
+	return m.suggestFunc(ctx, prefix, limit)
+}
+
+func (m *mockRepository) FindForecastByName(ctx context.Context, name string, months int) (*PackageForecast, error) {
+	return m.findForecastFunc(ctx, name, months)
+}
+
+func (m *mockRepository) FindDiff(ctx context.Context, fromMonth, toMonth, limit, offset int) (*popularity.DiffList, error) {
+	return m.findDiffFunc(ctx, fromMonth, toMonth, limit, offset)
 }
 
 func currentMonth() int {
@@ -46,12 +72,13 @@ func newTestMux(repo Repository) *http.ServeMux {
 func captureListRepo() (*mockRepository, *capturedListParams) {
 	captured := &capturedListParams{}
 	repo := &mockRepository{
-		findAllFunc: func(_ context.Context, query string, startMonth, endMonth, limit, offset int) (*PackagePopularityList, error) {
+		findAllFunc: func(_ context.Context, query string, startMonth, endMonth, limit, offset int, opts web.ListOptions) (*PackagePopularityList, error) {
 			captured.query = query
 			captured.startMonth = startMonth
 			captured.endMonth = endMonth
 			captured.limit = limit
 			captured.offset = offset
+			captured.opts = opts
 			return &PackagePopularityList{
 				PackagePopularities: []PackagePopularity{},
 				Limit:               limit,
@@ -69,6 +96,30 @@ type capturedListParams struct {
 	endMonth   int
 	limit      int
 	offset     int
+	opts       web.ListOptions
+}
+
+func TestRegisterRoutes_Reports(t *testing.T) {
+	mux := newTestMux(&mockRepository{})
+
+	// Report routes take precedence over packages of the same name; other
+	// paths below them still reach the package.
+	tests := map[string]string{
+		"/api/packages/new":         "GET /api/packages/new",
+		"/api/packages/gone":        "GET /api/packages/gone",
+		"/api/packages/suggest":     "GET /api/packages/suggest",
+		"/api/packages/diff":        "GET /api/packages/diff",
+		"/api/packages/newsboat":    "GET /api/packages/{name}",
+		"/api/packages/new/series":  "GET /api/packages/{name}/series",
+		"/api/packages/gone/series": "GET /api/packages/{name}/series",
+	}
+
+	for path, want := range tests {
+		_, pattern := mux.Handler(httptest.NewRequest(http.MethodGet, path, nil))
+		if pattern != want {
+			t.Errorf("%s: expected route %q, got %q", path, want, pattern)
+		}
+	}
 }
 
 func TestHandleGet(t *testing.T) {
@@ -164,7 +215,7 @@ func TestHandleList(t *testing.T) {
 	const testQuery = "pac"
 
 	repo := &mockRepository{
-		findAllFunc: func(_ context.Context, query string, _, _, limit, offset int) (*PackagePopularityList, error) {
+		findAllFunc: func(_ context.Context, query string, _, _, limit, offset int, _ web.ListOptions) (*PackagePopularityList, error) {
 			return &PackagePopularityList{
 				Total: 1,
 				Count: 1,
@@ -312,6 +363,41 @@ func TestHandleList_InvalidLimitReturns400(t *testing.T) {
 	}
 }
 
+func TestHandleList_ListOptions(t *testing.T) {
+	repo, captured := captureListRepo()
+
+	mux := newTestMux(repo)
+	req := httptest.NewRequest(http.MethodGet, "/api/packages?sort=name&order=desc&minCount=5", nil)
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+
+	want := web.ListOptions{Sort: web.SortName, Order: web.OrderDesc, MinCount: 5}
+	if captured.opts != want {
+		t.Errorf("expected options %+v, got %+v", want, captured.opts)
+	}
+}
+
+func TestHandleList_InvalidListOptions(t *testing.T) {
+	for _, query := range []string{"sort=size", "order=random", "minCount=1", "cursor=bogus"} {
+		t.Run(query, func(t *testing.T) {
+			repo, _ := captureListRepo()
+
+			mux := newTestMux(repo)
+			req := httptest.NewRequest(http.MethodGet, "/api/packages?"+query, nil)
+			rr := httptest.NewRecorder()
+			mux.ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusBadRequest {
+				t.Errorf("expected status %d, got %d", http.StatusBadRequest, rr.Code)
+			}
+		})
+	}
+}
+
 func TestHandleList_MonthRangeDefaults(t *testing.T) {
 	repo, captured := captureListRepo()
 	expected := currentMonth()
@@ -392,7 +478,7 @@ func TestHandleSeries_MonthZeroMeansNoFilter(t *testing.T) {
 	var capturedStart, capturedEnd int
 	cm := currentMonth()
 	repo := &mockRepository{
-		findSeriesByNameFunc: func(_ context.Context, _ string, startMonth, endMonth, _, _ int) (*PackagePopularityList, error) {
+		findSeriesByNameFunc: func(_ context.Context, _ string, startMonth, endMonth, _, _ int, _ web.SeriesOptions) (*PackagePopularityList, error) {
 			capturedStart = startMonth
 			capturedEnd = endMonth
 			return &PackagePopularityList{PackagePopularities: []PackagePopularity{}}, nil
@@ -472,7 +558,7 @@ func TestHandleList_EmptyQuery(t *testing.T) {
 
 func TestHandleList_RepositoryError(t *testing.T) {
 	repo := &mockRepository{
-		findAllFunc: func(_ context.Context, _ string, _, _, _, _ int) (*PackagePopularityList, error) {
+		findAllFunc: func(_ context.Context, _ string, _, _, _, _ int, _ web.ListOptions) (*PackagePopularityList, error) {
 			return nil, errors.New("database error")
 		},
 	}
@@ -489,7 +575,7 @@ func TestHandleList_RepositoryError(t *testing.T) {
 
 func TestHandleSeries(t *testing.T) {
 	repo := &mockRepository{
-		findSeriesByNameFunc: func(_ context.Context, name string, _, _, limit, _ int) (*PackagePopularityList, error) {
+		findSeriesByNameFunc: func(_ context.Context, name string, _, _, limit, _ int, _ web.SeriesOptions) (*PackagePopularityList, error) {
 			return &PackagePopularityList{
 				Total: 3,
 				Count: 3,
@@ -528,7 +614,7 @@ func TestHandleSeries(t *testing.T) {
 func TestHandleSeries_LimitZeroMeansMaxLimit(t *testing.T) {
 	var capturedLimit int
 	repo := &mockRepository{
-		findSeriesByNameFunc: func(_ context.Context, _ string, _, _, limit, _ int) (*PackagePopularityList, error) {
+		findSeriesByNameFunc: func(_ context.Context, _ string, _, _, limit, _ int, _ web.SeriesOptions) (*PackagePopularityList, error) {
 			capturedLimit = limit
 			return &PackagePopularityList{PackagePopularities: []PackagePopularity{}}, nil
 		},
@@ -549,7 +635,7 @@ func TestHandleSeries_LimitZeroMeansMaxLimit(t *testing.T) {
 
 func TestHandleSeries_RepositoryError(t *testing.T) {
 	repo := &mockRepository{
-		findSeriesByNameFunc: func(_ context.Context, _ string, _, _, _, _ int) (*PackagePopularityList, error) {
+		findSeriesByNameFunc: func(_ context.Context, _ string, _, _, _, _ int, _ web.SeriesOptions) (*PackagePopularityList, error) {
 			return nil, errors.New("database error")
 		},
 	}
@@ -564,6 +650,85 @@ func TestHandleSeries_RepositoryError(t *testing.T) {
 	}
 }
 
+func TestHandleSeries_Granularity(t *testing.T) {
+	var capturedGranularity string
+	repo := &mockRepository{
+		findSeriesByNameFunc: func(_ context.Context, _ string, _, _, _, _ int, opts web.SeriesOptions) (*PackagePopularityList, error) {
+			capturedGranularity = opts.Granularity
+			return &PackagePopularityList{PackagePopularities: []PackagePopularity{}}, nil
+		},
+	}
+
+	tests := []struct {
+		url      string
+		expected string
+	}{
+		{"/api/packages/pacman/series", web.GranularityMonth},
+		{"/api/packages/pacman/series?granularity=quarter", web.GranularityQuarter},
+		{"/api/packages/pacman/series?granularity=year", web.GranularityYear},
+	}
+
+	mux := newTestMux(repo)
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("%s: expected status %d, got %d", tt.url, http.StatusOK, rr.Code)
+		}
+		if capturedGranularity != tt.expected {
+			t.Errorf("%s: expected granularity %q, got %q", tt.url, tt.expected, capturedGranularity)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/packages/pacman/series?granularity=week", nil)
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, rr.Code)
+	}
+}
+
+func TestHandleSeries_Smoothing(t *testing.T) {
+	var capturedOpts web.SeriesOptions
+	repo := &mockRepository{
+		findSeriesByNameFunc: func(_ context.Context, _ string, _, _, _, _ int, opts web.SeriesOptions) (*PackagePopularityList, error) {
+			capturedOpts = opts
+			return &PackagePopularityList{PackagePopularities: []PackagePopularity{}, Smoothing: opts.Smoothing.String()}, nil
+		},
+	}
+
+	mux := newTestMux(repo)
+	req := httptest.NewRequest(http.MethodGet, "/api/packages/pacman/series?smooth=ema6", nil)
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+	if capturedOpts.Smoothing != (web.Smoothing{Method: web.SmoothExponential, Window: 6}) {
+		t.Errorf("unexpected smoothing %+v", capturedOpts.Smoothing)
+	}
+
+	var raw map[string]any
+	if err := json.NewDecoder(rr.Body).Decode(&raw); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if raw["smoothing"] != "ema6" {
+		t.Errorf("expected smoothing flag ema6, got %v", raw["smoothing"])
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/packages/pacman/series?smooth=ma99", nil)
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, rr.Code)
+	}
+}
+
 func TestHandleList_ContentType(t *testing.T) {
 	repo, _ := captureListRepo()
 
@@ -637,7 +802,7 @@ func TestHandleGet_ContextCanceled(t *testing.T) {
 
 func TestHandleList_ContextCanceled(t *testing.T) {
 	repo := &mockRepository{
-		findAllFunc: func(_ context.Context, _ string, _, _, _, _ int) (*PackagePopularityList, error) {
+		findAllFunc: func(_ context.Context, _ string, _, _, _, _ int, _ web.ListOptions) (*PackagePopularityList, error) {
 			return nil, fmt.Errorf("count packages: %w", context.Canceled)
 		},
 	}
@@ -657,7 +822,7 @@ func TestHandleList_ContextCanceled(t *testing.T) {
 
 func TestHandleSeries_ContextCanceled(t *testing.T) {
 	repo := &mockRepository{
-		findSeriesByNameFunc: func(_ context.Context, _ string, _, _, _, _ int) (*PackagePopularityList, error) {
+		findSeriesByNameFunc: func(_ context.Context, _ string, _, _, _, _ int, _ web.SeriesOptions) (*PackagePopularityList, error) {
 			return nil, context.Canceled
 		},
 	}
@@ -703,3 +868,337 @@ func TestHandleList_PaginationInvalidCases(t *testing.T) {
 		})
 	}
 }
+
+func TestHandleNew(t *testing.T) {
+	var capturedMonth, capturedLimit int
+	repo := &mockRepository{
+		findNewFunc: func(_ context.Context, month, limit, _ int) (*PackagePopularityList, error) {
+			capturedMonth = month
+			capturedLimit = limit
+			return &PackagePopularityList{
+				Total:               1,
+				Count:               1,
+				PackagePopularities: []PackagePopularity{{Name: "newpkg", Count: 20, StartMonth: month, EndMonth: month}},
+				Limit:               limit,
+			}, nil
+		},
+	}
+
+	mux := newTestMux(repo)
+	req := httptest.NewRequest(http.MethodGet, "/api/packages/new?month=202501&limit=5", nil)
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+	if capturedMonth != 202501 {
+		t.Errorf("expected month 202501, got %d", capturedMonth)
+	}
+	if capturedLimit != 5 {
+		t.Errorf("expected limit 5, got %d", capturedLimit)
+	}
+
+	var list PackagePopularityList
+	if err := json.NewDecoder(rr.Body).Decode(&list); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if len(list.PackagePopularities) != 1 || list.PackagePopularities[0].Name != "newpkg" {
+		t.Errorf("unexpected packages: %+v", list.PackagePopularities)
+	}
+}
+
+func TestHandleNew_DefaultMonth(t *testing.T) {
+	var capturedMonth int
+	repo := &mockRepository{
+		findNewFunc: func(_ context.Context, month, _, _ int) (*PackagePopularityList, error) {
+			capturedMonth = month
+			return &PackagePopularityList{PackagePopularities: []PackagePopularity{}}, nil
+		},
+	}
+
+	mux := newTestMux(repo)
+	req := httptest.NewRequest(http.MethodGet, "/api/packages/new", nil)
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+	if capturedMonth != currentMonth() {
+		t.Errorf("expected month %d, got %d", currentMonth(), capturedMonth)
+	}
+}
+
+func TestHandleNew_InvalidMonth(t *testing.T) {
+	mux := newTestMux(&mockRepository{})
+	req := httptest.NewRequest(http.MethodGet, "/api/packages/new?month=202513", nil)
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, rr.Code)
+	}
+}
+
+func TestHandleGone(t *testing.T) {
+	var capturedMonth int
+	repo := &mockRepository{
+		findGoneFunc: func(_ context.Context, month, limit, offset int) (*GonePackageList, error) {
+			capturedMonth = month
+			return &GonePackageList{
+				Total:        1,
+				Count:        1,
+				GonePackages: []GonePackage{{Name: "oldpkg", LastMonth: 202412, PeakMonth: 202406, PeakPopularity: 12.5}},
+				Limit:        limit,
+				Offset:       offset,
+			}, nil
+		},
+	}
+
+	mux := newTestMux(repo)
+	req := httptest.NewRequest(http.MethodGet, "/api/packages/gone?month=202501", nil)
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+	if capturedMonth != 202501 {
+		t.Errorf("expected month 202501, got %d", capturedMonth)
+	}
+
+	var raw map[string]any
+	if err := json.NewDecoder(rr.Body).Decode(&raw); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+
+	expectedKeys := []string{"total", "count", "gonePackages", "limit", "offset"}
+	for _, key := range expectedKeys {
+		if _, ok := raw[key]; !ok {
+			t.Errorf("missing key %q in response", key)
+		}
+	}
+}
+
+func TestHandleGone_RepositoryError(t *testing.T) {
+	repo := &mockRepository{
+		findGoneFunc: func(_ context.Context, _, _, _ int) (*GonePackageList, error) {
+			return nil, errors.New("database error")
+		},
+	}
+
+	mux := newTestMux(repo)
+	req := httptest.NewRequest(http.MethodGet, "/api/packages/gone", nil)
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusInternalServerError {
+		t.Errorf("expected status %d, got %d", http.StatusInternalServerError, rr.Code)
+	}
+}
+
+func TestHandleSuggest(t *testing.T) {
+	var capturedPrefix string
+	var capturedLimit int
+	repo := &mockRepository{
+		suggestFunc: func(_ context.Context, prefix string, limit int) (*PackageSuggestions, error) {
+			capturedPrefix = prefix
+			capturedLimit = limit
+			return &PackageSuggestions{Query: prefix, Suggestions: []string{"python", "python-requests"}}, nil
+		},
+	}
+
+	mux := newTestMux(repo)
+	req := httptest.NewRequest(http.MethodGet, "/api/packages/suggest?q=pyth", nil)
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+	if capturedPrefix != "pyth" {
+		t.Errorf("expected prefix pyth, got %q", capturedPrefix)
+	}
+	if capturedLimit != defaultSuggestLimit {
+		t.Errorf("expected limit %d, got %d", defaultSuggestLimit, capturedLimit)
+	}
+
+	var result PackageSuggestions
+	if err := json.NewDecoder(rr.Body).Decode(&result); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if result.Query != "pyth" || len(result.Suggestions) != 2 {
+		t.Errorf("unexpected response: %+v", result)
+	}
+}
+
+func TestHandleSuggest_InvalidParams(t *testing.T) {
+	repo := &mockRepository{
+		suggestFunc: func(_ context.Context, _ string, _ int) (*PackageSuggestions, error) {
+			t.Fatal("Suggest should not be called")
+			return nil, nil
+		},
+	}
+
+	tests := []struct {
+		name string
+		url  string
+	}{
+		{"missing q", "/api/packages/suggest"},
+		{"invalid q", "/api/packages/suggest?q=%3Cscript%3E"},
+		{"zero limit", "/api/packages/suggest?q=py&limit=0"},
+		{"limit too large", fmt.Sprintf("/api/packages/suggest?q=py&limit=%d", maxSuggestLimit+1)},
+	}
+
+	mux := newTestMux(repo)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			rr := httptest.NewRecorder()
+			mux.ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusBadRequest {
+				t.Errorf("expected status %d, got %d", http.StatusBadRequest, rr.Code)
+			}
+		})
+	}
+}
+
+func TestHandleSuggest_RepositoryError(t *testing.T) {
+	repo := &mockRepository{
+		suggestFunc: func(_ context.Context, _ string, _ int) (*PackageSuggestions, error) {
+			return nil, errors.New("database error")
+		},
+	}
+
+	mux := newTestMux(repo)
+	req := httptest.NewRequest(http.MethodGet, "/api/packages/suggest?q=py", nil)
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusInternalServerError {
+		t.Errorf("expected status %d, got %d", http.StatusInternalServerError, rr.Code)
+	}
+}
+
+func TestHandleForecast(t *testing.T) {
+	var capturedName string
+	var capturedMonths int
+	repo := &mockRepository{
+		findForecastFunc: func(_ context.Context, name string, months int) (*PackageForecast, error) {
+			capturedName = name
+			capturedMonths = months
+			return &PackageForecast{Name: name, StartMonth: 202401, EndMonth: 202412, Forecasts: []popularity.ForecastPoint{
+				{Month: 202501, Popularity: 12.5, Interval: popularity.Interval{Low: 10, High: 15}},
+			}}, nil
+		},
+	}
+
+	mux := newTestMux(repo)
+	req := httptest.NewRequest(http.MethodGet, "/api/packages/pacman/forecast", nil)
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+	if capturedName != "pacman" {
+		t.Errorf("expected name pacman, got %q", capturedName)
+	}
+	if capturedMonths != defaultForecastMonths {
+		t.Errorf("expected months %d, got %d", defaultForecastMonths, capturedMonths)
+	}
+
+	var raw map[string]any
+	if err := json.NewDecoder(rr.Body).Decode(&raw); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+
+	forecasts, ok := raw["forecasts"].([]any)
+	if !ok || len(forecasts) != 1 {
+		t.Fatalf("expected one forecast, got %v", raw["forecasts"])
+	}
+	point := forecasts[0].(map[string]any)
+	for _, key := range []string{"month", "popularity", "popularityLow", "popularityHigh"} {
+		if _, ok := point[key]; !ok {
+			t.Errorf("missing key %q in forecast point", key)
+		}
+	}
+}
+
+func TestHandleForecast_InvalidMonths(t *testing.T) {
+	repo := &mockRepository{
+		findForecastFunc: func(_ context.Context, _ string, _ int) (*PackageForecast, error) {
+			t.Fatal("FindForecastByName should not be called")
+			return nil, nil
+		},
+	}
+
+	mux := newTestMux(repo)
+	for _, months := range []string{"0", "-1", "abc", fmt.Sprint(maxForecastMonths + 1)} {
+		req := httptest.NewRequest(http.MethodGet, "/api/packages/pacman/forecast?months="+months, nil)
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("months=%s: expected status %d, got %d", months, http.StatusBadRequest, rr.Code)
+		}
+	}
+}
+
+func TestHandleForecast_RepositoryError(t *testing.T) {
+	repo := &mockRepository{
+		findForecastFunc: func(_ context.Context, _ string, _ int) (*PackageForecast, error) {
+			return nil, errors.New("database error")
+		},
+	}
+
+	mux := newTestMux(repo)
+	req := httptest.NewRequest(http.MethodGet, "/api/packages/pacman/forecast", nil)
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusInternalServerError {
+		t.Errorf("expected status %d, got %d", http.StatusInternalServerError, rr.Code)
+	}
+}
+
+func TestHandleDiff(t *testing.T) {
+	var capturedFrom, capturedTo int
+	repo := &mockRepository{
+		findDiffFunc: func(_ context.Context, fromMonth, toMonth, limit, offset int) (*popularity.DiffList, error) {
+			capturedFrom, capturedTo = fromMonth, toMonth
+			return &popularity.DiffList{FromMonth: fromMonth, ToMonth: toMonth, Entries: []popularity.DiffEntry{}, Limit: limit, Offset: offset}, nil
+		},
+	}
+
+	mux := newTestMux(repo)
+	req := httptest.NewRequest(http.MethodGet, "/api/packages/diff", nil)
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+	if capturedTo != currentMonth() || capturedFrom != web.OffsetMonth(currentMonth(), -1) {
+		t.Errorf("expected the last two complete months, got %d-%d", capturedFrom, capturedTo)
+	}
+}
+
+func TestHandleDiff_RepositoryError(t *testing.T) {
+	repo := &mockRepository{
+		findDiffFunc: func(_ context.Context, _, _, _, _ int) (*popularity.DiffList, error) {
+			return nil, errors.New("database error")
+		},
+	}
+
+	mux := newTestMux(repo)
+	req := httptest.NewRequest(http.MethodGet, "/api/packages/diff?from=202401&to=202501", nil)
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusInternalServerError {
+		t.Errorf("expected status %d, got %d", http.StatusInternalServerError, rr.Code)
+	}
+}
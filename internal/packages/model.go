@@ -1,12 +1,15 @@
 package packages
 
+import "pkgstatsd/internal/popularity"
+
 type PackagePopularity struct {
 	Name       string  `json:"name"`
 	Samples    int     `json:"samples"`
 	Count      int     `json:"count"`
 	Popularity float64 `json:"popularity"`
-	StartMonth int     `json:"startMonth"`
-	EndMonth   int     `json:"endMonth"`
+	*popularity.Interval
+	StartMonth int `json:"startMonth"`
+	EndMonth   int `json:"endMonth"`
 }
 
 func (p PackagePopularity) GetName() string        { return p.Name }
@@ -20,4 +23,36 @@ type PackagePopularityList struct {
 	Limit               int                 `json:"limit"`
 	Offset              int                 `json:"offset"`
 	Query               *string             `json:"query"`
+	Next                *string             `json:"next,omitempty"`
+	Smoothing           string              `json:"smoothing,omitempty"`
+}
+
+// GonePackage describes a package that dropped below the listing threshold.
+type GonePackage struct {
+	Name           string  `json:"name"`
+	LastMonth      int     `json:"lastMonth"`
+	PeakMonth      int     `json:"peakMonth"`
+	PeakPopularity float64 `json:"peakPopularity"`
+}
+
+type GonePackageList struct {
+	Total        int           `json:"total"`
+	Count        int           `json:"count"`
+	GonePackages []GonePackage `json:"gonePackages"`
+	Limit        int           `json:"limit"`
+	Offset       int           `json:"offset"`
+}
+
+type PackageSuggestions struct {
+	Query       string   `json:"query"`
+	Suggestions []string `json:"suggestions"`
+}
+
+// PackageForecast projects the monthly popularity of a package past the last
+// complete month from the trend between StartMonth and EndMonth.
+type PackageForecast struct {
+	Name       string                     `json:"name"`
+	StartMonth int                        `json:"startMonth"`
+	EndMonth   int                        `json:"endMonth"`
+	Forecasts  []popularity.ForecastPoint `json:"forecasts"`
 }
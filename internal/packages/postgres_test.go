@@ -0,0 +1,107 @@
+package packages
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"pkgstatsd/internal/database"
+	"pkgstatsd/internal/web"
+)
+
+// setupPostgresDB connects to the server in POSTGRES_TEST_URL and removes the
+// months the tests write to. It skips the test without it.
+func setupPostgresDB(t *testing.T) *SQLRepository {
+	t.Helper()
+
+	dsn := os.Getenv("POSTGRES_TEST_URL")
+	if dsn == "" {
+		t.Skip("POSTGRES_TEST_URL not set")
+	}
+
+	db, err := database.New(dsn)
+	if err != nil {
+		t.Fatalf("create database: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	if _, err := db.Exec(`DELETE FROM package WHERE month BETWEEN 202501 AND 202502`); err != nil {
+		t.Fatalf("clear test months: %v", err)
+	}
+
+	return NewSQLRepository(db, nil)
+}
+
+func TestPostgres_Queries(t *testing.T) {
+	repo := setupPostgresDB(t)
+	ctx := context.Background()
+
+	_, err := repo.db.Exec(`
+		INSERT INTO package_name (name) VALUES ('pacman'), ('linux'), ('Qt6-base'), ('python-requests')
+		ON CONFLICT (name) DO NOTHING;
+		INSERT INTO package (name_id, month, count)
+		SELECT package_name.id, v.month, v.count FROM (VALUES
+		('pacman', 202501, 1000),
+		('pacman', 202502, 900),
+		('linux', 202501, 800),
+		('linux', 202502, 850),
+		('Qt6-base', 202502, 200),
+		('python-requests', 202502, 300)
+		) AS v (name, month, count)
+		JOIN package_name ON package_name.name = v.name
+	`)
+	if err != nil {
+		t.Fatalf("insert test data: %v", err)
+	}
+
+	pkg, err := repo.FindByName(ctx, "pacman", 202501, 202502)
+	if err != nil {
+		t.Fatalf("FindByName error: %v", err)
+	}
+	if pkg.Count != 1900 || pkg.Samples != 1900 {
+		t.Errorf("expected count and samples 1900, got %d and %d", pkg.Count, pkg.Samples)
+	}
+
+	tests := []struct {
+		query string
+		match string
+		want  []string
+	}{
+		{"", "", []string{"pacman", "linux", "python-requests", "Qt6-base"}},
+		{"py", web.MatchPrefix, []string{"python-requests"}},
+		{"QT", web.MatchContains, []string{"Qt6-base"}},
+		{"*-*", web.MatchGlob, []string{"python-requests", "Qt6-base"}},
+		{"qt*", web.MatchGlob, nil},
+		{"pacmann", web.MatchFuzzy, []string{"pacman"}},
+	}
+
+	for _, tt := range tests {
+		list, err := repo.FindAll(ctx, tt.query, 202501, 202502, 100, 0, web.ListOptions{Match: tt.match})
+		if err != nil {
+			t.Fatalf("FindAll(%q, %q) error: %v", tt.query, tt.match, err)
+		}
+
+		var names []string
+		for _, p := range list.PackagePopularities {
+			names = append(names, p.Name)
+		}
+		if len(names) != len(tt.want) || list.Total != len(tt.want) {
+			t.Errorf("FindAll(%q, %q) = %v (total %d), want %v", tt.query, tt.match, names, list.Total, tt.want)
+			continue
+		}
+		for i := range names {
+			if names[i] != tt.want[i] {
+				t.Errorf("FindAll(%q, %q) = %v, want %v", tt.query, tt.match, names, tt.want)
+				break
+			}
+		}
+	}
+
+	newList, err := repo.FindNew(ctx, 202502, 100, 0)
+	if err != nil {
+		t.Fatalf("FindNew error: %v", err)
+	}
+	if newList.Total != 2 {
+		t.Errorf("expected 2 new packages, got %d", newList.Total)
+	}
+}
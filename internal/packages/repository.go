@@ -4,36 +4,61 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strings"
 
 	"pkgstatsd/internal/database"
 	"pkgstatsd/internal/popularity"
+	"pkgstatsd/internal/tracing"
+	"pkgstatsd/internal/web"
 )
 
 const (
-	minPopularity     = 16
-	nameLikeCondition = ` AND name LIKE ?`
+	minPopularity = 16
+	// packageNames joins the package counts with their names.
+	packageNames = `package JOIN package_name ON package_name.id = package.name_id`
+	// nameID looks up the id of the package name bound to ?.
+	nameID = `(SELECT id FROM package_name WHERE name = ?)`
+	// forecastHistory is the number of months a forecast trend is fitted to.
+	forecastHistory = 24
 )
 
 type Repository interface {
 	FindByName(ctx context.Context, name string, startMonth, endMonth int) (*PackagePopularity, error)
-	FindAll(ctx context.Context, query string, startMonth, endMonth, limit, offset int) (*PackagePopularityList, error)
-	FindSeriesByName(ctx context.Context, name string, startMonth, endMonth, limit, offset int) (*PackagePopularityList, error)
+	FindAll(ctx context.Context, query string, startMonth, endMonth, limit, offset int, opts web.ListOptions) (*PackagePopularityList, error)
+	FindSeriesByName(ctx context.Context, name string, startMonth, endMonth, limit, offset int, opts web.SeriesOptions) (*PackagePopularityList, error)
+	FindNew(ctx context.Context, month, limit, offset int) (*PackagePopularityList, error)
+	FindGone(ctx context.Context, month, limit, offset int) (*GonePackageList, error)
+	Suggest(ctx context.Context, prefix string, limit int) (*PackageSuggestions, error)
+	FindForecastByName(ctx context.Context, name string, months int) (*PackageForecast, error)
+	FindDiff(ctx context.Context, fromMonth, toMonth, limit, offset int) (*popularity.DiffList, error)
 }
 
-type SQLiteRepository struct {
+type SQLRepository struct {
 	db              *sql.DB
+	dialect         database.Dialect
 	monthlyMaxCache *database.MonthlySamplesCache
+	suggestions     *suggestIndex
+	results         *database.ResultCache
 }
 
-func NewSQLiteRepository(db *sql.DB) *SQLiteRepository {
-	return &SQLiteRepository{
+// NewSQLRepository creates the packages repository. results caches query
+// results and may be nil.
+func NewSQLRepository(db *sql.DB, results *database.ResultCache) *SQLRepository {
+	return &SQLRepository{
 		db:              db,
+		dialect:         database.DialectOf(db),
+		results:         results,
 		monthlyMaxCache: database.NewMonthlySamplesCache(db, `SELECT month, MAX(count) FROM package GROUP BY month`),
+		suggestions:     newSuggestIndex(db),
 	}
 }
 
-func (r *SQLiteRepository) WarmupCache(ctx context.Context) error {
-	return r.monthlyMaxCache.Warmup(ctx)
+func (r *SQLRepository) WarmupCache(ctx context.Context) error {
+	if err := r.monthlyMaxCache.Warmup(ctx); err != nil {
+		return err
+	}
+
+	return r.suggestions.Warmup(ctx)
 }
 
 // monthRange returns the SQL WHERE fragment and bound args for a month range.
@@ -46,22 +71,30 @@ func monthRange(startMonth, endMonth int) (clause string, args []any) {
 	return "month >= ? AND month <= ?", []any{startMonth, endMonth}
 }
 
-func (r *SQLiteRepository) FindByName(ctx context.Context, name string, startMonth, endMonth int) (*PackagePopularity, error) {
+func (r *SQLRepository) FindByName(ctx context.Context, name string, startMonth, endMonth int) (*PackagePopularity, error) {
+	method := "package.FindByName"
+	key := database.CacheKey(method, name, startMonth, endMonth)
+	return database.Cached(ctx, r.results, method, key, startMonth, endMonth, func(ctx context.Context) (*PackagePopularity, error) {
+		return r.findByName(ctx, name, startMonth, endMonth)
+	})
+}
+
+func (r *SQLRepository) findByName(ctx context.Context, name string, startMonth, endMonth int) (*PackagePopularity, error) {
 	var count int
 	var query string
 	var args []any
 
 	if startMonth == endMonth {
-		query = `SELECT COALESCE(SUM(count), 0) FROM package WHERE name = ? AND month = ?`
+		query = `SELECT COALESCE(SUM(count), 0) FROM package WHERE name_id = ` + nameID + ` AND month = ?`
 		args = []any{name, startMonth}
 	} else {
 		mClause, mArgs := monthRange(startMonth, endMonth)
-		query = `SELECT COALESCE(SUM(count), 0) FROM package WHERE name = ? AND ` + mClause
+		query = `SELECT COALESCE(SUM(count), 0) FROM package WHERE name_id = ` + nameID + ` AND ` + mClause
 		args = append([]any{name}, mArgs...)
 	}
 
 	//nolint:gosec // Query is safely constructed using fixed strings from monthRange and parameterized arguments
-	if err := r.db.QueryRowContext(ctx, query, args...).Scan(&count); err != nil {
+	if err := r.db.QueryRowContext(ctx, r.dialect.Rebind(query), args...).Scan(&count); err != nil {
 		return nil, fmt.Errorf("query package count: %w", err)
 	}
 
@@ -75,84 +108,102 @@ func (r *SQLiteRepository) FindByName(ctx context.Context, name string, startMon
 		Samples:    samples,
 		Count:      count,
 		Popularity: popularity.CalculatePopularity(count, samples),
+		Interval:   popularity.WilsonInterval(count, samples),
 		StartMonth: startMonth,
 		EndMonth:   endMonth,
 	}, nil
 }
 
-func (r *SQLiteRepository) FindAll(ctx context.Context, query string, startMonth, endMonth, limit, offset int) (*PackagePopularityList, error) {
+func (r *SQLRepository) FindAll(ctx context.Context, query string, startMonth, endMonth, limit, offset int, opts web.ListOptions) (*PackagePopularityList, error) {
+	method := "package.FindAll"
+	key := database.CacheKey(method, query, startMonth, endMonth, limit, offset, opts)
+	return database.Cached(ctx, r.results, method, key, startMonth, endMonth, func(ctx context.Context) (*PackagePopularityList, error) {
+		return r.findAll(ctx, query, startMonth, endMonth, limit, offset, opts)
+	})
+}
+
+func (r *SQLRepository) findAll(ctx context.Context, query string, startMonth, endMonth, limit, offset int, opts web.ListOptions) (*PackagePopularityList, error) {
 	samples, err := r.getMaxCount(ctx, startMonth, endMonth)
 	if err != nil {
 		return nil, fmt.Errorf("get samples: %w", err)
 	}
 
+	minCount := opts.MinCount
+	if minCount == 0 {
+		minCount = minPopularity
+	}
+
+	matchClause, matchArgs := nameMatchCondition(r.dialect, opts.Match, query)
+
 	var sqlQuery string
 	var countQuery string
 	var args []any
 	var countArgs []any
 
 	if startMonth == endMonth {
+		orderClause, orderArgs, err := r.orderBy(ctx, opts, startMonth, endMonth, "count")
+		if err != nil {
+			return nil, fmt.Errorf("get growth samples: %w", err)
+		}
+
 		sqlQuery = `
 			SELECT name, count
-			FROM package
+			FROM ` + packageNames + `
 			WHERE month = ? AND count >= ?`
-		args = []any{startMonth, minPopularity}
-		countArgs = []any{startMonth, minPopularity}
+		sqlQuery += matchClause
+		args = append([]any{startMonth, minCount}, matchArgs...)
+		countArgs = append([]any{startMonth, minCount}, matchArgs...)
 
-		if query != "" {
-			sqlQuery += nameLikeCondition
-			args = append(args, query+"%")
-			countArgs = append(countArgs, query+"%")
-		}
+		keysetClause, keysetArgs := popularity.KeysetCondition(opts, "name", "count")
+		sqlQuery += keysetClause + orderClause + ` LIMIT ? OFFSET ?`
+		args = append(append(append(args, keysetArgs...), orderArgs...), limit, offset)
 
-		sqlQuery += ` ORDER BY count DESC, name ASC LIMIT ? OFFSET ?`
-		args = append(args, limit, offset)
+		countQuery = `SELECT COUNT(*) FROM ` + packageNames + ` WHERE month = ? AND count >= ?` + matchClause
+	} else {
+		orderClause, orderArgs, err := r.orderBy(ctx, opts, startMonth, endMonth, "total_count")
+		if err != nil {
+			return nil, fmt.Errorf("get growth samples: %w", err)
+		}
 
-		countQuery = `SELECT COUNT(*) FROM package WHERE month = ? AND count >= ?`
-		if query != "" {
-			countQuery += nameLikeCondition
+		source, sourceArgs, err := popularity.RangeSource(ctx, r.db, "package", "name_id", startMonth, endMonth)
+		if err != nil {
+			return nil, fmt.Errorf("get rollups: %w", err)
 		}
-	} else {
+		source += ` JOIN package_name ON package_name.id = package.name_id`
+
 		mClause, mArgs := monthRange(startMonth, endMonth)
 		sqlQuery = `
 			SELECT name, SUM(count) as total_count
-			FROM package
-			WHERE ` + mClause
-		args = mArgs
-		countArgs = append([]any{}, mArgs...)
-
-		if query != "" {
-			sqlQuery += nameLikeCondition
-			args = append(args, query+"%")
-			countArgs = append(countArgs, query+"%")
-		}
+			FROM ` + source + `
+			WHERE ` + mClause + matchClause
+		args = append(append(sourceArgs, mArgs...), matchArgs...)
+		countArgs = append(append(append([]any{}, sourceArgs...), mArgs...), matchArgs...)
 
-		sqlQuery += ` GROUP BY name HAVING total_count >= ? ORDER BY total_count DESC, name ASC LIMIT ? OFFSET ?`
-		args = append(args, minPopularity, limit, offset)
+		keysetClause, keysetArgs := popularity.KeysetCondition(opts, "name", "SUM(count)")
+		sqlQuery += ` GROUP BY name_id, name HAVING SUM(count) >= ?` + keysetClause + orderClause + ` LIMIT ? OFFSET ?`
+		args = append(append(append(append(args, minCount), keysetArgs...), orderArgs...), limit, offset)
 
 		countQuery = `
 			SELECT COUNT(*) FROM (
-				SELECT name FROM package
-				WHERE ` + mClause
-		if query != "" {
-			countQuery += nameLikeCondition
-		}
-		countQuery += ` GROUP BY name HAVING SUM(count) >= ?)`
-		countArgs = append(countArgs, minPopularity)
+				SELECT name_id FROM ` + source + `
+				WHERE ` + mClause + matchClause + `
+				GROUP BY name_id HAVING SUM(count) >= ?) AS matches`
+		countArgs = append(countArgs, minCount)
 	}
 
 	var total int
-	if err := r.db.QueryRowContext(ctx, countQuery, countArgs...).Scan(&total); err != nil { //nolint:gosec // query is built from hardcoded strings and ? placeholders
+	if err := r.db.QueryRowContext(ctx, r.dialect.Rebind(countQuery), countArgs...).Scan(&total); err != nil { //nolint:gosec // query is built from hardcoded strings and ? placeholders
 		return nil, fmt.Errorf("count packages: %w", err)
 	}
 
-	rows, err := r.db.QueryContext(ctx, sqlQuery, args...) //nolint:gosec // query is built from hardcoded strings and ? placeholders
+	rows, err := r.db.QueryContext(ctx, r.dialect.Rebind(sqlQuery), args...) //nolint:gosec // query is built from hardcoded strings and ? placeholders
 	if err != nil {
 		return nil, fmt.Errorf("query packages: %w", err)
 	}
 	defer func() { _ = rows.Close() }()
 
 	var packages []PackagePopularity
+	var next *string
 	for rows.Next() {
 		var name string
 		var count int
@@ -165,9 +216,13 @@ func (r *SQLiteRepository) FindAll(ctx context.Context, query string, startMonth
 			Samples:    samples,
 			Count:      count,
 			Popularity: popularity.CalculatePopularity(count, samples),
+			Interval:   popularity.WilsonInterval(count, samples),
 			StartMonth: startMonth,
 			EndMonth:   endMonth,
 		})
+		if len(packages) == limit {
+			next = opts.NextCursor(count, name)
+		}
 	}
 
 	if err := rows.Err(); err != nil {
@@ -185,16 +240,26 @@ func (r *SQLiteRepository) FindAll(ctx context.Context, query string, startMonth
 		Limit:               limit,
 		Offset:              offset,
 		Query:               &query,
+		Next:                next,
 	}, nil
 }
 
-func (r *SQLiteRepository) FindSeriesByName(ctx context.Context, name string, startMonth, endMonth, limit, offset int) (*PackagePopularityList, error) {
+func (r *SQLRepository) FindSeriesByName(ctx context.Context, name string, startMonth, endMonth, limit, offset int, opts web.SeriesOptions) (*PackagePopularityList, error) {
+	method := "package.FindSeriesByName"
+	key := database.CacheKey(method, name, startMonth, endMonth, limit, offset, opts)
+	return database.Cached(ctx, r.results, method, key, startMonth, endMonth, func(ctx context.Context) (*PackagePopularityList, error) {
+		return r.findSeriesByName(ctx, name, startMonth, endMonth, limit, offset, opts)
+	})
+}
+
+func (r *SQLRepository) findSeriesByName(ctx context.Context, name string, startMonth, endMonth, limit, offset int, opts web.SeriesOptions) (*PackagePopularityList, error) {
 	mClause, mArgs := monthRange(startMonth, endMonth)
+	period := popularity.PeriodColumn(opts.Granularity)
 
-	countQuery := `SELECT COUNT(*) FROM package WHERE name = ? AND ` + mClause
+	countQuery := `SELECT COUNT(DISTINCT ` + period + `) FROM package WHERE name_id = ` + nameID + ` AND ` + mClause
 	var total int
 	//nolint:gosec // countQuery is safely constructed using fixed strings from monthRange and parameterized arguments
-	if err := r.db.QueryRowContext(ctx, countQuery, append([]any{name}, mArgs...)...).Scan(&total); err != nil {
+	if err := r.db.QueryRowContext(ctx, r.dialect.Rebind(countQuery), append([]any{name}, mArgs...)...).Scan(&total); err != nil {
 		return nil, fmt.Errorf("count series: %w", err)
 	}
 
@@ -202,41 +267,175 @@ func (r *SQLiteRepository) FindSeriesByName(ctx context.Context, name string, st
 	if err != nil {
 		return nil, fmt.Errorf("get monthly samples: %w", err)
 	}
+	samplesMap = popularity.PeriodSamples(samplesMap, opts.Granularity)
 
 	//nolint:gosec // sqlQuery is safely constructed using fixed strings from monthRange and parameterized arguments
-	sqlQuery := `SELECT month, count FROM package WHERE name = ? AND ` + mClause + ` ORDER BY month ASC LIMIT ? OFFSET ?`
+	sqlQuery := `SELECT ` + period + ` AS period, SUM(count) FROM package WHERE name_id = ` + nameID + ` AND ` + mClause + ` GROUP BY period ORDER BY period ASC LIMIT ? OFFSET ?`
 
+	queryLimit, queryOffset := popularity.SeriesWindow(limit, offset, opts)
 	//nolint:gosec // Safe execution of the securely constructed sqlQuery using parameterized arguments
-	rows, err := r.db.QueryContext(ctx, sqlQuery, append(append([]any{name}, mArgs...), limit, offset)...)
+	rows, err := r.db.QueryContext(ctx, r.dialect.Rebind(sqlQuery), append(append([]any{name}, mArgs...), queryLimit, queryOffset)...)
 	if err != nil {
 		return nil, fmt.Errorf("query series: %w", err)
 	}
 	defer func() { _ = rows.Close() }()
 
-	var packages []PackagePopularity
+	var points []popularity.SeriesPoint
 	for rows.Next() {
-		var month, count int
-		if err := rows.Scan(&month, &count); err != nil {
+		var p popularity.SeriesPoint
+		if err := rows.Scan(&p.Period, &p.Count); err != nil {
 			return nil, fmt.Errorf("scan series: %w", err)
 		}
 
-		samples := samplesMap[month]
+		p.Samples = samplesMap[p.Period]
+		points = append(points, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate series: %w", err)
+	}
+
+	points = popularity.SmoothSeries(points, offset, opts)
+	packages := make([]PackagePopularity, 0, len(points))
+	for _, p := range points {
+		first, last := popularity.PeriodRange(p.Period, opts.Granularity, startMonth, endMonth)
+		packages = append(packages, PackagePopularity{
+			Name:       name,
+			Samples:    p.Samples,
+			Count:      p.Count,
+			Popularity: p.Popularity,
+			Interval:   p.Interval,
+			StartMonth: first,
+			EndMonth:   last,
+		})
+	}
+
+	return &PackagePopularityList{
+		Total:               total,
+		Count:               len(packages),
+		PackagePopularities: packages,
+		Limit:               limit,
+		Offset:              offset,
+		Query:               nil,
+		Smoothing:           opts.Smoothing.String(),
+	}, nil
+}
+
+// FindForecastByName projects the popularity of a package for the given
+// number of months after the last complete month. The forecast is empty if
+// the package was reported in fewer than popularity.MinForecastHistory of
+// the preceding forecastHistory months.
+func (r *SQLRepository) FindForecastByName(ctx context.Context, name string, months int) (_ *PackageForecast, err error) {
+	ctx, span := tracing.Start(ctx, "package.FindForecastByName")
+	defer func() { tracing.End(span, err) }()
+
+	endMonth := web.GetLastCompleteMonth()
+	startMonth := web.OffsetMonth(endMonth, 1-forecastHistory)
+
+	series, err := r.FindSeriesByName(ctx, name, startMonth, endMonth, forecastHistory, 0, web.SeriesOptions{Granularity: web.GranularityMonth})
+	if err != nil {
+		return nil, err
+	}
+
+	history := make([]int, len(series.PackagePopularities))
+	values := make([]float64, len(series.PackagePopularities))
+	for i, p := range series.PackagePopularities {
+		history[i] = p.StartMonth
+		values[i] = p.Popularity
+	}
+
+	forecasts := popularity.Forecast(history, values, endMonth, months)
+	if forecasts == nil {
+		forecasts = []popularity.ForecastPoint{}
+	}
+
+	return &PackageForecast{
+		Name:       name,
+		StartMonth: startMonth,
+		EndMonth:   endMonth,
+		Forecasts:  forecasts,
+	}, nil
+}
+
+// FindDiff compares the popularity of packages listed in either month, using
+// the same minPopularity floor as FindAll.
+func (r *SQLRepository) FindDiff(ctx context.Context, fromMonth, toMonth, limit, offset int) (*popularity.DiffList, error) {
+	startMonth, endMonth := min(fromMonth, toMonth), max(fromMonth, toMonth)
+	method := "package.FindDiff"
+	key := database.CacheKey(method, fromMonth, toMonth, limit, offset)
+
+	return database.Cached(ctx, r.results, method, key, startMonth, endMonth, func(ctx context.Context) (*popularity.DiffList, error) {
+		samples, err := r.getMonthlyMaxCounts(ctx, startMonth, endMonth)
+		if err != nil {
+			return nil, fmt.Errorf("get monthly samples: %w", err)
+		}
+
+		return popularity.FindDiff(ctx, r.db, packageNames, "name", minPopularity, samples, fromMonth, toMonth, limit, offset)
+	})
+}
+
+// FindNew lists packages whose first month with at least minPopularity
+// reports is the given month.
+func (r *SQLRepository) FindNew(ctx context.Context, month, limit, offset int) (*PackagePopularityList, error) {
+	method := "package.FindNew"
+	key := database.CacheKey(method, month, limit, offset)
+	return database.Cached(ctx, r.results, method, key, 0, month, func(ctx context.Context) (*PackagePopularityList, error) {
+		return r.findNew(ctx, month, limit, offset)
+	})
+}
+
+func (r *SQLRepository) findNew(ctx context.Context, month, limit, offset int) (*PackagePopularityList, error) {
+	samplesMap, err := r.getMonthlyMaxCounts(ctx, month, month)
+	if err != nil {
+		return nil, fmt.Errorf("get samples: %w", err)
+	}
+	samples := samplesMap[month]
+
+	const newCondition = `
+		FROM package p
+		JOIN package_name n ON n.id = p.name_id
+		WHERE p.month = ? AND p.count >= ?
+		  AND NOT EXISTS (
+			  SELECT 1 FROM package p2
+			  WHERE p2.name_id = p.name_id AND p2.month < ? AND p2.count >= ?
+		  )`
+	args := []any{month, minPopularity, month, minPopularity}
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, r.dialect.Rebind(`SELECT COUNT(*)`+newCondition), args...).Scan(&total); err != nil {
+		return nil, fmt.Errorf("count new packages: %w", err)
+	}
+
+	rows, err := r.db.QueryContext(ctx,
+		r.dialect.Rebind(`SELECT n.name, p.count`+newCondition+` ORDER BY p.count DESC, n.name ASC LIMIT ? OFFSET ?`),
+		append(args, limit, offset)...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query new packages: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	packages := []PackagePopularity{}
+	for rows.Next() {
+		var name string
+		var count int
+		if err := rows.Scan(&name, &count); err != nil {
+			return nil, fmt.Errorf("scan new package: %w", err)
+		}
+
 		packages = append(packages, PackagePopularity{
 			Name:       name,
 			Samples:    samples,
 			Count:      count,
 			Popularity: popularity.CalculatePopularity(count, samples),
+			Interval:   popularity.WilsonInterval(count, samples),
 			StartMonth: month,
 			EndMonth:   month,
 		})
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("iterate series: %w", err)
-	}
-
-	if packages == nil {
-		packages = []PackagePopularity{}
+		return nil, fmt.Errorf("iterate new packages: %w", err)
 	}
 
 	return &PackagePopularityList{
@@ -249,7 +448,150 @@ func (r *SQLiteRepository) FindSeriesByName(ctx context.Context, name string, st
 	}, nil
 }
 
-func (r *SQLiteRepository) getMaxCount(ctx context.Context, startMonth, endMonth int) (int, error) {
+// FindGone lists packages whose last month with at least minPopularity
+// reports is the month before the given month, along with the highest
+// popularity they ever reached.
+func (r *SQLRepository) FindGone(ctx context.Context, month, limit, offset int) (*GonePackageList, error) {
+	// A package is gone as long as no month since has reports, up to the
+	// current one, so the result depends on all of them.
+	method := "package.FindGone"
+	key := database.CacheKey(method, month, limit, offset)
+	return database.Cached(ctx, r.results, method, key, 0, max(month, web.GetCurrentMonth()), func(ctx context.Context) (*GonePackageList, error) {
+		return r.findGone(ctx, month, limit, offset)
+	})
+}
+
+func (r *SQLRepository) findGone(ctx context.Context, month, limit, offset int) (*GonePackageList, error) {
+	lastMonth := web.OffsetMonth(month, -1)
+
+	samplesMap, err := r.getMonthlyMaxCounts(ctx, 0, lastMonth)
+	if err != nil {
+		return nil, fmt.Errorf("get monthly samples: %w", err)
+	}
+
+	const goneCondition = `
+		FROM package p
+		JOIN package_name n ON n.id = p.name_id
+		WHERE p.month = ? AND p.count >= ?
+		  AND NOT EXISTS (
+			  SELECT 1 FROM package p2
+			  WHERE p2.name_id = p.name_id AND p2.month >= ? AND p2.count >= ?
+		  )`
+	args := []any{lastMonth, minPopularity, month, minPopularity}
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, r.dialect.Rebind(`SELECT COUNT(*)`+goneCondition), args...).Scan(&total); err != nil {
+		return nil, fmt.Errorf("count gone packages: %w", err)
+	}
+
+	// Page through gone packages first, then join their full history to find the peak.
+	rows, err := r.db.QueryContext(ctx, r.dialect.Rebind(`
+		WITH gone AS (
+			SELECT p.name_id, n.name, p.count`+goneCondition+`
+			ORDER BY p.count DESC, n.name ASC
+			LIMIT ? OFFSET ?
+		)
+		SELECT g.name, h.month, h.count
+		FROM gone g
+		JOIN package h ON h.name_id = g.name_id AND h.month <= ?
+		ORDER BY g.count DESC, g.name ASC, h.month ASC`),
+		append(args, limit, offset, lastMonth)...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query gone packages: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	packages := []GonePackage{}
+	for rows.Next() {
+		var name string
+		var historyMonth, count int
+		if err := rows.Scan(&name, &historyMonth, &count); err != nil {
+			return nil, fmt.Errorf("scan gone package: %w", err)
+		}
+
+		if len(packages) == 0 || packages[len(packages)-1].Name != name {
+			packages = append(packages, GonePackage{Name: name, LastMonth: lastMonth})
+		}
+
+		pkg := &packages[len(packages)-1]
+		if p := popularity.CalculatePopularity(count, samplesMap[historyMonth]); p > pkg.PeakPopularity || pkg.PeakMonth == 0 {
+			pkg.PeakPopularity = p
+			pkg.PeakMonth = historyMonth
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate gone packages: %w", err)
+	}
+
+	return &GonePackageList{
+		Total:        total,
+		Count:        len(packages),
+		GonePackages: packages,
+		Limit:        limit,
+		Offset:       offset,
+	}, nil
+}
+
+// nameMatchCondition matches package names by prefix unless another mode is
+// requested. Fuzzy matches are narrowed down via the trigram index first
+// where the dialect has one.
+func nameMatchCondition(d database.Dialect, match, query string) (string, []any) {
+	if match == "" {
+		match = web.MatchPrefix
+	}
+
+	clause, args := popularity.MatchCondition(d, match, "name", query)
+	if match != web.MatchFuzzy || clause == "" || !d.HasTrigramIndex() {
+		return clause, args
+	}
+
+	trigrams := popularity.Trigrams(query)
+	if len(trigrams) == 0 {
+		return clause, args
+	}
+
+	terms := make([]string, len(trigrams))
+	for i, trigram := range trigrams {
+		terms[i] = `"` + trigram + `"`
+	}
+
+	return ` AND package_name.id IN (SELECT rowid FROM package_name_trigram WHERE package_name_trigram MATCH ?)` + clause,
+		append([]any{strings.Join(terms, " OR ")}, args...)
+}
+
+func (r *SQLRepository) orderBy(ctx context.Context, opts web.ListOptions, startMonth, endMonth int, countExpr string) (string, []any, error) {
+	var growthSamples map[int]int
+	if opts.Sort == web.SortGrowth {
+		baseMonth, targetMonth := popularity.GrowthMonths(startMonth, endMonth)
+
+		var err error
+		if growthSamples, err = r.getMonthlyMaxCounts(ctx, baseMonth, targetMonth); err != nil {
+			return "", nil, err
+		}
+	}
+
+	clause, args := popularity.OrderBy(opts, "package", "name_id", "name", countExpr, startMonth, endMonth, growthSamples)
+
+	return clause, args, nil
+}
+
+// Suggest returns the most popular package names starting with prefix,
+// served from an in-memory index of the last complete month.
+func (r *SQLRepository) Suggest(ctx context.Context, prefix string, limit int) (_ *PackageSuggestions, err error) {
+	ctx, span := tracing.Start(ctx, "package.Suggest")
+	defer func() { tracing.End(span, err) }()
+
+	names, err := r.suggestions.Find(ctx, prefix, limit)
+	if err != nil {
+		return nil, fmt.Errorf("find suggestions: %w", err)
+	}
+
+	return &PackageSuggestions{Query: prefix, Suggestions: names}, nil
+}
+
+func (r *SQLRepository) getMaxCount(ctx context.Context, startMonth, endMonth int) (int, error) {
 	monthlyCounts, err := r.getMonthlyMaxCounts(ctx, startMonth, endMonth)
 	if err != nil {
 		return 0, err
@@ -262,6 +604,6 @@ func (r *SQLiteRepository) getMaxCount(ctx context.Context, startMonth, endMonth
 	return total, nil
 }
 
-func (r *SQLiteRepository) getMonthlyMaxCounts(ctx context.Context, startMonth, endMonth int) (map[int]int, error) {
+func (r *SQLRepository) getMonthlyMaxCounts(ctx context.Context, startMonth, endMonth int) (map[int]int, error) {
 	return r.monthlyMaxCache.Get(ctx, startMonth, endMonth)
 }
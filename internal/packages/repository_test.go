@@ -2,20 +2,24 @@ package packages
 
 import (
 	"context"
+	"fmt"
+	"reflect"
+	"slices"
 	"testing"
 
 	"pkgstatsd/internal/database"
 	"pkgstatsd/internal/popularity"
+	"pkgstatsd/internal/web"
 )
 
-func setupTestDB(t *testing.T) *SQLiteRepository {
+func setupTestDB(t *testing.T) *SQLRepository {
 	t.Helper()
 	db, err := database.New(":memory:")
 	if err != nil {
 		t.Fatalf("create database: %v", err)
 	}
 	t.Cleanup(func() { _ = db.Close() })
-	return NewSQLiteRepository(db)
+	return NewSQLRepository(db, nil)
 }
 
 func TestFindByName_Empty(t *testing.T) {
@@ -44,9 +48,10 @@ func TestFindByName_WithData(t *testing.T) {
 	repo := setupTestDB(t)
 
 	_, err := repo.db.Exec(`
-		INSERT INTO package (name, month, count) VALUES
-		('pacman', 202501, 100),
-		('glibc', 202501, 500)
+		INSERT INTO package_name (id, name) VALUES (1, 'pacman'), (2, 'glibc');
+		INSERT INTO package (name_id, month, count) VALUES
+		(1, 202501, 100),
+		(2, 202501, 500)
 	`)
 	if err != nil {
 		t.Fatalf("insert test data: %v", err)
@@ -72,11 +77,12 @@ func TestFindByName_MultiMonth(t *testing.T) {
 	repo := setupTestDB(t)
 
 	_, err := repo.db.Exec(`
-		INSERT INTO package (name, month, count) VALUES
-		('pacman', 202501, 100),
-		('pacman', 202502, 150),
-		('glibc', 202501, 500),
-		('glibc', 202502, 600)
+		INSERT INTO package_name (id, name) VALUES (1, 'pacman'), (2, 'glibc');
+		INSERT INTO package (name_id, month, count) VALUES
+		(1, 202501, 100),
+		(1, 202502, 150),
+		(2, 202501, 500),
+		(2, 202502, 600)
 	`)
 	if err != nil {
 		t.Fatalf("insert test data: %v", err)
@@ -106,7 +112,7 @@ func TestFindByName_MultiMonth(t *testing.T) {
 func TestFindByName_NonExistentRetainsName(t *testing.T) {
 	repo := setupTestDB(t)
 
-	_, err := repo.db.Exec(`INSERT INTO package (name, month, count) VALUES ('glibc', 202501, 500)`)
+	_, err := repo.db.Exec(`INSERT INTO package_name (id, name) VALUES (1, 'glibc'); INSERT INTO package (name_id, month, count) VALUES (1, 202501, 500)`)
 	if err != nil {
 		t.Fatalf("insert test data: %v", err)
 	}
@@ -134,7 +140,7 @@ func TestFindByName_NonExistentRetainsName(t *testing.T) {
 func TestFindAll_Empty(t *testing.T) {
 	repo := setupTestDB(t)
 
-	list, err := repo.FindAll(context.Background(), "", 202501, 202501, 100, 0)
+	list, err := repo.FindAll(context.Background(), "", 202501, 202501, 100, 0, web.ListOptions{})
 	if err != nil {
 		t.Fatalf("FindAll error: %v", err)
 	}
@@ -155,16 +161,17 @@ func TestFindAll_WithData(t *testing.T) {
 	repo := setupTestDB(t)
 
 	_, err := repo.db.Exec(`
-		INSERT INTO package (name, month, count) VALUES
-		('pacman', 202501, 100),
-		('glibc', 202501, 500),
-		('unpopular', 202501, 5)
+		INSERT INTO package_name (id, name) VALUES (1, 'pacman'), (2, 'glibc'), (3, 'unpopular');
+		INSERT INTO package (name_id, month, count) VALUES
+		(1, 202501, 100),
+		(2, 202501, 500),
+		(3, 202501, 5)
 	`)
 	if err != nil {
 		t.Fatalf("insert test data: %v", err)
 	}
 
-	list, err := repo.FindAll(context.Background(), "", 202501, 202501, 100, 0)
+	list, err := repo.FindAll(context.Background(), "", 202501, 202501, 100, 0, web.ListOptions{})
 	if err != nil {
 		t.Fatalf("FindAll error: %v", err)
 	}
@@ -185,16 +192,17 @@ func TestFindAll_MinPopularityFilter(t *testing.T) {
 	repo := setupTestDB(t)
 
 	_, err := repo.db.Exec(`
-		INSERT INTO package (name, month, count) VALUES
-		('popular', 202501, 16),
-		('barely-popular', 202501, 15),
-		('unpopular', 202501, 1)
+		INSERT INTO package_name (id, name) VALUES (1, 'popular'), (2, 'barely-popular'), (3, 'unpopular');
+		INSERT INTO package (name_id, month, count) VALUES
+		(1, 202501, 16),
+		(2, 202501, 15),
+		(3, 202501, 1)
 	`)
 	if err != nil {
 		t.Fatalf("insert test data: %v", err)
 	}
 
-	list, err := repo.FindAll(context.Background(), "", 202501, 202501, 100, 0)
+	list, err := repo.FindAll(context.Background(), "", 202501, 202501, 100, 0, web.ListOptions{})
 	if err != nil {
 		t.Fatalf("FindAll error: %v", err)
 	}
@@ -215,16 +223,17 @@ func TestFindAll_WithQuery(t *testing.T) {
 	repo := setupTestDB(t)
 
 	_, err := repo.db.Exec(`
-		INSERT INTO package (name, month, count) VALUES
-		('pacman', 202501, 100),
-		('pacman-contrib', 202501, 50),
-		('glibc', 202501, 500)
+		INSERT INTO package_name (id, name) VALUES (1, 'pacman'), (2, 'pacman-contrib'), (3, 'glibc');
+		INSERT INTO package (name_id, month, count) VALUES
+		(1, 202501, 100),
+		(2, 202501, 50),
+		(3, 202501, 500)
 	`)
 	if err != nil {
 		t.Fatalf("insert test data: %v", err)
 	}
 
-	list, err := repo.FindAll(context.Background(), "pacman", 202501, 202501, 100, 0)
+	list, err := repo.FindAll(context.Background(), "pacman", 202501, 202501, 100, 0, web.ListOptions{})
 	if err != nil {
 		t.Fatalf("FindAll error: %v", err)
 	}
@@ -238,15 +247,16 @@ func TestFindAll_EmptyQueryReturnsAll(t *testing.T) {
 	repo := setupTestDB(t)
 
 	_, err := repo.db.Exec(`
-		INSERT INTO package (name, month, count) VALUES
-		('pacman', 202501, 100),
-		('glibc', 202501, 500)
+		INSERT INTO package_name (id, name) VALUES (1, 'pacman'), (2, 'glibc');
+		INSERT INTO package (name_id, month, count) VALUES
+		(1, 202501, 100),
+		(2, 202501, 500)
 	`)
 	if err != nil {
 		t.Fatalf("insert test data: %v", err)
 	}
 
-	list, err := repo.FindAll(context.Background(), "", 202501, 202501, 100, 0)
+	list, err := repo.FindAll(context.Background(), "", 202501, 202501, 100, 0, web.ListOptions{})
 	if err != nil {
 		t.Fatalf("FindAll error: %v", err)
 	}
@@ -260,16 +270,17 @@ func TestFindAll_QueryIsPrefixMatch(t *testing.T) {
 	repo := setupTestDB(t)
 
 	_, err := repo.db.Exec(`
-		INSERT INTO package (name, month, count) VALUES
-		('php', 202501, 100),
-		('php-fpm', 202501, 50),
-		('xphp', 202501, 50)
+		INSERT INTO package_name (id, name) VALUES (1, 'php'), (2, 'php-fpm'), (3, 'xphp');
+		INSERT INTO package (name_id, month, count) VALUES
+		(1, 202501, 100),
+		(2, 202501, 50),
+		(3, 202501, 50)
 	`)
 	if err != nil {
 		t.Fatalf("insert test data: %v", err)
 	}
 
-	list, err := repo.FindAll(context.Background(), "php", 202501, 202501, 100, 0)
+	list, err := repo.FindAll(context.Background(), "php", 202501, 202501, 100, 0, web.ListOptions{})
 	if err != nil {
 		t.Fatalf("FindAll error: %v", err)
 	}
@@ -284,19 +295,20 @@ func TestFindAll_Pagination(t *testing.T) {
 	repo := setupTestDB(t)
 
 	_, err := repo.db.Exec(`
-		INSERT INTO package (name, month, count) VALUES
-		('a', 202501, 100),
-		('b', 202501, 90),
-		('c', 202501, 80),
-		('d', 202501, 70),
-		('e', 202501, 60)
+		INSERT INTO package_name (id, name) VALUES (1, 'a'), (2, 'b'), (3, 'c'), (4, 'd'), (5, 'e');
+		INSERT INTO package (name_id, month, count) VALUES
+		(1, 202501, 100),
+		(2, 202501, 90),
+		(3, 202501, 80),
+		(4, 202501, 70),
+		(5, 202501, 60)
 	`)
 	if err != nil {
 		t.Fatalf("insert test data: %v", err)
 	}
 
 	// Limit 2, offset 0: first 2
-	list, err := repo.FindAll(context.Background(), "", 202501, 202501, 2, 0)
+	list, err := repo.FindAll(context.Background(), "", 202501, 202501, 2, 0, web.ListOptions{})
 	if err != nil {
 		t.Fatalf("FindAll error: %v", err)
 	}
@@ -311,7 +323,7 @@ func TestFindAll_Pagination(t *testing.T) {
 	}
 
 	// Limit 2, offset 2: next 2
-	list, err = repo.FindAll(context.Background(), "", 202501, 202501, 2, 2)
+	list, err = repo.FindAll(context.Background(), "", 202501, 202501, 2, 2, web.ListOptions{})
 	if err != nil {
 		t.Fatalf("FindAll error: %v", err)
 	}
@@ -326,7 +338,7 @@ func TestFindAll_Pagination(t *testing.T) {
 	}
 
 	// Limit 2, offset 4: last 1
-	list, err = repo.FindAll(context.Background(), "", 202501, 202501, 2, 4)
+	list, err = repo.FindAll(context.Background(), "", 202501, 202501, 2, 4, web.ListOptions{})
 	if err != nil {
 		t.Fatalf("FindAll error: %v", err)
 	}
@@ -335,7 +347,7 @@ func TestFindAll_Pagination(t *testing.T) {
 	}
 
 	// Offset beyond total: empty result
-	list, err = repo.FindAll(context.Background(), "", 202501, 202501, 2, 100)
+	list, err = repo.FindAll(context.Background(), "", 202501, 202501, 2, 100, web.ListOptions{})
 	if err != nil {
 		t.Fatalf("FindAll error: %v", err)
 	}
@@ -347,21 +359,224 @@ func TestFindAll_Pagination(t *testing.T) {
 	}
 }
 
+func TestFindAll_SortByName(t *testing.T) {
+	repo := setupTestDB(t)
+
+	_, err := repo.db.Exec(`
+		INSERT INTO package_name (id, name) VALUES (1, 'pacman'), (2, 'glibc'), (3, 'zsh');
+		INSERT INTO package (name_id, month, count) VALUES
+		(1, 202501, 100),
+		(2, 202501, 500),
+		(3, 202501, 50)
+	`)
+	if err != nil {
+		t.Fatalf("insert test data: %v", err)
+	}
+
+	list, err := repo.FindAll(context.Background(), "", 202501, 202501, 100, 0, web.ListOptions{Sort: web.SortName, Order: web.OrderDesc})
+	if err != nil {
+		t.Fatalf("FindAll error: %v", err)
+	}
+
+	for i, want := range []string{"zsh", "pacman", "glibc"} {
+		if list.PackagePopularities[i].Name != want {
+			t.Errorf("item %d: expected %s, got %s", i, want, list.PackagePopularities[i].Name)
+		}
+	}
+}
+
+func TestFindAll_SortByGrowth(t *testing.T) {
+	repo := setupTestDB(t)
+
+	_, err := repo.db.Exec(`
+		INSERT INTO package_name (id, name) VALUES (1, 'glibc'), (2, 'pacman'), (3, 'rising'), (4, 'fresh');
+		INSERT INTO package (name_id, month, count) VALUES
+		(1, 202412, 1000),
+		(1, 202501, 500),
+		(2, 202412, 900),
+		(2, 202501, 450),
+		(3, 202412, 100),
+		(3, 202501, 250),
+		(4, 202501, 50)
+	`)
+	if err != nil {
+		t.Fatalf("insert test data: %v", err)
+	}
+
+	// rising: 10% -> 50%, fresh: 0% -> 10%, pacman: 90% -> 90%, glibc: 100% -> 100%
+	list, err := repo.FindAll(context.Background(), "", 202501, 202501, 100, 0, web.ListOptions{Sort: web.SortGrowth})
+	if err != nil {
+		t.Fatalf("FindAll error: %v", err)
+	}
+
+	for i, want := range []string{"rising", "fresh", "glibc", "pacman"} {
+		if list.PackagePopularities[i].Name != want {
+			t.Errorf("item %d: expected %s, got %s", i, want, list.PackagePopularities[i].Name)
+		}
+	}
+
+	list, err = repo.FindAll(context.Background(), "", 202412, 202501, 100, 0, web.ListOptions{Sort: web.SortGrowth})
+	if err != nil {
+		t.Fatalf("FindAll error: %v", err)
+	}
+
+	if list.PackagePopularities[0].Name != "rising" {
+		t.Errorf("expected rising first over a range, got %s", list.PackagePopularities[0].Name)
+	}
+}
+
+func TestFindAll_MinCount(t *testing.T) {
+	repo := setupTestDB(t)
+
+	_, err := repo.db.Exec(`
+		INSERT INTO package_name (id, name) VALUES (1, 'pacman'), (2, 'rare'), (3, 'tiny');
+		INSERT INTO package (name_id, month, count) VALUES
+		(1, 202501, 100),
+		(2, 202501, 8),
+		(3, 202501, 2)
+	`)
+	if err != nil {
+		t.Fatalf("insert test data: %v", err)
+	}
+
+	list, err := repo.FindAll(context.Background(), "", 202501, 202501, 100, 0, web.ListOptions{MinCount: 5})
+	if err != nil {
+		t.Fatalf("FindAll error: %v", err)
+	}
+	if list.Total != 2 {
+		t.Errorf("expected total 2 with minCount 5, got %d", list.Total)
+	}
+
+	list, err = repo.FindAll(context.Background(), "", 202501, 202502, 100, 0, web.ListOptions{MinCount: 5})
+	if err != nil {
+		t.Fatalf("FindAll error: %v", err)
+	}
+	if list.Total != 2 {
+		t.Errorf("expected total 2 with minCount 5 over a range, got %d", list.Total)
+	}
+}
+
+func TestFindAll_Cursor(t *testing.T) {
+	repo := setupTestDB(t)
+
+	_, err := repo.db.Exec(`
+		INSERT INTO package_name (id, name) VALUES (1, 'a'), (2, 'b'), (3, 'c'), (4, 'd');
+		INSERT INTO package (name_id, month, count) VALUES
+		(1, 202501, 100),
+		(2, 202501, 90),
+		(3, 202501, 90),
+		(4, 202501, 70),
+		(1, 202502, 100),
+		(2, 202502, 90),
+		(3, 202502, 90),
+		(4, 202502, 70)
+	`)
+	if err != nil {
+		t.Fatalf("insert test data: %v", err)
+	}
+
+	for _, endMonth := range []int{202501, 202502} {
+		var opts web.ListOptions
+		var paged []string
+		for range 10 {
+			list, err := repo.FindAll(context.Background(), "", 202501, endMonth, 2, 0, opts)
+			if err != nil {
+				t.Fatalf("FindAll error: %v", err)
+			}
+			for _, pkg := range list.PackagePopularities {
+				paged = append(paged, pkg.Name)
+			}
+			if list.Next == nil {
+				break
+			}
+			if opts.Cursor, err = web.DecodeCursor(*list.Next); err != nil {
+				t.Fatalf("decode cursor: %v", err)
+			}
+		}
+
+		want := []string{"a", "b", "c", "d"}
+		if len(paged) != len(want) {
+			t.Fatalf("endMonth %d: expected %v, got %v", endMonth, want, paged)
+		}
+		for i := range want {
+			if paged[i] != want[i] {
+				t.Errorf("endMonth %d: item %d: expected %s, got %s", endMonth, i, want[i], paged[i])
+			}
+		}
+	}
+}
+
+func TestFindAll_Match(t *testing.T) {
+	repo := setupTestDB(t)
+
+	_, err := repo.db.Exec(`
+		INSERT INTO package_name (id, name) VALUES (1, 'firefox'), (2, 'firewalld'), (3, 'python-foo-git'), (4, 'python-bar-git'), (5, 'python-bar');
+		INSERT INTO package (name_id, month, count) VALUES
+		(1, 202501, 300),
+		(2, 202501, 200),
+		(3, 202501, 100),
+		(4, 202501, 90),
+		(5, 202501, 80),
+		(3, 202502, 100)
+	`)
+	if err != nil {
+		t.Fatalf("insert test data: %v", err)
+	}
+
+	tests := []struct {
+		match string
+		query string
+		want  []string
+	}{
+		{"", "fire", []string{"firefox", "firewalld"}},
+		{web.MatchPrefix, "bar", nil},
+		{web.MatchContains, "bar", []string{"python-bar-git", "python-bar"}},
+		{web.MatchGlob, "python-*-git", []string{"python-foo-git", "python-bar-git"}},
+		{web.MatchGlob, "python-ba?", []string{"python-bar"}},
+		{web.MatchFuzzy, "firefx", []string{"firefox"}},
+		{web.MatchFuzzy, "pythn-bar", []string{"python-bar-git", "python-bar"}},
+		{web.MatchFuzzy, "fi", []string{"firefox", "firewalld"}},
+	}
+
+	for _, tt := range tests {
+		for _, endMonth := range []int{202501, 202502} {
+			t.Run(fmt.Sprintf("%s_%s_%d", tt.match, tt.query, endMonth), func(t *testing.T) {
+				list, err := repo.FindAll(context.Background(), tt.query, 202501, endMonth, 100, 0, web.ListOptions{Match: tt.match})
+				if err != nil {
+					t.Fatalf("FindAll error: %v", err)
+				}
+
+				var got []string
+				for _, pkg := range list.PackagePopularities {
+					got = append(got, pkg.Name)
+				}
+				if !slices.Equal(got, tt.want) {
+					t.Errorf("expected %v, got %v", tt.want, got)
+				}
+				if list.Total != len(tt.want) {
+					t.Errorf("expected total %d, got %d", len(tt.want), list.Total)
+				}
+			})
+		}
+	}
+}
+
 func TestFindAll_MultiMonth(t *testing.T) {
 	repo := setupTestDB(t)
 
 	_, err := repo.db.Exec(`
-		INSERT INTO package (name, month, count) VALUES
-		('pacman', 202501, 100),
-		('pacman', 202502, 200),
-		('glibc', 202501, 500),
-		('glibc', 202502, 600)
+		INSERT INTO package_name (id, name) VALUES (1, 'pacman'), (2, 'glibc');
+		INSERT INTO package (name_id, month, count) VALUES
+		(1, 202501, 100),
+		(1, 202502, 200),
+		(2, 202501, 500),
+		(2, 202502, 600)
 	`)
 	if err != nil {
 		t.Fatalf("insert test data: %v", err)
 	}
 
-	list, err := repo.FindAll(context.Background(), "", 202501, 202502, 100, 0)
+	list, err := repo.FindAll(context.Background(), "", 202501, 202502, 100, 0, web.ListOptions{})
 	if err != nil {
 		t.Fatalf("FindAll error: %v", err)
 	}
@@ -386,7 +601,7 @@ func TestFindAll_ResponseMetadata(t *testing.T) {
 	repo := setupTestDB(t)
 
 	query := "pac"
-	list, err := repo.FindAll(context.Background(), query, 202501, 202501, 50, 10)
+	list, err := repo.FindAll(context.Background(), query, 202501, 202501, 50, 10, web.ListOptions{})
 	if err != nil {
 		t.Fatalf("FindAll error: %v", err)
 	}
@@ -409,16 +624,17 @@ func TestFindSeriesByName(t *testing.T) {
 	repo := setupTestDB(t)
 
 	_, err := repo.db.Exec(`
-		INSERT INTO package (name, month, count) VALUES
-		('pacman', 202501, 100),
-		('pacman', 202502, 150),
-		('pacman', 202503, 200)
+		INSERT INTO package_name (id, name) VALUES (1, 'pacman');
+		INSERT INTO package (name_id, month, count) VALUES
+		(1, 202501, 100),
+		(1, 202502, 150),
+		(1, 202503, 200)
 	`)
 	if err != nil {
 		t.Fatalf("insert test data: %v", err)
 	}
 
-	list, err := repo.FindSeriesByName(context.Background(), "pacman", 202501, 202503, 100, 0)
+	list, err := repo.FindSeriesByName(context.Background(), "pacman", 202501, 202503, 100, 0, web.SeriesOptions{})
 	if err != nil {
 		t.Fatalf("FindSeriesByName error: %v", err)
 	}
@@ -443,16 +659,17 @@ func TestFindSeriesByName_Pagination(t *testing.T) {
 	repo := setupTestDB(t)
 
 	_, err := repo.db.Exec(`
-		INSERT INTO package (name, month, count) VALUES
-		('pacman', 202501, 100),
-		('pacman', 202502, 150),
-		('pacman', 202503, 200)
+		INSERT INTO package_name (id, name) VALUES (1, 'pacman');
+		INSERT INTO package (name_id, month, count) VALUES
+		(1, 202501, 100),
+		(1, 202502, 150),
+		(1, 202503, 200)
 	`)
 	if err != nil {
 		t.Fatalf("insert test data: %v", err)
 	}
 
-	list, err := repo.FindSeriesByName(context.Background(), "pacman", 202501, 202503, 1, 0)
+	list, err := repo.FindSeriesByName(context.Background(), "pacman", 202501, 202503, 1, 0, web.SeriesOptions{})
 	if err != nil {
 		t.Fatalf("FindSeriesByName error: %v", err)
 	}
@@ -465,7 +682,7 @@ func TestFindSeriesByName_Pagination(t *testing.T) {
 	}
 
 	// Offset 1: second entry
-	list, err = repo.FindSeriesByName(context.Background(), "pacman", 202501, 202503, 1, 1)
+	list, err = repo.FindSeriesByName(context.Background(), "pacman", 202501, 202503, 1, 1, web.SeriesOptions{})
 	if err != nil {
 		t.Fatalf("FindSeriesByName error: %v", err)
 	}
@@ -477,7 +694,7 @@ func TestFindSeriesByName_Pagination(t *testing.T) {
 func TestFindSeriesByName_NoQuery(t *testing.T) {
 	repo := setupTestDB(t)
 
-	list, err := repo.FindSeriesByName(context.Background(), "pacman", 202501, 202501, 100, 0)
+	list, err := repo.FindSeriesByName(context.Background(), "pacman", 202501, 202501, 100, 0, web.SeriesOptions{})
 	if err != nil {
 		t.Fatalf("FindSeriesByName error: %v", err)
 	}
@@ -492,17 +709,18 @@ func TestFindSeriesByName_PerMonthSamples(t *testing.T) {
 	repo := setupTestDB(t)
 
 	_, err := repo.db.Exec(`
-		INSERT INTO package (name, month, count) VALUES
-		('pacman', 202501, 100),
-		('pacman', 202502, 150),
-		('glibc', 202501, 500),
-		('glibc', 202502, 600)
+		INSERT INTO package_name (id, name) VALUES (1, 'pacman'), (2, 'glibc');
+		INSERT INTO package (name_id, month, count) VALUES
+		(1, 202501, 100),
+		(1, 202502, 150),
+		(2, 202501, 500),
+		(2, 202502, 600)
 	`)
 	if err != nil {
 		t.Fatalf("insert test data: %v", err)
 	}
 
-	list, err := repo.FindSeriesByName(context.Background(), "pacman", 202501, 202502, 100, 0)
+	list, err := repo.FindSeriesByName(context.Background(), "pacman", 202501, 202502, 100, 0, web.SeriesOptions{})
 	if err != nil {
 		t.Fatalf("FindSeriesByName error: %v", err)
 	}
@@ -516,6 +734,255 @@ func TestFindSeriesByName_PerMonthSamples(t *testing.T) {
 	}
 }
 
+func TestFindSeriesByName_Granularity(t *testing.T) {
+	repo := setupTestDB(t)
+
+	_, err := repo.db.Exec(`
+		INSERT INTO package_name (id, name) VALUES (1, 'pacman'), (2, 'glibc');
+		INSERT INTO package (name_id, month, count) VALUES
+		(1, 202501, 100),
+		(1, 202502, 150),
+		(1, 202504, 200),
+		(2, 202501, 500),
+		(2, 202502, 500),
+		(2, 202503, 400),
+		(2, 202504, 400)
+	`)
+	if err != nil {
+		t.Fatalf("insert test data: %v", err)
+	}
+
+	list, err := repo.FindSeriesByName(context.Background(), "pacman", 202501, 202504, 100, 0, web.SeriesOptions{Granularity: web.GranularityQuarter})
+	if err != nil {
+		t.Fatalf("FindSeriesByName error: %v", err)
+	}
+
+	if list.Total != 2 {
+		t.Fatalf("expected total 2, got %d", list.Total)
+	}
+
+	// Samples add up the monthly maximum of every month in the quarter
+	q1 := list.PackagePopularities[0]
+	if q1.Count != 250 || q1.Samples != 1400 {
+		t.Errorf("expected 250/1400 in Q1, got %d/%d", q1.Count, q1.Samples)
+	}
+	if q1.StartMonth != 202501 || q1.EndMonth != 202503 {
+		t.Errorf("expected 202501-202503, got %d-%d", q1.StartMonth, q1.EndMonth)
+	}
+
+	q2 := list.PackagePopularities[1]
+	if q2.StartMonth != 202504 || q2.EndMonth != 202504 || q2.Popularity != 50 {
+		t.Errorf("expected 50%% in 202504-202504, got %v%% in %d-%d", q2.Popularity, q2.StartMonth, q2.EndMonth)
+	}
+}
+
+func TestFindSeriesByName_Smoothing(t *testing.T) {
+	repo := setupTestDB(t)
+
+	_, err := repo.db.Exec(`
+		INSERT INTO package_name (id, name) VALUES (1, 'pacman'), (2, 'glibc');
+		INSERT INTO package (name_id, month, count) VALUES
+		(1, 202501, 100),
+		(1, 202502, 300),
+		(2, 202501, 1000),
+		(2, 202502, 1000)
+	`)
+	if err != nil {
+		t.Fatalf("insert test data: %v", err)
+	}
+
+	opts := web.SeriesOptions{Smoothing: web.Smoothing{Method: web.SmoothExponential, Window: 3}}
+	list, err := repo.FindSeriesByName(context.Background(), "pacman", 202501, 202502, 100, 0, opts)
+	if err != nil {
+		t.Fatalf("FindSeriesByName error: %v", err)
+	}
+
+	if list.Smoothing != "ema3" {
+		t.Errorf("expected smoothing ema3, got %q", list.Smoothing)
+	}
+	// alpha = 0.5: 10% then 0.5*30% + 0.5*10%
+	if list.PackagePopularities[0].Popularity != 10 || list.PackagePopularities[1].Popularity != 20 {
+		t.Errorf("expected 10%% and 20%%, got %v%% and %v%%", list.PackagePopularities[0].Popularity, list.PackagePopularities[1].Popularity)
+	}
+}
+
+func TestFindByName_Interval(t *testing.T) {
+	repo := setupTestDB(t)
+
+	_, err := repo.db.Exec(`
+		INSERT INTO package_name (id, name) VALUES (1, 'pacman'), (2, 'glibc');
+		INSERT INTO package (name_id, month, count) VALUES
+		(1, 202501, 100),
+		(2, 202501, 50)
+	`)
+	if err != nil {
+		t.Fatalf("insert test data: %v", err)
+	}
+
+	pkg, err := repo.FindByName(context.Background(), "glibc", 202501, 202501)
+	if err != nil {
+		t.Fatalf("FindByName error: %v", err)
+	}
+
+	if low, high, ok := pkg.Bounds(); !ok || low != 40.38 || high != 59.62 {
+		t.Errorf("expected interval [40.38, 59.62], got [%v, %v] (ok=%v)", low, high, ok)
+	}
+
+	series, err := repo.FindSeriesByName(context.Background(), "glibc", 202501, 202501, 100, 0, web.SeriesOptions{})
+	if err != nil {
+		t.Fatalf("FindSeriesByName error: %v", err)
+	}
+
+	if _, _, ok := series.PackagePopularities[0].Bounds(); !ok {
+		t.Error("expected interval on series point")
+	}
+
+	smoothed, err := repo.FindSeriesByName(context.Background(), "glibc", 202501, 202501, 100, 0, web.SeriesOptions{
+		Smoothing: web.Smoothing{Method: web.SmoothMovingAverage, Window: 2},
+	})
+	if err != nil {
+		t.Fatalf("FindSeriesByName error: %v", err)
+	}
+
+	if _, _, ok := smoothed.PackagePopularities[0].Bounds(); ok {
+		t.Error("expected no interval on smoothed series point")
+	}
+}
+
+func TestFindNew(t *testing.T) {
+	repo := setupTestDB(t)
+
+	_, err := repo.db.Exec(`
+		INSERT INTO package_name (id, name) VALUES (1, 'glibc'), (2, 'pacman'), (3, 'rising'), (4, 'fresh'), (5, 'tiny');
+		INSERT INTO package (name_id, month, count) VALUES
+		(1, 202501, 500),
+		(1, 202502, 600),
+		(2, 202501, 100),
+		(2, 202502, 150),
+		(3, 202501, 5),
+		(3, 202502, 40),
+		(4, 202502, 20),
+		(5, 202502, 3)
+	`)
+	if err != nil {
+		t.Fatalf("insert test data: %v", err)
+	}
+
+	list, err := repo.FindNew(context.Background(), 202502, 100, 0)
+	if err != nil {
+		t.Fatalf("FindNew error: %v", err)
+	}
+
+	// "rising" existed before but only crossed the threshold this month;
+	// "tiny" never reached it.
+	if list.Total != 2 {
+		t.Fatalf("expected total 2, got %d", list.Total)
+	}
+	if list.PackagePopularities[0].Name != "rising" || list.PackagePopularities[1].Name != "fresh" {
+		t.Errorf("expected [rising fresh], got %+v", list.PackagePopularities)
+	}
+	if list.PackagePopularities[1].Samples != 600 {
+		t.Errorf("expected samples 600, got %d", list.PackagePopularities[1].Samples)
+	}
+	for _, p := range list.PackagePopularities {
+		if want := popularity.WilsonInterval(p.Count, p.Samples); !reflect.DeepEqual(p.Interval, want) {
+			t.Errorf("expected interval %+v for %s, got %+v", want, p.Name, p.Interval)
+		}
+	}
+	if list.Query != nil {
+		t.Errorf("expected nil query, got %v", list.Query)
+	}
+}
+
+func TestFindNew_Empty(t *testing.T) {
+	repo := setupTestDB(t)
+
+	list, err := repo.FindNew(context.Background(), 202502, 100, 0)
+	if err != nil {
+		t.Fatalf("FindNew error: %v", err)
+	}
+
+	if list.PackagePopularities == nil {
+		t.Error("expected empty slice, got nil")
+	}
+}
+
+func TestFindGone(t *testing.T) {
+	repo := setupTestDB(t)
+
+	_, err := repo.db.Exec(`
+		INSERT INTO package_name (id, name) VALUES (1, 'glibc'), (2, 'oldpkg'), (3, 'fading'), (4, 'earlier'), (5, 'pacman');
+		INSERT INTO package (name_id, month, count) VALUES
+		(1, 202501, 1000),
+		(1, 202502, 500),
+		(1, 202503, 400),
+		(2, 202501, 300),
+		(2, 202502, 100),
+		(3, 202502, 50),
+		(3, 202503, 10),
+		(4, 202501, 50),
+		(5, 202502, 100),
+		(5, 202503, 100)
+	`)
+	if err != nil {
+		t.Fatalf("insert test data: %v", err)
+	}
+
+	list, err := repo.FindGone(context.Background(), 202503, 100, 0)
+	if err != nil {
+		t.Fatalf("FindGone error: %v", err)
+	}
+
+	// "earlier" dropped out a month before, "pacman" is still present.
+	if list.Total != 2 {
+		t.Fatalf("expected total 2, got %d", list.Total)
+	}
+
+	oldpkg := list.GonePackages[0]
+	if oldpkg.Name != "oldpkg" {
+		t.Fatalf("expected oldpkg first, got %s", oldpkg.Name)
+	}
+	if oldpkg.LastMonth != 202502 {
+		t.Errorf("expected lastMonth 202502, got %d", oldpkg.LastMonth)
+	}
+	// 300/1000 = 30% in 202501 beats 100/500 = 20% in 202502
+	if oldpkg.PeakMonth != 202501 || oldpkg.PeakPopularity != 30 {
+		t.Errorf("expected peak 30%% in 202501, got %v%% in %d", oldpkg.PeakPopularity, oldpkg.PeakMonth)
+	}
+
+	if list.GonePackages[1].Name != "fading" {
+		t.Errorf("expected fading second, got %s", list.GonePackages[1].Name)
+	}
+}
+
+func TestFindGone_Pagination(t *testing.T) {
+	repo := setupTestDB(t)
+
+	_, err := repo.db.Exec(`
+		INSERT INTO package_name (id, name) VALUES (1, 'a'), (2, 'b'), (3, 'c');
+		INSERT INTO package (name_id, month, count) VALUES
+		(1, 202501, 300),
+		(1, 202412, 200),
+		(2, 202501, 200),
+		(3, 202501, 100)
+	`)
+	if err != nil {
+		t.Fatalf("insert test data: %v", err)
+	}
+
+	list, err := repo.FindGone(context.Background(), 202502, 1, 1)
+	if err != nil {
+		t.Fatalf("FindGone error: %v", err)
+	}
+
+	if list.Total != 3 {
+		t.Errorf("expected total 3, got %d", list.Total)
+	}
+	if list.Count != 1 || list.GonePackages[0].Name != "b" {
+		t.Errorf("expected only b, got %+v", list.GonePackages)
+	}
+}
+
 func TestCalculatePopularity(t *testing.T) {
 	tests := []struct {
 		count    int
@@ -540,3 +1007,190 @@ func TestCalculatePopularity(t *testing.T) {
 		}
 	}
 }
+
+func TestFindForecastByName(t *testing.T) {
+	repo := setupTestDB(t)
+
+	if _, err := repo.db.Exec(`INSERT INTO package_name (id, name) VALUES (1, 'pacman'), (2, 'glibc')`); err != nil {
+		t.Fatalf("insert test data: %v", err)
+	}
+
+	endMonth := web.GetLastCompleteMonth()
+	for i := range 6 {
+		month := web.OffsetMonth(endMonth, i-5)
+		if _, err := repo.db.Exec(`INSERT INTO package (name_id, month, count) VALUES (1, ?, ?), (2, ?, 1000)`, month, 100+10*i, month); err != nil {
+			t.Fatalf("insert test data: %v", err)
+		}
+	}
+
+	forecast, err := repo.FindForecastByName(context.Background(), "pacman", 3)
+	if err != nil {
+		t.Fatalf("FindForecastByName error: %v", err)
+	}
+
+	if forecast.EndMonth != endMonth || forecast.StartMonth != web.OffsetMonth(endMonth, 1-forecastHistory) {
+		t.Errorf("unexpected history range %d-%d", forecast.StartMonth, forecast.EndMonth)
+	}
+	if len(forecast.Forecasts) != 3 {
+		t.Fatalf("expected 3 forecast points, got %d", len(forecast.Forecasts))
+	}
+	if forecast.Forecasts[0].Month != web.OffsetMonth(endMonth, 1) {
+		t.Errorf("expected first forecast month %d, got %d", web.OffsetMonth(endMonth, 1), forecast.Forecasts[0].Month)
+	}
+	if forecast.Forecasts[0].Popularity <= 15 {
+		t.Errorf("expected rising trend above 15%%, got %v", forecast.Forecasts[0].Popularity)
+	}
+
+	unknown, err := repo.FindForecastByName(context.Background(), "unknown", 3)
+	if err != nil {
+		t.Fatalf("FindForecastByName error: %v", err)
+	}
+	if unknown.Forecasts == nil || len(unknown.Forecasts) != 0 {
+		t.Errorf("expected empty forecast, got %v", unknown.Forecasts)
+	}
+}
+
+func TestFindDiff_MinPopularity(t *testing.T) {
+	repo := setupTestDB(t)
+
+	_, err := repo.db.Exec(`
+		INSERT INTO package_name (id, name) VALUES (1, 'pacman'), (2, 'rare'), (3, 'gone');
+		INSERT INTO package (name_id, month, count) VALUES
+		(1, 202501, 100), (1, 202502, 100),
+		(2, 202501, 10), (2, 202502, 20),
+		(3, 202501, 50), (3, 202502, 5)
+	`)
+	if err != nil {
+		t.Fatalf("insert test data: %v", err)
+	}
+
+	list, err := repo.FindDiff(context.Background(), 202501, 202502, 10, 0)
+	if err != nil {
+		t.Fatalf("FindDiff error: %v", err)
+	}
+
+	names := make([]string, len(list.Entries))
+	for i, e := range list.Entries {
+		names[i] = e.Identifier
+	}
+	if !slices.Equal(names, []string{"pacman", "rare", "gone"}) {
+		t.Fatalf("expected pacman, rare, gone, got %v", names)
+	}
+
+	rare := list.Entries[1]
+	if rare.FromRank != nil || rare.ToRank == nil || *rare.ToRank != 2 {
+		t.Errorf("expected rare to enter at rank 2, got %v -> %v", rare.FromRank, rare.ToRank)
+	}
+	if rare.FromPopularity != 0 || rare.ToPopularity != 20 {
+		t.Errorf("expected unlisted month to count as 0, got %v -> %v", rare.FromPopularity, rare.ToPopularity)
+	}
+
+	gone := list.Entries[2]
+	if gone.ToRank != nil || gone.FromRank == nil || *gone.FromRank != 2 {
+		t.Errorf("expected gone to leave from rank 2, got %v -> %v", gone.FromRank, gone.ToRank)
+	}
+}
+
+func TestFindByName_ResultCache(t *testing.T) {
+	db, err := database.New(":memory:")
+	if err != nil {
+		t.Fatalf("create database: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	results := database.NewResultCache(10)
+	repo := NewSQLRepository(db, results)
+
+	_, _ = db.Exec(`INSERT INTO package_name (id, name) VALUES (1, 'pacman'); INSERT INTO package (name_id, month, count) VALUES (1, 202501, 100)`)
+
+	if _, err := repo.FindByName(context.Background(), "pacman", 202501, 202501); err != nil {
+		t.Fatalf("FindByName error: %v", err)
+	}
+
+	_, _ = db.Exec(`UPDATE package SET count = 200 WHERE name_id = 1`)
+
+	pkg, err := repo.FindByName(context.Background(), "pacman", 202501, 202501)
+	if err != nil {
+		t.Fatalf("FindByName error: %v", err)
+	}
+	if pkg.Count != 100 {
+		t.Errorf("expected cached count 100, got %d", pkg.Count)
+	}
+
+	results.InvalidateMonth(202501)
+
+	pkg, err = repo.FindByName(context.Background(), "pacman", 202501, 202501)
+	if err != nil {
+		t.Fatalf("FindByName error: %v", err)
+	}
+	if pkg.Count != 200 {
+		t.Errorf("expected count 200 after invalidation, got %d", pkg.Count)
+	}
+}
+
+func TestFindGone_ResultCache(t *testing.T) {
+	db, err := database.New(":memory:")
+	if err != nil {
+		t.Fatalf("create database: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	results := database.NewResultCache(10)
+	repo := NewSQLRepository(db, results)
+
+	currentMonth := web.GetCurrentMonth()
+	lastMonth := web.OffsetMonth(currentMonth, -1)
+	_, _ = db.Exec(`INSERT INTO package_name (id, name) VALUES (1, 'pacman'); INSERT INTO package (name_id, month, count) VALUES (1, ?, 100)`,
+		web.OffsetMonth(currentMonth, -2))
+
+	list, err := repo.FindGone(context.Background(), lastMonth, 100, 0)
+	if err != nil {
+		t.Fatalf("FindGone error: %v", err)
+	}
+	if list.Total != 1 {
+		t.Fatalf("expected pacman to be gone, got total %d", list.Total)
+	}
+
+	// Reports in the current month bring it back.
+	_, _ = db.Exec(`INSERT INTO package (name_id, month, count) VALUES (1, ?, 100)`, currentMonth)
+	results.InvalidateMonth(currentMonth)
+
+	list, err = repo.FindGone(context.Background(), lastMonth, 100, 0)
+	if err != nil {
+		t.Fatalf("FindGone error: %v", err)
+	}
+	if list.Total != 0 {
+		t.Errorf("expected no gone packages after invalidating the current month, got %d", list.Total)
+	}
+}
+
+func TestFindAll_Rollup(t *testing.T) {
+	repo := setupTestDB(t)
+
+	_, _ = repo.db.Exec(`INSERT INTO package_name (id, name) VALUES (1, 'pacman'), (2, 'linux'); INSERT INTO package (name_id, month, count) VALUES
+		(1, 202401, 10), (1, 202412, 10), (1, 202501, 10),
+		(2, 202406, 25)`)
+
+	before, err := repo.FindAll(context.Background(), "", 0, 202501, 10, 0, web.ListOptions{})
+	if err != nil {
+		t.Fatalf("FindAll error: %v", err)
+	}
+
+	_, _ = repo.db.Exec(`INSERT INTO package_year (name_id, year, count) VALUES (1, 2024, 20), (2, 2024, 25)`)
+	_, _ = repo.db.Exec(`INSERT INTO rollup (name, year) VALUES ('package', 2024)`)
+
+	after, err := repo.FindAll(context.Background(), "", 0, 202501, 10, 0, web.ListOptions{})
+	if err != nil {
+		t.Fatalf("FindAll error: %v", err)
+	}
+
+	if after.Total != before.Total || after.Total != 2 {
+		t.Fatalf("expected total 2 before and after rollup, got %d and %d", before.Total, after.Total)
+	}
+	if !reflect.DeepEqual(before.PackagePopularities, after.PackagePopularities) {
+		t.Errorf("expected %+v after rollup, got %+v", before.PackagePopularities, after.PackagePopularities)
+	}
+	if before.PackagePopularities[0].Interval == nil {
+		t.Error("expected list entries to carry an interval")
+	}
+}
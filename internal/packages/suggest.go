@@ -0,0 +1,129 @@
+package packages
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"pkgstatsd/internal/database"
+	"pkgstatsd/internal/web"
+)
+
+type suggestEntry struct {
+	key   string // lowercase name for case-insensitive prefix search
+	name  string
+	count int
+}
+
+// suggestIndex keeps the packages listed in the last complete month sorted
+// by name, so prefix lookups are a binary search instead of a query. Like
+// MonthlySamplesCache it is reloaded at the start of the next month and
+// when the data version changed.
+type suggestIndex struct {
+	db *sql.DB
+
+	mu      sync.RWMutex
+	entries []suggestEntry
+	expiry  time.Time
+	version int64
+}
+
+func newSuggestIndex(db *sql.DB) *suggestIndex {
+	return &suggestIndex{db: db}
+}
+
+// Find returns up to limit package names starting with prefix, most popular first.
+func (i *suggestIndex) Find(ctx context.Context, prefix string, limit int) ([]string, error) {
+	entries, err := i.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix = strings.ToLower(prefix)
+	start := sort.Search(len(entries), func(k int) bool { return entries[k].key >= prefix })
+
+	var matches []suggestEntry
+	for _, entry := range entries[start:] {
+		if !strings.HasPrefix(entry.key, prefix) {
+			break
+		}
+		matches = append(matches, entry)
+	}
+
+	slices.SortFunc(matches, func(a, b suggestEntry) int {
+		return cmp.Or(cmp.Compare(b.count, a.count), cmp.Compare(a.name, b.name))
+	})
+
+	names := make([]string, 0, min(limit, len(matches)))
+	for _, entry := range matches[:min(limit, len(matches))] {
+		names = append(names, entry.name)
+	}
+
+	return names, nil
+}
+
+func (i *suggestIndex) Warmup(ctx context.Context) error {
+	_, err := i.load(ctx)
+	return err
+}
+
+func (i *suggestIndex) load(ctx context.Context) ([]suggestEntry, error) {
+	i.mu.RLock()
+	if i.fresh() {
+		entries := i.entries
+		i.mu.RUnlock()
+		return entries, nil
+	}
+	i.mu.RUnlock()
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	// Double-check after acquiring write lock
+	if i.fresh() {
+		return i.entries, nil
+	}
+
+	version := database.CurrentDataVersion().Version
+	rows, err := i.db.QueryContext(ctx,
+		database.DialectOf(i.db).Rebind(`SELECT name, count FROM `+packageNames+` WHERE month = ? AND count >= ?`),
+		web.GetLastCompleteMonth(), minPopularity,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query suggestions: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	entries := []suggestEntry{}
+	for rows.Next() {
+		var entry suggestEntry
+		if err := rows.Scan(&entry.name, &entry.count); err != nil {
+			return nil, fmt.Errorf("scan suggestion: %w", err)
+		}
+		entry.key = strings.ToLower(entry.name)
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(entries, func(a, b suggestEntry) int { return cmp.Compare(a.key, b.key) })
+
+	i.entries = entries
+	i.expiry = database.StartOfNextMonth()
+	i.version = version
+
+	return entries, nil
+}
+
+// fresh reports whether the loaded entries are current. i.mu must be held.
+func (i *suggestIndex) fresh() bool {
+	return i.entries != nil && time.Now().Before(i.expiry) && i.version == database.CurrentDataVersion().Version
+}
@@ -0,0 +1,81 @@
+package packages
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+
+	"pkgstatsd/internal/web"
+)
+
+func TestSuggest(t *testing.T) {
+	repo := setupTestDB(t)
+	month := web.GetLastCompleteMonth()
+
+	_, err := repo.db.Exec(fmt.Sprintf(`
+		INSERT INTO package_name (id, name) VALUES (1, 'python'), (2, 'python-requests'), (3, 'Python-Tiny'), (4, 'python-rare'), (5, 'python-old'), (6, 'pacman');
+		INSERT INTO package (name_id, month, count) VALUES
+		(1, %[1]d, 900),
+		(2, %[1]d, 500),
+		(3, %[1]d, 500),
+		(4, %[1]d, 3),
+		(5, %[2]d, 1000),
+		(6, %[1]d, 1000)
+	`, month, web.OffsetMonth(month, -1)))
+	if err != nil {
+		t.Fatalf("insert test data: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		prefix   string
+		limit    int
+		expected []string
+	}{
+		{"ranked by popularity then name", "python", 10, []string{"python", "Python-Tiny", "python-requests"}},
+		{"case insensitive", "PYTHON-", 10, []string{"Python-Tiny", "python-requests"}},
+		{"limit", "p", 2, []string{"pacman", "python"}},
+		{"no match", "zsh", 10, []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := repo.Suggest(context.Background(), tt.prefix, tt.limit)
+			if err != nil {
+				t.Fatalf("Suggest error: %v", err)
+			}
+			if result.Query != tt.prefix {
+				t.Errorf("expected query %q, got %q", tt.prefix, result.Query)
+			}
+			if !slices.Equal(result.Suggestions, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, result.Suggestions)
+			}
+		})
+	}
+}
+
+func TestSuggest_CachedUntilNextMonth(t *testing.T) {
+	repo := setupTestDB(t)
+	month := web.GetLastCompleteMonth()
+
+	if err := repo.WarmupCache(context.Background()); err != nil {
+		t.Fatalf("WarmupCache error: %v", err)
+	}
+
+	_, err := repo.db.Exec(`INSERT INTO package_name (id, name) VALUES (1, 'pacman')`)
+	if err == nil {
+		_, err = repo.db.Exec(`INSERT INTO package (name_id, month, count) VALUES (1, ?, 100)`, month)
+	}
+	if err != nil {
+		t.Fatalf("insert test data: %v", err)
+	}
+
+	result, err := repo.Suggest(context.Background(), "pac", 10)
+	if err != nil {
+		t.Fatalf("Suggest error: %v", err)
+	}
+	if len(result.Suggestions) != 0 {
+		t.Errorf("expected cached empty index, got %v", result.Suggestions)
+	}
+}
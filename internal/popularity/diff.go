@@ -0,0 +1,121 @@
+package popularity
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+
+	"pkgstatsd/internal/database"
+)
+
+// DiffEntry compares an identifier's popularity between two months. Ranks
+// are nil for the month the identifier was not listed in.
+type DiffEntry struct {
+	Identifier     string   `json:"identifier"`
+	FromPopularity float64  `json:"fromPopularity"`
+	ToPopularity   float64  `json:"toPopularity"`
+	Change         float64  `json:"change"`
+	RelativeChange *float64 `json:"relativeChange"`
+	FromRank       *int     `json:"fromRank"`
+	ToRank         *int     `json:"toRank"`
+	RankChange     *int     `json:"rankChange"`
+}
+
+type DiffList struct {
+	FromMonth int         `json:"fromMonth"`
+	ToMonth   int         `json:"toMonth"`
+	Total     int         `json:"total"`
+	Count     int         `json:"count"`
+	Entries   []DiffEntry `json:"entries"`
+	Limit     int         `json:"limit"`
+	Offset    int         `json:"offset"`
+}
+
+// FindDiff lists every identifier of table listed in fromMonth or toMonth,
+// ordered by its popularity in toMonth. Identifiers are listed in a month if
+// their count reaches minCount; ranks are taken among the listed ones and a
+// month the identifier is not listed in counts as zero popularity, so counts
+// below the floor are never revealed. monthlySamples must contain the
+// samples of both months.
+func FindDiff(ctx context.Context, db *sql.DB, table, column string, minCount int, monthlySamples map[int]int, fromMonth, toMonth, limit, offset int) (*DiffList, error) {
+	//nolint:gosec
+	ranked := fmt.Sprintf(`SELECT %[1]s AS identifier, SUM(count) AS total_count, RANK() OVER (ORDER BY SUM(count) DESC) AS rank
+		FROM %[2]s WHERE month = ? GROUP BY %[1]s HAVING SUM(count) >= ?`,
+		column, table,
+	)
+	with := `WITH f AS (` + ranked + `), t AS (` + ranked + `),
+		ids AS (SELECT identifier FROM f UNION SELECT identifier FROM t)`
+	withArgs := []any{fromMonth, minCount, toMonth, minCount}
+	dialect := database.DialectOf(db)
+
+	var total int
+	if err := db.QueryRowContext(ctx, dialect.Rebind(with+` SELECT COUNT(*) FROM ids`), withArgs...).Scan(&total); err != nil {
+		return nil, fmt.Errorf("count %s diff: %w", table, err)
+	}
+
+	rows, err := db.QueryContext(ctx, dialect.Rebind(with+`
+		SELECT ids.identifier, COALESCE(f.total_count, 0), f.rank, COALESCE(t.total_count, 0), t.rank
+		FROM ids LEFT JOIN f USING (identifier) LEFT JOIN t USING (identifier)
+		ORDER BY COALESCE(t.total_count, 0) DESC, COALESCE(f.total_count, 0) DESC, ids.identifier ASC
+		LIMIT ? OFFSET ?`),
+		append(withArgs, limit, offset)...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query %s diff: %w", table, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	entries := make([]DiffEntry, 0)
+	for rows.Next() {
+		var e DiffEntry
+		var fromCount, toCount int
+		if err := rows.Scan(&e.Identifier, &fromCount, &e.FromRank, &toCount, &e.ToRank); err != nil {
+			return nil, fmt.Errorf("scan %s diff: %w", table, err)
+		}
+
+		e.FromPopularity = CalculatePopularity(fromCount, monthlySamples[fromMonth])
+		e.ToPopularity = CalculatePopularity(toCount, monthlySamples[toMonth])
+		e.Change = math.Round((e.ToPopularity-e.FromPopularity)*popularityPrecision) / popularityPrecision
+		if e.FromPopularity > 0 {
+			relative := math.Round((e.ToPopularity-e.FromPopularity)/e.FromPopularity*maxPopularity*popularityPrecision) / popularityPrecision
+			e.RelativeChange = &relative
+		}
+		if e.FromRank != nil && e.ToRank != nil {
+			rankChange := *e.FromRank - *e.ToRank
+			e.RankChange = &rankChange
+		}
+
+		entries = append(entries, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate %s diff: %w", table, err)
+	}
+
+	return &DiffList{
+		FromMonth: fromMonth,
+		ToMonth:   toMonth,
+		Total:     total,
+		Count:     len(entries),
+		Entries:   entries,
+		Limit:     limit,
+		Offset:    offset,
+	}, nil
+}
+
+// FindDiff compares the popularity of all identifiers between two months.
+func (r *Repository[T, L]) FindDiff(ctx context.Context, fromMonth, toMonth, limit, offset int) (*DiffList, error) {
+	startMonth, endMonth := min(fromMonth, toMonth), max(fromMonth, toMonth)
+	method := r.cfg.Table + ".FindDiff"
+	key := database.CacheKey(method, fromMonth, toMonth, limit, offset)
+
+	return database.Cached(ctx, r.cfg.Results, method, key, startMonth, endMonth, func(ctx context.Context) (*DiffList, error) {
+		samples, err := r.getMonthlySamples(ctx, startMonth, endMonth)
+		if err != nil {
+			return nil, fmt.Errorf("get monthly samples: %w", err)
+		}
+
+		return FindDiff(ctx, r.db, r.cfg.Table, r.cfg.Column, 0, samples, fromMonth, toMonth, limit, offset)
+	})
+}
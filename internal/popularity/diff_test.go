@@ -0,0 +1,106 @@
+package popularity
+
+import (
+	"context"
+	"testing"
+)
+
+func TestFindDiff(t *testing.T) {
+	repo, db := setupTestRepository(t, Config{Table: "test_entity", Column: "id"})
+	_, _ = db.Exec(`INSERT INTO test_entity (id, month, count) VALUES
+		('a', 202501, 50), ('b', 202501, 30), ('c', 202501, 20),
+		('a', 202502, 40), ('b', 202502, 40), ('d', 202502, 20)`)
+
+	list, err := repo.FindDiff(context.Background(), 202501, 202502, 10, 0)
+	if err != nil {
+		t.Fatalf("FindDiff error: %v", err)
+	}
+
+	if list.Total != 4 || list.Count != 4 {
+		t.Fatalf("expected 4 entries, got total %d count %d", list.Total, list.Count)
+	}
+	if list.FromMonth != 202501 || list.ToMonth != 202502 {
+		t.Errorf("unexpected months %d-%d", list.FromMonth, list.ToMonth)
+	}
+
+	tests := []struct {
+		identifier string
+		from, to   float64
+		change     float64
+		relative   *float64
+		fromRank   *int
+		toRank     *int
+		rankChange *int
+	}{
+		{"a", 50, 40, -10, new(-20.0), new(1), new(1), new(0)},
+		{"b", 30, 40, 10, new(33.33), new(2), new(1), new(1)},
+		{"d", 0, 20, 20, nil, nil, new(3), nil},
+		{"c", 20, 0, -20, new(-100.0), new(3), nil, nil},
+	}
+
+	for i, tt := range tests {
+		e := list.Entries[i]
+		if e.Identifier != tt.identifier {
+			t.Fatalf("entry %d: expected %s, got %s", i, tt.identifier, e.Identifier)
+		}
+		if e.FromPopularity != tt.from || e.ToPopularity != tt.to || e.Change != tt.change {
+			t.Errorf("%s: expected %v -> %v (%v), got %v -> %v (%v)", tt.identifier, tt.from, tt.to, tt.change, e.FromPopularity, e.ToPopularity, e.Change)
+		}
+		if !equalPtr(e.RelativeChange, tt.relative) {
+			t.Errorf("%s: expected relative change %v, got %v", tt.identifier, deref(tt.relative), deref(e.RelativeChange))
+		}
+		if !equalPtr(e.FromRank, tt.fromRank) || !equalPtr(e.ToRank, tt.toRank) || !equalPtr(e.RankChange, tt.rankChange) {
+			t.Errorf("%s: expected ranks %v -> %v (%v), got %v -> %v (%v)", tt.identifier,
+				deref(tt.fromRank), deref(tt.toRank), deref(tt.rankChange),
+				deref(e.FromRank), deref(e.ToRank), deref(e.RankChange))
+		}
+	}
+}
+
+func TestFindDiff_Pagination(t *testing.T) {
+	repo, db := setupTestRepository(t, Config{Table: "test_entity", Column: "id"})
+	_, _ = db.Exec(`INSERT INTO test_entity (id, month, count) VALUES
+		('a', 202501, 50), ('b', 202501, 30), ('c', 202501, 20),
+		('a', 202502, 40), ('b', 202502, 40), ('d', 202502, 20)`)
+
+	list, err := repo.FindDiff(context.Background(), 202501, 202502, 2, 2)
+	if err != nil {
+		t.Fatalf("FindDiff error: %v", err)
+	}
+
+	if list.Total != 4 || list.Count != 2 {
+		t.Fatalf("expected 2 of 4 entries, got %d of %d", list.Count, list.Total)
+	}
+	if list.Entries[0].Identifier != "d" || list.Entries[1].Identifier != "c" {
+		t.Errorf("expected d, c, got %s, %s", list.Entries[0].Identifier, list.Entries[1].Identifier)
+	}
+}
+
+func TestFindDiff_Empty(t *testing.T) {
+	repo, _ := setupTestRepository(t, Config{Table: "test_entity", Column: "id"})
+
+	list, err := repo.FindDiff(context.Background(), 202501, 202502, 10, 0)
+	if err != nil {
+		t.Fatalf("FindDiff error: %v", err)
+	}
+
+	if list.Total != 0 || list.Entries == nil || len(list.Entries) != 0 {
+		t.Errorf("expected empty entries, got %+v", list)
+	}
+}
+
+func equalPtr[T comparable](a, b *T) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return *a == *b
+}
+
+func deref[T any](p *T) any {
+	if p == nil {
+		return nil
+	}
+
+	return *p
+}
@@ -0,0 +1,109 @@
+package popularity
+
+import (
+	"math"
+
+	"pkgstatsd/internal/web"
+)
+
+const (
+	// MinForecastHistory is the number of observed months required to fit
+	// a trend with a residual error estimate.
+	MinForecastHistory = 3
+	// logitEpsilon keeps the logit finite for popularities of 0 and 100.
+	logitEpsilon  = 1e-4
+	monthsPerYear = 12
+)
+
+// ForecastPoint is the projected popularity of a month with its 95%
+// prediction interval.
+type ForecastPoint struct {
+	Month      int     `json:"month"`
+	Popularity float64 `json:"popularity"`
+	Interval
+}
+
+// Forecast projects a monthly popularity series horizon months past
+// endMonth, the last month of the series' range, whether or not it has an
+// observation. It fits an ordinary least squares line to the logit of the
+// popularities, so projections stay within 0..100 and trends flatten out
+// towards the bounds. Months without an observation are skipped rather than
+// treated as zero. The prediction interval uses the normal approximation of
+// the residual error. It returns nil if fewer than MinForecastHistory months
+// are given.
+func Forecast(months []int, popularities []float64, endMonth, horizon int) []ForecastPoint {
+	n := len(months)
+	if n < MinForecastHistory || n != len(popularities) || horizon < 1 {
+		return nil
+	}
+
+	xs := make([]float64, n)
+	ys := make([]float64, n)
+	var meanX, meanY float64
+	for i := range months {
+		xs[i] = float64(monthIndex(months[i]))
+		ys[i] = logit(popularities[i] / maxPopularity)
+		meanX += xs[i]
+		meanY += ys[i]
+	}
+	meanX /= float64(n)
+	meanY /= float64(n)
+
+	var sxx, sxy float64
+	for i := range xs {
+		sxx += (xs[i] - meanX) * (xs[i] - meanX)
+		sxy += (xs[i] - meanX) * (ys[i] - meanY)
+	}
+	if sxx == 0 {
+		return nil
+	}
+
+	slope := sxy / sxx
+	intercept := meanY - slope*meanX
+
+	var sse float64
+	for i := range xs {
+		residual := ys[i] - (intercept + slope*xs[i])
+		sse += residual * residual
+	}
+	stdErr := math.Sqrt(sse / float64(n-2))
+
+	points := make([]ForecastPoint, horizon)
+	for h := range points {
+		month := web.OffsetMonth(endMonth, h+1)
+		x := float64(monthIndex(month))
+		y := intercept + slope*x
+		margin := confidenceZ * stdErr * math.Sqrt(1+1/float64(n)+(x-meanX)*(x-meanX)/sxx)
+
+		points[h] = ForecastPoint{
+			Month:      month,
+			Popularity: roundPercent(sigmoid(y)),
+			Interval: Interval{
+				Low:  roundPercent(sigmoid(y - margin)),
+				High: roundPercent(sigmoid(y + margin)),
+			},
+		}
+	}
+
+	return points
+}
+
+// monthIndex maps a YYYYMM month to a count of months, so consecutive
+// months differ by one across year boundaries.
+func monthIndex(yearMonth int) int {
+	year, month := web.SplitYearMonth(yearMonth)
+	return year*monthsPerYear + int(month) - 1
+}
+
+func logit(p float64) float64 {
+	p = min(max(p, logitEpsilon), 1-logitEpsilon)
+	return math.Log(p / (1 - p))
+}
+
+func sigmoid(x float64) float64 {
+	return 1 / (1 + math.Exp(-x))
+}
+
+func roundPercent(p float64) float64 {
+	return math.Round(p*popularityScale) / popularityPrecision
+}
@@ -0,0 +1,95 @@
+package popularity
+
+import (
+	"math"
+	"testing"
+)
+
+func TestForecast_Linear(t *testing.T) {
+	months := []int{202410, 202411, 202412, 202501, 202502, 202503}
+	var values []float64
+	for i := range months {
+		// Exact logistic growth, so the fit has no residual error.
+		values = append(values, math.Round(sigmoid(-2+0.25*float64(i))*popularityScale)/popularityPrecision)
+	}
+
+	points := Forecast(months, values, 202503, 3)
+	if len(points) != 3 {
+		t.Fatalf("expected 3 points, got %d", len(points))
+	}
+
+	wantMonths := []int{202504, 202505, 202506}
+	for i, p := range points {
+		if p.Month != wantMonths[i] {
+			t.Errorf("point %d: expected month %d, got %d", i, wantMonths[i], p.Month)
+		}
+
+		want := math.Round(sigmoid(-2+0.25*float64(len(months)+i))*popularityScale) / popularityPrecision
+		if math.Abs(p.Popularity-want) > 0.02 {
+			t.Errorf("point %d: expected popularity %v, got %v", i, want, p.Popularity)
+		}
+		if p.Low > p.Popularity || p.High < p.Popularity {
+			t.Errorf("point %d: interval [%v, %v] does not contain %v", i, p.Low, p.High, p.Popularity)
+		}
+	}
+}
+
+func TestForecast_IntervalWidens(t *testing.T) {
+	months := []int{202501, 202502, 202503, 202504, 202505, 202506}
+	values := []float64{10, 12, 11, 14, 13, 15}
+
+	points := Forecast(months, values, 202506, 6)
+	if len(points) != 6 {
+		t.Fatalf("expected 6 points, got %d", len(points))
+	}
+
+	for i := 1; i < len(points); i++ {
+		prev, cur := points[i-1], points[i]
+		if cur.High-cur.Low <= prev.High-prev.Low {
+			t.Errorf("expected interval to widen at point %d: [%v, %v] after [%v, %v]", i, cur.Low, cur.High, prev.Low, prev.High)
+		}
+	}
+}
+
+func TestForecast_Bounds(t *testing.T) {
+	months := []int{202501, 202502, 202503, 202504}
+
+	for _, values := range [][]float64{{0, 0, 0, 0}, {100, 100, 100, 100}, {90, 95, 98, 99.5}} {
+		for _, p := range Forecast(months, values, 202504, 12) {
+			if p.Low < 0 || p.High > maxPopularity || p.Popularity < 0 || p.Popularity > maxPopularity {
+				t.Errorf("values %v: point %+v out of bounds", values, p)
+			}
+		}
+	}
+}
+
+func TestForecast_Gaps(t *testing.T) {
+	points := Forecast([]int{202411, 202501, 202503}, []float64{10, 10, 10}, 202503, 1)
+	if len(points) != 1 {
+		t.Fatalf("expected 1 point, got %d", len(points))
+	}
+	if points[0].Month != 202504 || points[0].Popularity != 10 {
+		t.Errorf("expected 10%% in 202504, got %+v", points[0])
+	}
+}
+
+func TestForecast_MissingLastMonths(t *testing.T) {
+	// The projection continues after the end of the range, not after the
+	// last month with an observation.
+	points := Forecast([]int{202501, 202502, 202503}, []float64{10, 10, 10}, 202506, 2)
+	if len(points) != 2 {
+		t.Fatalf("expected 2 points, got %d", len(points))
+	}
+	if points[0].Month != 202507 || points[1].Month != 202508 {
+		t.Errorf("expected 202507 and 202508, got %d and %d", points[0].Month, points[1].Month)
+	}
+}
+
+func TestForecast_InsufficientHistory(t *testing.T) {
+	if points := Forecast([]int{202501, 202502}, []float64{10, 20}, 202502, 6); points != nil {
+		t.Errorf("expected nil, got %+v", points)
+	}
+	if points := Forecast([]int{202501, 202502, 202503}, []float64{10, 20, 30}, 202503, 0); points != nil {
+		t.Errorf("expected nil for zero horizon, got %+v", points)
+	}
+}
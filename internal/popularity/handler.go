@@ -9,8 +9,9 @@ import (
 
 type Querier[T any, L any] interface {
 	FindByIdentifier(ctx context.Context, identifier string, startMonth, endMonth int) (*T, error)
-	FindAll(ctx context.Context, query string, startMonth, endMonth, limit, offset int) (*L, error)
-	FindSeries(ctx context.Context, identifier string, startMonth, endMonth, limit, offset int) (*L, error)
+	FindAll(ctx context.Context, query string, startMonth, endMonth, limit, offset int, opts web.ListOptions) (*L, error)
+	FindSeries(ctx context.Context, identifier string, startMonth, endMonth, limit, offset int, opts web.SeriesOptions) (*L, error)
+	FindDiff(ctx context.Context, fromMonth, toMonth, limit, offset int) (*DiffList, error)
 }
 
 type Handler[T any, L any] struct {
@@ -42,13 +43,18 @@ func (h *Handler[T, L]) HandleGet(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	validators, done := web.CheckNotModified(w, r, endMonth)
+	if done {
+		return
+	}
+
 	item, err := h.repo.FindByIdentifier(r.Context(), identifier, startMonth, endMonth)
 	if err != nil {
-		web.ServerError(w, "failed to find item", err)
+		web.ServerError(w, r, "failed to find item", err)
 		return
 	}
 
-	web.WriteEntityJSON(w, item)
+	web.WriteEntityJSON(w, r, validators, item)
 }
 
 func (h *Handler[T, L]) HandleList(w http.ResponseWriter, r *http.Request) {
@@ -70,13 +76,24 @@ func (h *Handler[T, L]) HandleList(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	list, err := h.repo.FindAll(r.Context(), query, startMonth, endMonth, limit, offset)
+	opts, err := web.ParseListOptions(r)
+	if err != nil {
+		web.BadRequest(w, err.Error())
+		return
+	}
+
+	validators, done := web.CheckNotModified(w, r, endMonth)
+	if done {
+		return
+	}
+
+	list, err := h.repo.FindAll(r.Context(), query, startMonth, endMonth, limit, offset, opts)
 	if err != nil {
-		web.ServerError(w, "failed to list items", err)
+		web.ServerError(w, r, "failed to list items", err)
 		return
 	}
 
-	web.WriteEntityJSON(w, list)
+	web.WriteEntityJSON(w, r, validators, list)
 }
 
 func (h *Handler[T, L]) HandleSeries(w http.ResponseWriter, r *http.Request) {
@@ -98,17 +115,59 @@ func (h *Handler[T, L]) HandleSeries(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	list, err := h.repo.FindSeries(r.Context(), identifier, startMonth, endMonth, limit, offset)
+	opts, err := web.ParseSeriesOptions(r)
+	if err != nil {
+		web.BadRequest(w, err.Error())
+		return
+	}
+
+	validators, done := web.CheckNotModified(w, r, endMonth)
+	if done {
+		return
+	}
+
+	list, err := h.repo.FindSeries(r.Context(), identifier, startMonth, endMonth, limit, offset, opts)
+	if err != nil {
+		web.ServerError(w, r, "failed to find item series", err)
+		return
+	}
+
+	web.WriteEntityJSON(w, r, validators, list)
+}
+
+func (h *Handler[T, L]) HandleDiff(w http.ResponseWriter, r *http.Request) {
+	fromMonth, toMonth, err := web.ParseDiffMonths(r)
+	if err != nil {
+		web.BadRequest(w, err.Error())
+		return
+	}
+
+	limit, offset, err := web.ParsePagination(r)
+	if err != nil {
+		web.BadRequest(w, err.Error())
+		return
+	}
+
+	validators, done := web.CheckNotModified(w, r, toMonth)
+	if done {
+		return
+	}
+
+	list, err := h.repo.FindDiff(r.Context(), fromMonth, toMonth, limit, offset)
 	if err != nil {
-		web.ServerError(w, "failed to find item series", err)
+		web.ServerError(w, r, "failed to compare items", err)
 		return
 	}
 
-	web.WriteEntityJSON(w, list)
+	web.WriteEntityJSON(w, r, validators, list)
 }
 
+// RegisterRoutes registers the entity's API. The diff route is more
+// specific than the item route, so an identifier named diff cannot be
+// fetched as a single item.
 func (h *Handler[T, L]) RegisterRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("GET "+h.basePath, h.HandleList)
+	mux.HandleFunc("GET "+h.basePath+"/diff", h.HandleDiff)
 	mux.HandleFunc("GET "+h.basePath+"/{"+h.pathParam+"}", h.HandleGet)
 	mux.HandleFunc("GET "+h.basePath+"/{"+h.pathParam+"}/series", h.HandleSeries)
 }
@@ -5,28 +5,37 @@ import (
 	"database/sql"
 	"fmt"
 	"math"
+	"strings"
 
 	"pkgstatsd/internal/database"
+	"pkgstatsd/internal/web"
 )
 
 const (
 	popularityScale     = 10000
 	popularityPrecision = 100
 	maxPopularity       = 100
+	trigramLength       = 3
+	fuzzySimilarity     = 0.6
+	// confidenceZ is the standard normal quantile for a 95% interval.
+	confidenceZ = 1.96
 )
 
 type Config struct {
 	Table         string // e.g. "country"
 	Column        string // e.g. "code"
-	QueryContains bool   // true for mirrors (uses %query%), false for prefix (query%)
+	QueryContains bool   // default match: true for contains (%query%), false for prefix (query%)
+	// Results caches query results; nil disables caching.
+	Results *database.ResultCache
 }
 
-type ItemFunc[T any] func(identifier string, samples, count int, popularity float64, startMonth, endMonth int) T
+type ItemFunc[T any] func(identifier string, samples, count int, popularity float64, interval *Interval, startMonth, endMonth int) T
 
-type ListFunc[L any, T any] func(total, count int, items []T, limit, offset int, query *string) L
+type ListFunc[L any, T any] func(total, count int, items []T, limit, offset int, query, next *string, smoothing string) L
 
 type Repository[T any, L any] struct {
 	db           *sql.DB
+	dialect      database.Dialect
 	cfg          Config
 	samplesCache *database.MonthlySamplesCache
 	newItem      ItemFunc[T]
@@ -38,6 +47,7 @@ func NewRepository[T, L any](db *sql.DB, cfg Config, newItem ItemFunc[T], newLis
 
 	return &Repository[T, L]{
 		db:           db,
+		dialect:      database.DialectOf(db),
 		cfg:          cfg,
 		samplesCache: database.NewMonthlySamplesCache(db, samplesQuery),
 		newItem:      newItem,
@@ -56,6 +66,14 @@ func monthRange(startMonth, endMonth int) (clause string, args []any) {
 }
 
 func (r *Repository[T, L]) FindByIdentifier(ctx context.Context, identifier string, startMonth, endMonth int) (*T, error) {
+	method := r.cfg.Table + ".FindByIdentifier"
+	key := database.CacheKey(method, identifier, startMonth, endMonth)
+	return database.Cached(ctx, r.cfg.Results, method, key, startMonth, endMonth, func(ctx context.Context) (*T, error) {
+		return r.findByIdentifier(ctx, identifier, startMonth, endMonth)
+	})
+}
+
+func (r *Repository[T, L]) findByIdentifier(ctx context.Context, identifier string, startMonth, endMonth int) (*T, error) {
 	var count int
 
 	mClause, mArgs := monthRange(startMonth, endMonth)
@@ -65,7 +83,7 @@ func (r *Repository[T, L]) FindByIdentifier(ctx context.Context, identifier stri
 		r.cfg.Table, r.cfg.Column,
 	)
 
-	if err := r.db.QueryRowContext(ctx, query, append([]any{identifier}, mArgs...)...).Scan(&count); err != nil {
+	if err := r.db.QueryRowContext(ctx, r.dialect.Rebind(query), append([]any{identifier}, mArgs...)...).Scan(&count); err != nil {
 		return nil, fmt.Errorf("query %s count: %w", r.cfg.Table, err)
 	}
 
@@ -74,62 +92,85 @@ func (r *Repository[T, L]) FindByIdentifier(ctx context.Context, identifier stri
 		return nil, fmt.Errorf("get samples: %w", err)
 	}
 
-	item := r.newItem(identifier, samples, count, CalculatePopularity(count, samples), startMonth, endMonth)
+	item := r.newItem(identifier, samples, count, CalculatePopularity(count, samples), WilsonInterval(count, samples), startMonth, endMonth)
 
 	return &item, nil
 }
 
-func (r *Repository[T, L]) FindAll(ctx context.Context, query string, startMonth, endMonth, limit, offset int) (*L, error) {
+func (r *Repository[T, L]) FindAll(ctx context.Context, query string, startMonth, endMonth, limit, offset int, opts web.ListOptions) (*L, error) {
+	method := r.cfg.Table + ".FindAll"
+	key := database.CacheKey(method, query, startMonth, endMonth, limit, offset, opts)
+	return database.Cached(ctx, r.cfg.Results, method, key, startMonth, endMonth, func(ctx context.Context) (*L, error) {
+		return r.findAll(ctx, query, startMonth, endMonth, limit, offset, opts)
+	})
+}
+
+func (r *Repository[T, L]) findAll(ctx context.Context, query string, startMonth, endMonth, limit, offset int, opts web.ListOptions) (*L, error) {
 	samples, err := r.getSamples(ctx, startMonth, endMonth)
 	if err != nil {
 		return nil, fmt.Errorf("get samples: %w", err)
 	}
 
+	orderClause, orderArgs, err := r.orderBy(ctx, opts, startMonth, endMonth)
+	if err != nil {
+		return nil, fmt.Errorf("get growth samples: %w", err)
+	}
+
+	keysetClause, keysetArgs := KeysetCondition(opts, r.cfg.Column, "SUM(count)")
+
+	source, sourceArgs, err := RangeSource(ctx, r.db, r.cfg.Table, r.cfg.Column, startMonth, endMonth)
+	if err != nil {
+		return nil, fmt.Errorf("get rollups: %w", err)
+	}
+
 	mClause, mArgs := monthRange(startMonth, endMonth)
-	likeCondition := fmt.Sprintf(` AND %s LIKE ?`, r.cfg.Column)
+	whereClause := ` WHERE ` + mClause
+	whereArgs := append(sourceArgs, mArgs...)
+
+	match := opts.Match
+	if match == "" {
+		match = web.MatchPrefix
+		if r.cfg.QueryContains {
+			match = web.MatchContains
+		}
+	}
+
+	matchClause, matchArgs := MatchCondition(r.dialect, match, r.cfg.Column, query)
+	whereClause += matchClause
+	whereArgs = append(whereArgs, matchArgs...)
 
 	//nolint:gosec
 	sqlQuery := fmt.Sprintf(`
 		SELECT %s, SUM(count) as total_count
-		FROM %s
-		WHERE `+mClause,
-		r.cfg.Column, r.cfg.Table,
-	)
-	args := append([]any{}, mArgs...)
-	countArgs := append([]any{}, mArgs...)
-
-	if query != "" {
-		sqlQuery += likeCondition
-		pattern := r.queryPattern(query)
-		args = append(args, pattern)
-		countArgs = append(countArgs, pattern)
-	}
-
-	sqlQuery += fmt.Sprintf(` GROUP BY %s ORDER BY total_count DESC, %s ASC LIMIT ? OFFSET ?`,
-		r.cfg.Column, r.cfg.Column,
+		FROM %s`+whereClause+`
+		GROUP BY %s HAVING SUM(count) >= ?`+keysetClause+orderClause+` LIMIT ? OFFSET ?`,
+		r.cfg.Column, source, r.cfg.Column,
 	)
-	args = append(args, limit, offset)
+	args := append(append([]any{}, whereArgs...), opts.MinCount)
+	args = append(append(append(args, keysetArgs...), orderArgs...), limit, offset)
 
 	//nolint:gosec
-	countQuery := fmt.Sprintf(`SELECT COUNT(DISTINCT %s) FROM %s WHERE `+mClause,
-		r.cfg.Column, r.cfg.Table,
+	countQuery := fmt.Sprintf(`
+		SELECT COUNT(*) FROM (
+			SELECT %s FROM %s`+whereClause+`
+			GROUP BY %s HAVING SUM(count) >= ?) AS matches`,
+		r.cfg.Column, source, r.cfg.Column,
 	)
-	if query != "" {
-		countQuery += likeCondition
-	}
+	countArgs := append(whereArgs, opts.MinCount)
 
 	var total int
-	if err := r.db.QueryRowContext(ctx, countQuery, countArgs...).Scan(&total); err != nil {
+	if err := r.db.QueryRowContext(ctx, r.dialect.Rebind(countQuery), countArgs...).Scan(&total); err != nil {
 		return nil, fmt.Errorf("count %s: %w", r.cfg.Table, err)
 	}
 
-	rows, err := r.db.QueryContext(ctx, sqlQuery, args...)
+	rows, err := r.db.QueryContext(ctx, r.dialect.Rebind(sqlQuery), args...)
 	if err != nil {
 		return nil, fmt.Errorf("query %s: %w", r.cfg.Table, err)
 	}
 	defer func() { _ = rows.Close() }()
 
 	var items []T
+	var next *string
 	for rows.Next() {
 		var identifier string
 		var count int
@@ -137,7 +178,10 @@ func (r *Repository[T, L]) FindAll(ctx context.Context, query string, startMonth
 			return nil, fmt.Errorf("scan %s: %w", r.cfg.Table, err)
 		}
 
-		items = append(items, r.newItem(identifier, samples, count, CalculatePopularity(count, samples), startMonth, endMonth))
+		items = append(items, r.newItem(identifier, samples, count, CalculatePopularity(count, samples), WilsonInterval(count, samples), startMonth, endMonth))
+		if len(items) == limit {
+			next = opts.NextCursor(count, identifier)
+		}
 	}
 
 	if err := rows.Err(); err != nil {
@@ -148,21 +192,46 @@ func (r *Repository[T, L]) FindAll(ctx context.Context, query string, startMonth
 		items = make([]T, 0)
 	}
 
-	list := r.newList(total, len(items), items, limit, offset, &query)
+	list := r.newList(total, len(items), items, limit, offset, &query, next, "")
 
 	return &list, nil
 }
 
-func (r *Repository[T, L]) FindSeries(ctx context.Context, identifier string, startMonth, endMonth, limit, offset int) (*L, error) {
+func (r *Repository[T, L]) orderBy(ctx context.Context, opts web.ListOptions, startMonth, endMonth int) (string, []any, error) {
+	var growthSamples map[int]int
+	if opts.Sort == web.SortGrowth {
+		baseMonth, targetMonth := GrowthMonths(startMonth, endMonth)
+
+		var err error
+		if growthSamples, err = r.getMonthlySamples(ctx, baseMonth, targetMonth); err != nil {
+			return "", nil, err
+		}
+	}
+
+	clause, args := OrderBy(opts, r.cfg.Table, r.cfg.Column, r.cfg.Column, "total_count", startMonth, endMonth, growthSamples)
+
+	return clause, args, nil
+}
+
+func (r *Repository[T, L]) FindSeries(ctx context.Context, identifier string, startMonth, endMonth, limit, offset int, opts web.SeriesOptions) (*L, error) {
+	method := r.cfg.Table + ".FindSeries"
+	key := database.CacheKey(method, identifier, startMonth, endMonth, limit, offset, opts)
+	return database.Cached(ctx, r.cfg.Results, method, key, startMonth, endMonth, func(ctx context.Context) (*L, error) {
+		return r.findSeries(ctx, identifier, startMonth, endMonth, limit, offset, opts)
+	})
+}
+
+func (r *Repository[T, L]) findSeries(ctx context.Context, identifier string, startMonth, endMonth, limit, offset int, opts web.SeriesOptions) (*L, error) {
 	mClause, mArgs := monthRange(startMonth, endMonth)
+	period := PeriodColumn(opts.Granularity)
 
 	//nolint:gosec
-	countQuery := fmt.Sprintf(`SELECT COUNT(*) FROM %s WHERE %s = ? AND `+mClause,
-		r.cfg.Table, r.cfg.Column,
+	countQuery := fmt.Sprintf(`SELECT COUNT(DISTINCT %s) FROM %s WHERE %s = ? AND `+mClause,
+		period, r.cfg.Table, r.cfg.Column,
 	)
 
 	var total int
-	if err := r.db.QueryRowContext(ctx, countQuery, append([]any{identifier}, mArgs...)...).Scan(&total); err != nil {
+	if err := r.db.QueryRowContext(ctx, r.dialect.Rebind(countQuery), append([]any{identifier}, mArgs...)...).Scan(&total); err != nil {
 		return nil, fmt.Errorf("count series: %w", err)
 	}
 
@@ -170,38 +239,43 @@ func (r *Repository[T, L]) FindSeries(ctx context.Context, identifier string, st
 	if err != nil {
 		return nil, fmt.Errorf("get monthly samples: %w", err)
 	}
+	samplesMap = PeriodSamples(samplesMap, opts.Granularity)
 
 	//nolint:gosec
-	sqlQuery := fmt.Sprintf(`SELECT month, count FROM %s WHERE %s = ? AND `+mClause+` ORDER BY month ASC LIMIT ? OFFSET ?`,
-		r.cfg.Table, r.cfg.Column,
+	sqlQuery := fmt.Sprintf(`SELECT %s AS period, SUM(count) FROM %s WHERE %s = ? AND `+mClause+` GROUP BY period ORDER BY period ASC LIMIT ? OFFSET ?`,
+		period, r.cfg.Table, r.cfg.Column,
 	)
 
-	rows, err := r.db.QueryContext(ctx, sqlQuery, append(append([]any{identifier}, mArgs...), limit, offset)...)
+	queryLimit, queryOffset := SeriesWindow(limit, offset, opts)
+	rows, err := r.db.QueryContext(ctx, r.dialect.Rebind(sqlQuery), append(append([]any{identifier}, mArgs...), queryLimit, queryOffset)...)
 	if err != nil {
 		return nil, fmt.Errorf("query series: %w", err)
 	}
 	defer func() { _ = rows.Close() }()
 
-	var items []T
+	var points []SeriesPoint
 	for rows.Next() {
-		var month, count int
-		if err := rows.Scan(&month, &count); err != nil {
+		var p SeriesPoint
+		if err := rows.Scan(&p.Period, &p.Count); err != nil {
 			return nil, fmt.Errorf("scan series: %w", err)
 		}
 
-		samples := samplesMap[month]
-		items = append(items, r.newItem(identifier, samples, count, CalculatePopularity(count, samples), month, month))
+		p.Samples = samplesMap[p.Period]
+		points = append(points, p)
 	}
 
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("iterate series: %w", err)
 	}
 
-	if items == nil {
-		items = make([]T, 0)
+	points = SmoothSeries(points, offset, opts)
+	items := make([]T, 0, len(points))
+	for _, p := range points {
+		first, last := PeriodRange(p.Period, opts.Granularity, startMonth, endMonth)
+		items = append(items, r.newItem(identifier, p.Samples, p.Count, p.Popularity, p.Interval, first, last))
 	}
 
-	list := r.newList(total, len(items), items, limit, offset, nil)
+	list := r.newList(total, len(items), items, limit, offset, nil, nil, opts.Smoothing.String())
 
 	return &list, nil
 }
@@ -210,14 +284,6 @@ func (r *Repository[T, L]) WarmupCache(ctx context.Context) error {
 	return r.samplesCache.Warmup(ctx)
 }
 
-func (r *Repository[T, L]) queryPattern(query string) string {
-	if r.cfg.QueryContains {
-		return "%" + query + "%"
-	}
-
-	return query + "%"
-}
-
 func (r *Repository[T, L]) getSamples(ctx context.Context, startMonth, endMonth int) (int, error) {
 	monthlySamples, err := r.getMonthlySamples(ctx, startMonth, endMonth)
 	if err != nil {
@@ -236,6 +302,224 @@ func (r *Repository[T, L]) getMonthlySamples(ctx context.Context, startMonth, en
 	return r.samplesCache.Get(ctx, startMonth, endMonth)
 }
 
+// GrowthMonths returns the months compared when ordering by growth: the first
+// and last month of the range, or the month before endMonth when the range
+// covers a single month or has no lower bound.
+func GrowthMonths(startMonth, endMonth int) (baseMonth, targetMonth int) {
+	if startMonth != 0 && startMonth < endMonth {
+		return startMonth, endMonth
+	}
+
+	return web.OffsetMonth(endMonth, -1), endMonth
+}
+
+// PeriodColumn returns the SQL expression mapping the month column to the
+// first month of its period, so series can be grouped by granularity.
+func PeriodColumn(granularity string) string {
+	switch granularity {
+	case web.GranularityQuarter:
+		return "month - (month % 100 - 1) % 3"
+	case web.GranularityYear:
+		return "month - month % 100 + 1"
+	default:
+		return "month"
+	}
+}
+
+// PeriodSamples sums monthly samples into the periods of the granularity,
+// keyed by the first month of each period.
+func PeriodSamples(monthlySamples map[int]int, granularity string) map[int]int {
+	if granularity == web.GranularityMonth {
+		return monthlySamples
+	}
+
+	samples := make(map[int]int)
+	for month, count := range monthlySamples {
+		samples[web.PeriodStart(month, granularity)] += count
+	}
+
+	return samples
+}
+
+// SeriesPoint is a single period of a series before it is turned into an
+// entity's popularity item.
+type SeriesPoint struct {
+	Period     int
+	Samples    int
+	Count      int
+	Popularity float64
+	Interval   *Interval
+}
+
+// SeriesWindow returns the LIMIT and OFFSET to query a page of a series.
+// Smoothing needs the preceding periods as well, so smoothed pages are read
+// from the start of the series and trimmed by SmoothSeries. Pages still count
+// rows; only the smoothing windows span periods.
+func SeriesWindow(limit, offset int, opts web.SeriesOptions) (queryLimit, queryOffset int) {
+	if opts.Smoothing.Method == "" {
+		return limit, offset
+	}
+
+	return offset + limit, 0
+}
+
+// SmoothSeries calculates the popularity of each point, smooths it according
+// to opts and drops the leading points SeriesWindow read for smoothing only.
+// Periods without a point are filled in as gaps first, so smoothing windows
+// span periods rather than rows, just like chartdata.Build. Confidence
+// intervals describe a single sample, so smoothed points have none.
+func SmoothSeries(points []SeriesPoint, offset int, opts web.SeriesOptions) []SeriesPoint {
+	for i, p := range points {
+		points[i].Popularity = CalculatePopularity(p.Count, p.Samples)
+		if opts.Smoothing.Method == "" {
+			points[i].Interval = WilsonInterval(p.Count, p.Samples)
+		}
+	}
+
+	if opts.Smoothing.Method == "" || len(points) == 0 {
+		return points
+	}
+
+	var values []*float64
+	indexes := make([]int, len(points))
+	last := points[len(points)-1].Period
+	for period, i := points[0].Period, 0; period <= last; period = web.NextPeriod(period, opts.Granularity) {
+		var value *float64
+		if points[i].Period == period {
+			indexes[i] = len(values)
+			value = &points[i].Popularity
+			i++
+		}
+		values = append(values, value)
+	}
+
+	smoothed := Smooth(values, opts.Smoothing)
+	for i := range points {
+		points[i].Popularity = *smoothed[indexes[i]]
+	}
+
+	return points[min(offset, len(points)):]
+}
+
+// PeriodRange returns the months a series point covers: the period starting
+// at period, clipped to the requested range.
+func PeriodRange(period int, granularity string, startMonth, endMonth int) (first, last int) {
+	return max(period, startMonth), min(web.PeriodEnd(period, granularity), endMonth)
+}
+
+// OrderBy returns the ORDER BY clause for opts and its bound args. column is
+// the selected identifier and countExpr the selected count column. Growth
+// ordering compares each entry's share of samples between the GrowthMonths,
+// which monthlySamples must contain, summing the rows of table whose key
+// column matches the entry's.
+func OrderBy(opts web.ListOptions, table, key, column, countExpr string, startMonth, endMonth int, monthlySamples map[int]int) (string, []any) {
+	direction := "ASC"
+	if opts.Descending() {
+		direction = "DESC"
+	}
+
+	switch opts.Sort {
+	case web.SortName:
+		return fmt.Sprintf(` ORDER BY %s %s`, column, direction), nil
+	case web.SortGrowth:
+		baseMonth, targetMonth := GrowthMonths(startMonth, endMonth)
+		share := fmt.Sprintf(`(SELECT COALESCE(SUM(g.count), 0) FROM %s g WHERE g.%s = %s.%s AND g.month = ?) * CAST(? AS DOUBLE PRECISION)`,
+			table, key, table, key,
+		)
+
+		return fmt.Sprintf(` ORDER BY %s - %s %s, %s ASC`, share, share, direction, column), []any{
+			targetMonth, sampleWeight(monthlySamples[targetMonth]),
+			baseMonth, sampleWeight(monthlySamples[baseMonth]),
+		}
+	default:
+		return fmt.Sprintf(` ORDER BY %s %s, %s ASC`, countExpr, direction, column), nil
+	}
+}
+
+// MatchCondition returns an SQL condition matching column against query, or
+// an empty string when query is empty. Fuzzy matching requires a share of
+// the query's trigrams to appear in the value, so it tolerates typos.
+func MatchCondition(d database.Dialect, match, column, query string) (string, []any) {
+	if query == "" {
+		return "", nil
+	}
+
+	switch match {
+	case web.MatchContains:
+		return fmt.Sprintf(` AND %s %s ?`, column, d.Like()), []any{"%" + query + "%"}
+	case web.MatchGlob:
+		clause, arg := d.Glob(column, query)
+		return ` AND ` + clause, []any{arg}
+	case web.MatchFuzzy:
+		trigrams := Trigrams(query)
+		if len(trigrams) == 0 {
+			return fmt.Sprintf(` AND %s %s ?`, column, d.Like()), []any{"%" + query + "%"}
+		}
+
+		terms := make([]string, len(trigrams))
+		args := make([]any, 0, len(trigrams)+1)
+		for i, trigram := range trigrams {
+			terms[i] = `CASE WHEN ` + d.Instr(`lower(`+column+`)`) + ` > 0 THEN 1 ELSE 0 END`
+			args = append(args, trigram)
+		}
+		args = append(args, int(math.Ceil(float64(len(trigrams))*fuzzySimilarity)))
+
+		return ` AND (` + strings.Join(terms, " + ") + `) >= ?`, args
+	default:
+		return fmt.Sprintf(` AND %s %s ?`, column, d.Like()), []any{query + "%"}
+	}
+}
+
+// Trigrams returns the distinct lowercase three-character substrings of s.
+func Trigrams(s string) []string {
+	s = strings.ToLower(s)
+
+	var trigrams []string
+	seen := make(map[string]bool)
+	for i := 0; i+trigramLength <= len(s); i++ {
+		trigram := s[i : i+trigramLength]
+		if !seen[trigram] {
+			seen[trigram] = true
+			trigrams = append(trigrams, trigram)
+		}
+	}
+
+	return trigrams
+}
+
+// KeysetCondition returns an SQL condition selecting the rows that follow
+// opts.Cursor in the order produced by OrderBy, or an empty string when no
+// cursor is set.
+func KeysetCondition(opts web.ListOptions, column, countExpr string) (string, []any) {
+	cursor := opts.Cursor
+	if cursor == nil {
+		return "", nil
+	}
+
+	if cursor.Sort == web.SortName {
+		if opts.Descending() {
+			return fmt.Sprintf(` AND %s < ?`, column), []any{cursor.Key}
+		}
+		return fmt.Sprintf(` AND %s > ?`, column), []any{cursor.Key}
+	}
+
+	countOp := ">"
+	if opts.Descending() {
+		countOp = "<"
+	}
+
+	return fmt.Sprintf(` AND (%s %s ? OR (%s = ? AND %s > ?))`, countExpr, countOp, countExpr, column),
+		[]any{cursor.Count, cursor.Count, cursor.Key}
+}
+
+func sampleWeight(samples int) float64 {
+	if samples == 0 {
+		return 0
+	}
+
+	return 1 / float64(samples)
+}
+
 // CalculatePopularity returns a percentage rounded to 2 decimal places, capped at 100.
 func CalculatePopularity(count, samples int) float64 {
 	if samples == 0 {
@@ -244,3 +528,41 @@ func CalculatePopularity(count, samples int) float64 {
 
 	return min(math.Round(float64(count)/float64(samples)*popularityScale)/popularityPrecision, maxPopularity)
 }
+
+// Interval is a confidence interval around a popularity, in percent. It is
+// embedded as a pointer in item responses, so it is only serialized when set.
+type Interval struct {
+	Low  float64 `json:"popularityLow"`
+	High float64 `json:"popularityHigh"`
+}
+
+// Bounds returns the interval, or ok=false when there is none.
+func (i *Interval) Bounds() (low, high float64, ok bool) {
+	if i == nil {
+		return 0, 0, false
+	}
+
+	return i.Low, i.High, true
+}
+
+// WilsonInterval returns the 95% Wilson score interval of count out of
+// samples, or nil without samples. Unlike the normal approximation it stays
+// within 0-100% and is meaningful for the tiny counts of niche entries.
+func WilsonInterval(count, samples int) *Interval {
+	if samples == 0 {
+		return nil
+	}
+
+	n := float64(samples)
+	p := min(float64(count)/n, 1)
+	z2 := confidenceZ * confidenceZ
+
+	denominator := 1 + z2/n
+	center := (p + z2/(2*n)) / denominator
+	margin := confidenceZ * math.Sqrt(p*(1-p)/n+z2/(4*n*n)) / denominator
+
+	return &Interval{
+		Low:  max(math.Round((center-margin)*popularityScale)/popularityPrecision, 0),
+		High: min(math.Round((center+margin)*popularityScale)/popularityPrecision, maxPopularity),
+	}
+}
@@ -3,9 +3,13 @@ package popularity
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"slices"
+	"strings"
 	"testing"
 
 	"pkgstatsd/internal/database"
+	"pkgstatsd/internal/web"
 )
 
 type testItem struct {
@@ -13,30 +17,33 @@ type testItem struct {
 	Samples    int     `json:"samples"`
 	Count      int     `json:"count"`
 	Popularity float64 `json:"popularity"`
-	StartMonth int     `json:"startMonth"`
-	EndMonth   int     `json:"endMonth"`
+	*Interval
+	StartMonth int `json:"startMonth"`
+	EndMonth   int `json:"endMonth"`
 }
 
 type testList struct {
-	Total  int        `json:"total"`
-	Count  int        `json:"count"`
-	Items  []testItem `json:"items"`
-	Limit  int        `json:"limit"`
-	Offset int        `json:"offset"`
-	Query  *string    `json:"query"`
+	Total     int        `json:"total"`
+	Count     int        `json:"count"`
+	Items     []testItem `json:"items"`
+	Limit     int        `json:"limit"`
+	Offset    int        `json:"offset"`
+	Query     *string    `json:"query"`
+	Next      *string    `json:"next,omitempty"`
+	Smoothing string     `json:"smoothing,omitempty"`
 }
 
-func newTestItem(identifier string, samples, count int, popularity float64, startMonth, endMonth int) testItem {
+func newTestItem(identifier string, samples, count int, popularity float64, interval *Interval, startMonth, endMonth int) testItem {
 	return testItem{
 		ID: identifier, Samples: samples, Count: count,
-		Popularity: popularity, StartMonth: startMonth, EndMonth: endMonth,
+		Popularity: popularity, Interval: interval, StartMonth: startMonth, EndMonth: endMonth,
 	}
 }
 
-func newTestList(total, count int, items []testItem, limit, offset int, query *string) testList {
+func newTestList(total, count int, items []testItem, limit, offset int, query, next *string, smoothing string) testList {
 	return testList{
 		Total: total, Count: count, Items: items,
-		Limit: limit, Offset: offset, Query: query,
+		Limit: limit, Offset: offset, Query: query, Next: next, Smoothing: smoothing,
 	}
 }
 
@@ -94,7 +101,7 @@ func TestFindAll(t *testing.T) {
 		('b', 202501, 20),
 		('c', 202501, 30)`)
 
-	list, err := repo.FindAll(context.Background(), "", 202501, 202501, 2, 0)
+	list, err := repo.FindAll(context.Background(), "", 202501, 202501, 2, 0, web.ListOptions{})
 	if err != nil {
 		t.Fatalf("FindAll error: %v", err)
 	}
@@ -111,12 +118,146 @@ func TestFindAll(t *testing.T) {
 	}
 }
 
+func TestFindAll_SortByName(t *testing.T) {
+	repo, db := setupTestRepository(t, Config{Table: "test_entity", Column: "id"})
+
+	_, _ = db.Exec(`INSERT INTO test_entity (id, month, count) VALUES ('b', 202501, 10), ('c', 202501, 20), ('a', 202501, 30)`)
+
+	list, err := repo.FindAll(context.Background(), "", 202501, 202501, 10, 0, web.ListOptions{Sort: web.SortName})
+	if err != nil {
+		t.Fatalf("FindAll error: %v", err)
+	}
+
+	for i, want := range []string{"a", "b", "c"} {
+		if list.Items[i].ID != want {
+			t.Errorf("item %d: expected %s, got %s", i, want, list.Items[i].ID)
+		}
+	}
+}
+
+func TestFindAll_SortByGrowth(t *testing.T) {
+	repo, db := setupTestRepository(t, Config{Table: "test_entity", Column: "id"})
+
+	// Shares in 202412: a=80%, b=20%; in 202501: a=50%, b=30%, c=20%
+	_, _ = db.Exec(`INSERT INTO test_entity (id, month, count) VALUES
+		('a', 202412, 80), ('b', 202412, 20),
+		('a', 202501, 50), ('b', 202501, 30), ('c', 202501, 20)`)
+
+	list, err := repo.FindAll(context.Background(), "", 202501, 202501, 10, 0, web.ListOptions{Sort: web.SortGrowth})
+	if err != nil {
+		t.Fatalf("FindAll error: %v", err)
+	}
+
+	for i, want := range []string{"c", "b", "a"} {
+		if list.Items[i].ID != want {
+			t.Errorf("item %d: expected %s, got %s", i, want, list.Items[i].ID)
+		}
+	}
+
+	// Over a range, the first and last month are compared
+	list, err = repo.FindAll(context.Background(), "", 202412, 202501, 10, 0, web.ListOptions{Sort: web.SortGrowth, Order: web.OrderAsc})
+	if err != nil {
+		t.Fatalf("FindAll error: %v", err)
+	}
+
+	if list.Items[0].ID != "a" {
+		t.Errorf("expected a to have the lowest growth, got %s", list.Items[0].ID)
+	}
+}
+
+func TestFindAll_MinCount(t *testing.T) {
+	repo, db := setupTestRepository(t, Config{Table: "test_entity", Column: "id"})
+
+	_, _ = db.Exec(`INSERT INTO test_entity (id, month, count) VALUES ('a', 202501, 3), ('b', 202501, 5), ('c', 202501, 30)`)
+
+	list, err := repo.FindAll(context.Background(), "", 202501, 202501, 10, 0, web.ListOptions{})
+	if err != nil {
+		t.Fatalf("FindAll error: %v", err)
+	}
+	if list.Total != 3 {
+		t.Errorf("expected all 3 items without minCount, got %d", list.Total)
+	}
+
+	list, err = repo.FindAll(context.Background(), "", 202501, 202501, 10, 0, web.ListOptions{MinCount: 5})
+	if err != nil {
+		t.Fatalf("FindAll error: %v", err)
+	}
+	if list.Total != 2 || list.Count != 2 {
+		t.Errorf("expected 2 items with minCount 5, got total %d, count %d", list.Total, list.Count)
+	}
+}
+
+func TestFindAll_Cursor(t *testing.T) {
+	for _, opts := range []web.ListOptions{
+		{Sort: web.SortPopularity, Order: web.OrderDesc},
+		{Sort: web.SortPopularity, Order: web.OrderAsc},
+		{Sort: web.SortName, Order: web.OrderAsc},
+		{Sort: web.SortName, Order: web.OrderDesc},
+	} {
+		t.Run(opts.Sort+"_"+opts.Order, func(t *testing.T) {
+			repo, db := setupTestRepository(t, Config{Table: "test_entity", Column: "id"})
+
+			_, _ = db.Exec(`INSERT INTO test_entity (id, month, count) VALUES
+				('a', 202501, 10), ('b', 202501, 20), ('c', 202501, 20),
+				('d', 202501, 30), ('e', 202501, 5)`)
+
+			all, err := repo.FindAll(context.Background(), "", 202501, 202501, 10, 0, opts)
+			if err != nil {
+				t.Fatalf("FindAll error: %v", err)
+			}
+
+			var paged []string
+			for range 10 {
+				list, err := repo.FindAll(context.Background(), "", 202501, 202501, 2, 0, opts)
+				if err != nil {
+					t.Fatalf("FindAll error: %v", err)
+				}
+				if list.Total != 5 {
+					t.Errorf("expected total 5, got %d", list.Total)
+				}
+				for _, item := range list.Items {
+					paged = append(paged, item.ID)
+				}
+				if list.Next == nil {
+					break
+				}
+				if opts.Cursor, err = web.DecodeCursor(*list.Next); err != nil {
+					t.Fatalf("decode cursor: %v", err)
+				}
+			}
+
+			if len(paged) != len(all.Items) {
+				t.Fatalf("expected %d paged items, got %v", len(all.Items), paged)
+			}
+			for i, item := range all.Items {
+				if paged[i] != item.ID {
+					t.Errorf("item %d: expected %s, got %s", i, item.ID, paged[i])
+				}
+			}
+		})
+	}
+}
+
+func TestFindAll_NoCursorForGrowth(t *testing.T) {
+	repo, db := setupTestRepository(t, Config{Table: "test_entity", Column: "id"})
+
+	_, _ = db.Exec(`INSERT INTO test_entity (id, month, count) VALUES ('a', 202501, 10), ('b', 202501, 20)`)
+
+	list, err := repo.FindAll(context.Background(), "", 202501, 202501, 1, 0, web.ListOptions{Sort: web.SortGrowth})
+	if err != nil {
+		t.Fatalf("FindAll error: %v", err)
+	}
+	if list.Next != nil {
+		t.Errorf("expected no next cursor, got %q", *list.Next)
+	}
+}
+
 func TestFindAll_WithQuery(t *testing.T) {
 	repo, db := setupTestRepository(t, Config{Table: "test_entity", Column: "id", QueryContains: false})
 
 	_, _ = db.Exec(`INSERT INTO test_entity (id, month, count) VALUES ('foo', 202501, 10), ('bar', 202501, 20)`)
 
-	list, err := repo.FindAll(context.Background(), "f", 202501, 202501, 10, 0)
+	list, err := repo.FindAll(context.Background(), "f", 202501, 202501, 10, 0, web.ListOptions{})
 	if err != nil {
 		t.Fatalf("FindAll error: %v", err)
 	}
@@ -129,12 +270,58 @@ func TestFindAll_WithQuery(t *testing.T) {
 	}
 }
 
+func TestFindAll_MatchOverridesDefault(t *testing.T) {
+	repo, db := setupTestRepository(t, Config{Table: "test_entity", Column: "id", QueryContains: true})
+
+	_, _ = db.Exec(`INSERT INTO test_entity (id, month, count) VALUES ('foobar', 202501, 10), ('barfoo', 202501, 20)`)
+
+	tests := []struct {
+		match string
+		query string
+		want  int
+	}{
+		{"", "foo", 2},
+		{web.MatchPrefix, "foo", 1},
+		{web.MatchGlob, "*foo", 1},
+		{web.MatchFuzzy, "foobr", 1},
+	}
+
+	for _, tt := range tests {
+		list, err := repo.FindAll(context.Background(), tt.query, 202501, 202501, 10, 0, web.ListOptions{Match: tt.match})
+		if err != nil {
+			t.Fatalf("FindAll error: %v", err)
+		}
+		if list.Total != tt.want {
+			t.Errorf("match %q query %q: expected total %d, got %d", tt.match, tt.query, tt.want, list.Total)
+		}
+	}
+}
+
+func TestTrigrams(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{"", nil},
+		{"ab", nil},
+		{"abc", []string{"abc"}},
+		{"Firefx", []string{"fir", "ire", "ref", "efx"}},
+		{"aaaa", []string{"aaa"}},
+	}
+
+	for _, tt := range tests {
+		if got := Trigrams(tt.input); !slices.Equal(got, tt.want) {
+			t.Errorf("Trigrams(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
+
 func TestFindAll_WithQueryContains(t *testing.T) {
 	repo, db := setupTestRepository(t, Config{Table: "test_entity", Column: "id", QueryContains: true})
 
 	_, _ = db.Exec(`INSERT INTO test_entity (id, month, count) VALUES ('foobar', 202501, 10), ('baz', 202501, 20)`)
 
-	list, err := repo.FindAll(context.Background(), "oba", 202501, 202501, 10, 0)
+	list, err := repo.FindAll(context.Background(), "oba", 202501, 202501, 10, 0, web.ListOptions{})
 	if err != nil {
 		t.Fatalf("FindAll error: %v", err)
 	}
@@ -152,7 +339,7 @@ func TestFindSeries(t *testing.T) {
 
 	_, _ = db.Exec(`INSERT INTO test_entity (id, month, count) VALUES ('a', 202501, 10), ('a', 202502, 20)`)
 
-	list, err := repo.FindSeries(context.Background(), "a", 202501, 202502, 10, 0)
+	list, err := repo.FindSeries(context.Background(), "a", 202501, 202502, 10, 0, web.SeriesOptions{})
 	if err != nil {
 		t.Fatalf("FindSeries error: %v", err)
 	}
@@ -165,6 +352,187 @@ func TestFindSeries(t *testing.T) {
 	}
 }
 
+func TestFindSeries_Granularity(t *testing.T) {
+	repo, db := setupTestRepository(t, Config{Table: "test_entity", Column: "id"})
+
+	_, _ = db.Exec(`INSERT INTO test_entity (id, month, count) VALUES
+		('a', 202411, 10), ('b', 202411, 30),
+		('a', 202412, 20), ('b', 202412, 20),
+		('a', 202501, 30), ('b', 202501, 10),
+		('a', 202504, 10), ('b', 202504, 10)`)
+
+	quarters, err := repo.FindSeries(context.Background(), "a", 202411, 202504, 10, 0, web.SeriesOptions{Granularity: web.GranularityQuarter})
+	if err != nil {
+		t.Fatalf("FindSeries error: %v", err)
+	}
+
+	if quarters.Total != 3 {
+		t.Fatalf("expected total 3, got %d", quarters.Total)
+	}
+
+	// Q4 2024 is clipped to the requested range and sums both months
+	q4 := quarters.Items[0]
+	if q4.StartMonth != 202411 || q4.EndMonth != 202412 {
+		t.Errorf("expected 202411-202412, got %d-%d", q4.StartMonth, q4.EndMonth)
+	}
+	if q4.Count != 30 || q4.Samples != 80 || q4.Popularity != 37.5 {
+		t.Errorf("expected 30/80 = 37.5%%, got %d/%d = %v%%", q4.Count, q4.Samples, q4.Popularity)
+	}
+	if quarters.Items[1].StartMonth != 202501 || quarters.Items[1].EndMonth != 202503 {
+		t.Errorf("expected 202501-202503, got %d-%d", quarters.Items[1].StartMonth, quarters.Items[1].EndMonth)
+	}
+
+	years, err := repo.FindSeries(context.Background(), "a", 0, 202504, 10, 0, web.SeriesOptions{Granularity: web.GranularityYear})
+	if err != nil {
+		t.Fatalf("FindSeries error: %v", err)
+	}
+
+	if years.Total != 2 {
+		t.Fatalf("expected total 2, got %d", years.Total)
+	}
+	if years.Items[0].StartMonth != 202401 || years.Items[0].EndMonth != 202412 {
+		t.Errorf("expected 202401-202412, got %d-%d", years.Items[0].StartMonth, years.Items[0].EndMonth)
+	}
+	if years.Items[1].Count != 40 || years.Items[1].Samples != 60 {
+		t.Errorf("expected 40/60 in 2025, got %d/%d", years.Items[1].Count, years.Items[1].Samples)
+	}
+}
+
+func TestFindSeries_Smoothing(t *testing.T) {
+	repo, db := setupTestRepository(t, Config{Table: "test_entity", Column: "id"})
+
+	_, _ = db.Exec(`INSERT INTO test_entity (id, month, count) VALUES
+		('a', 202501, 10), ('b', 202501, 90),
+		('a', 202502, 30), ('b', 202502, 70),
+		('a', 202503, 50), ('b', 202503, 50)`)
+
+	opts := web.SeriesOptions{Smoothing: web.Smoothing{Method: web.SmoothMovingAverage, Window: 2}}
+	list, err := repo.FindSeries(context.Background(), "a", 202501, 202503, 10, 0, opts)
+	if err != nil {
+		t.Fatalf("FindSeries error: %v", err)
+	}
+
+	if list.Smoothing != "ma2" {
+		t.Errorf("expected smoothing ma2, got %q", list.Smoothing)
+	}
+
+	expected := []float64{10, 20, 40}
+	for i, item := range list.Items {
+		if item.Popularity != expected[i] {
+			t.Errorf("item %d: expected popularity %v, got %v", i, expected[i], item.Popularity)
+		}
+	}
+	// Counts stay raw
+	if list.Items[1].Count != 30 {
+		t.Errorf("expected raw count 30, got %d", list.Items[1].Count)
+	}
+
+	// A later page still averages over the preceding point
+	page, err := repo.FindSeries(context.Background(), "a", 202501, 202503, 1, 2, opts)
+	if err != nil {
+		t.Fatalf("FindSeries error: %v", err)
+	}
+
+	if page.Count != 1 || page.Items[0].StartMonth != 202503 || page.Items[0].Popularity != 40 {
+		t.Errorf("expected 202503 at 40%%, got %+v", page.Items)
+	}
+}
+
+func TestFindSeries_SmoothingGaps(t *testing.T) {
+	repo, db := setupTestRepository(t, Config{Table: "test_entity", Column: "id"})
+
+	_, _ = db.Exec(`INSERT INTO test_entity (id, month, count) VALUES
+		('a', 202501, 10), ('b', 202501, 90),
+		('b', 202502, 100),
+		('a', 202503, 50), ('b', 202503, 50),
+		('a', 202504, 30), ('b', 202504, 70)`)
+
+	// The window covers two months, not two rows: 202503 has no preceding
+	// point within its window.
+	opts := web.SeriesOptions{Smoothing: web.Smoothing{Method: web.SmoothMovingAverage, Window: 2}}
+	list, err := repo.FindSeries(context.Background(), "a", 202501, 202504, 10, 0, opts)
+	if err != nil {
+		t.Fatalf("FindSeries error: %v", err)
+	}
+
+	expected := map[int]float64{202501: 10, 202503: 50, 202504: 40}
+	if len(list.Items) != len(expected) {
+		t.Fatalf("expected %d items, got %d", len(expected), len(list.Items))
+	}
+	for _, item := range list.Items {
+		if item.Popularity != expected[item.StartMonth] {
+			t.Errorf("%d: expected popularity %v, got %v", item.StartMonth, expected[item.StartMonth], item.Popularity)
+		}
+	}
+
+	// Pages count rows, so the second page starts at 202503.
+	page, err := repo.FindSeries(context.Background(), "a", 202501, 202504, 1, 1, opts)
+	if err != nil {
+		t.Fatalf("FindSeries error: %v", err)
+	}
+
+	if page.Count != 1 || page.Items[0].StartMonth != 202503 || page.Items[0].Popularity != 50 {
+		t.Errorf("expected 202503 at 50%%, got %+v", page.Items)
+	}
+}
+
+func TestWilsonInterval(t *testing.T) {
+	tests := []struct {
+		count   int
+		samples int
+		low     float64
+		high    float64
+	}{
+		{0, 10, 0, 27.75},
+		{1, 10, 1.79, 40.42},
+		{50, 100, 40.38, 59.62},
+		{10, 10, 72.25, 100},
+		{5000, 1000000, 0.49, 0.51},
+	}
+
+	for _, tt := range tests {
+		got := WilsonInterval(tt.count, tt.samples)
+		if got.Low != tt.low || got.High != tt.high {
+			t.Errorf("WilsonInterval(%d, %d) = [%v, %v], want [%v, %v]", tt.count, tt.samples, got.Low, got.High, tt.low, tt.high)
+		}
+	}
+
+	if WilsonInterval(0, 0) != nil {
+		t.Error("expected no interval without samples")
+	}
+}
+
+func TestFindByIdentifier_Interval(t *testing.T) {
+	repo, db := setupTestRepository(t, Config{Table: "test_entity", Column: "id"})
+
+	_, _ = db.Exec(`INSERT INTO test_entity (id, month, count) VALUES ('a', 202501, 1), ('b', 202501, 9)`)
+
+	item, err := repo.FindByIdentifier(context.Background(), "a", 202501, 202501)
+	if err != nil {
+		t.Fatalf("FindByIdentifier error: %v", err)
+	}
+
+	low, high, ok := item.Bounds()
+	if !ok || low != 1.79 || high != 40.42 {
+		t.Errorf("expected interval [1.79, 40.42], got [%v, %v] (ok=%v)", low, high, ok)
+	}
+
+	data, _ := json.Marshal(item)
+	if !strings.Contains(string(data), `"popularityLow":1.79,"popularityHigh":40.42`) {
+		t.Errorf("expected interval in JSON, got %s", data)
+	}
+
+	list, err := repo.FindAll(context.Background(), "", 202501, 202501, 10, 0, web.ListOptions{})
+	if err != nil {
+		t.Fatalf("FindAll error: %v", err)
+	}
+
+	data, _ = json.Marshal(list)
+	if !strings.Contains(string(data), `"popularity":10,"popularityLow":1.79,"popularityHigh":40.42`) {
+		t.Errorf("expected the interval in list entries, got %s", data)
+	}
+}
+
 func TestCalculatePopularity(t *testing.T) {
 	tests := []struct {
 		count    int
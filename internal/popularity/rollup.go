@@ -0,0 +1,115 @@
+package popularity
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"pkgstatsd/internal/database"
+	"pkgstatsd/internal/web"
+)
+
+// RollupTable returns the name of the yearly rollup table of a count table.
+func RollupTable(table string) string {
+	return table + "_year"
+}
+
+// RangeSource returns the FROM source for summing the counts of table over
+// the months startMonth to endMonth. Complete years of the range that have
+// been rolled up are read from the yearly rollup table, one row per
+// identifier and year, and all other months from table itself. The source is
+// aliased to table and keeps its columns, with rollup rows dated to the
+// first month of their year, so queries filtering the same month range and
+// referring to table work unchanged. Without rolled up years in the range
+// the source is just table.
+func RangeSource(ctx context.Context, db *sql.DB, table, column string, startMonth, endMonth int) (string, []any, error) {
+	years, err := rolledUpYears(ctx, db, table, startMonth, endMonth)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(years) == 0 {
+		return table, nil, nil
+	}
+
+	var ranges []string
+	var args []any
+	from := startMonth
+	for _, year := range years {
+		if before := web.JoinYearMonth(year-1, time.December); from <= before {
+			ranges = append(ranges, "month BETWEEN ? AND ?")
+			args = append(args, from, before)
+		}
+		from = web.JoinYearMonth(year+1, time.January)
+	}
+	if from <= endMonth {
+		ranges = append(ranges, "month BETWEEN ? AND ?")
+		args = append(args, from, endMonth)
+	}
+
+	placeholders := strings.Repeat(", ?", len(years))[2:]
+	//nolint:gosec
+	rollup := fmt.Sprintf(`SELECT %s, year * 100 + 1 AS month, count FROM %s WHERE year IN (%s)`,
+		column, RollupTable(table), placeholders,
+	)
+	for _, year := range years {
+		args = append(args, year)
+	}
+
+	if len(ranges) == 0 {
+		return `(` + rollup + `) AS ` + table, args, nil
+	}
+
+	//nolint:gosec
+	monthly := fmt.Sprintf(`SELECT %s, month, count FROM %s WHERE %s`,
+		column, table, strings.Join(ranges, " OR "),
+	)
+
+	return `(` + monthly + ` UNION ALL ` + rollup + `) AS ` + table, args, nil
+}
+
+// rolledUpYears returns the years completely inside startMonth to endMonth
+// whose rollup of table has been built, in ascending order.
+func rolledUpYears(ctx context.Context, db *sql.DB, table string, startMonth, endMonth int) ([]int, error) {
+	firstYear := 0
+	if startMonth != 0 {
+		var month time.Month
+		if firstYear, month = web.SplitYearMonth(startMonth); month != time.January {
+			firstYear++
+		}
+	}
+
+	lastYear, month := web.SplitYearMonth(endMonth)
+	if month != time.December {
+		lastYear--
+	}
+
+	if firstYear > lastYear {
+		return nil, nil
+	}
+
+	rows, err := db.QueryContext(ctx,
+		database.DialectOf(db).Rebind(`SELECT year FROM rollup WHERE name = ? AND year >= ? AND year <= ? ORDER BY year`),
+		table, firstYear, lastYear,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query %s rollups: %w", table, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var years []int
+	for rows.Next() {
+		var year int
+		if err := rows.Scan(&year); err != nil {
+			return nil, fmt.Errorf("scan %s rollup: %w", table, err)
+		}
+		years = append(years, year)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate %s rollups: %w", table, err)
+	}
+
+	return years, nil
+}
@@ -0,0 +1,80 @@
+package popularity
+
+import (
+	"context"
+	"testing"
+
+	"pkgstatsd/internal/web"
+)
+
+func TestFindAll_Rollup(t *testing.T) {
+	repo, db := setupTestRepository(t, Config{Table: "test_entity", Column: "id"})
+
+	_, _ = db.Exec(`CREATE TABLE test_entity_year (id TEXT, year INTEGER, count INTEGER)`)
+	_, _ = db.Exec(`INSERT INTO test_entity (id, month, count) VALUES
+		('a', 202312, 1), ('a', 202406, 10), ('a', 202501, 100),
+		('b', 202406, 20)`)
+	// The rollup deliberately disagrees with the monthly rows, so the test
+	// can tell which were read.
+	_, _ = db.Exec(`INSERT INTO test_entity_year (id, year, count) VALUES ('a', 2024, 1000), ('b', 2024, 2000)`)
+	_, _ = db.Exec(`INSERT INTO rollup (name, year) VALUES ('test_entity', 2024)`)
+
+	tests := []struct {
+		name                 string
+		startMonth, endMonth int
+		want                 map[string]int
+	}{
+		{"all time", 0, 202501, map[string]int{"a": 1101, "b": 2000}},
+		{"complete year", 202401, 202412, map[string]int{"a": 1000, "b": 2000}},
+		{"partial year", 202402, 202501, map[string]int{"a": 110, "b": 20}},
+		{"year not rolled up", 202301, 202312, map[string]int{"a": 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list, err := repo.FindAll(context.Background(), "", tt.startMonth, tt.endMonth, 10, 0, web.ListOptions{})
+			if err != nil {
+				t.Fatalf("FindAll error: %v", err)
+			}
+
+			if list.Total != len(tt.want) {
+				t.Errorf("expected total %d, got %d", len(tt.want), list.Total)
+			}
+			for _, item := range list.Items {
+				if item.Count != tt.want[item.ID] {
+					t.Errorf("expected count %d for %s, got %d", tt.want[item.ID], item.ID, item.Count)
+				}
+			}
+		})
+	}
+}
+
+func TestRangeSource(t *testing.T) {
+	_, db := setupTestRepository(t, Config{Table: "test_entity", Column: "id"})
+
+	source, args, err := RangeSource(context.Background(), db, "test_entity", "id", 202401, 202512)
+	if err != nil {
+		t.Fatalf("RangeSource error: %v", err)
+	}
+	if source != "test_entity" || args != nil {
+		t.Errorf("expected plain table without rollups, got %q %v", source, args)
+	}
+
+	_, _ = db.Exec(`INSERT INTO rollup (name, year) VALUES ('test_entity', 2022), ('test_entity', 2023), ('test_entity', 2025)`)
+
+	_, args, err = RangeSource(context.Background(), db, "test_entity", "id", 202107, 202602)
+	if err != nil {
+		t.Fatalf("RangeSource error: %v", err)
+	}
+
+	want := []any{202107, 202112, 202401, 202412, 202601, 202602, 2022, 2023, 2025}
+	if len(args) != len(want) {
+		t.Fatalf("expected args %v, got %v", want, args)
+	}
+	for i := range want {
+		if args[i] != want[i] {
+			t.Errorf("expected args %v, got %v", want, args)
+			break
+		}
+	}
+}
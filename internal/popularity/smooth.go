@@ -0,0 +1,52 @@
+package popularity
+
+import (
+	"math"
+
+	"pkgstatsd/internal/web"
+)
+
+// Smooth applies the smoothing to a series with one value per period. Null
+// values mark periods without data; they stay null and are skipped: the
+// moving average covers the non-null values among the trailing window, and
+// exponential smoothing carries its state across gaps.
+func Smooth(values []*float64, s web.Smoothing) []*float64 {
+	if s.Method == "" {
+		return values
+	}
+
+	smoothed := make([]*float64, len(values))
+	alpha := 2 / float64(s.Window+1)
+	var previous *float64
+
+	for i, v := range values {
+		if v == nil {
+			continue
+		}
+
+		var result float64
+		switch s.Method {
+		case web.SmoothExponential:
+			result = *v
+			if previous != nil {
+				result = alpha*(*v) + (1-alpha)*(*previous)
+			}
+			previous = &result
+		default:
+			var sum float64
+			var n int
+			for _, w := range values[max(0, i-s.Window+1) : i+1] {
+				if w != nil {
+					sum += *w
+					n++
+				}
+			}
+			result = sum / float64(n)
+		}
+
+		rounded := math.Round(result*popularityPrecision) / popularityPrecision
+		smoothed[i] = &rounded
+	}
+
+	return smoothed
+}
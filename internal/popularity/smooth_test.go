@@ -0,0 +1,46 @@
+package popularity
+
+import (
+	"slices"
+	"testing"
+
+	"pkgstatsd/internal/web"
+)
+
+func TestSmooth(t *testing.T) {
+	values := []*float64{new(10.0), new(20.0), new(30.0), nil, new(0.0)}
+
+	tests := []struct {
+		name      string
+		smoothing web.Smoothing
+		want      []*float64
+	}{
+		{"none", web.Smoothing{}, values},
+		{"moving average", web.Smoothing{Method: web.SmoothMovingAverage, Window: 3}, []*float64{new(10.0), new(15.0), new(20.0), nil, new(15.0)}},
+		// alpha = 2/(3+1) = 0.5
+		{"exponential", web.Smoothing{Method: web.SmoothExponential, Window: 3}, []*float64{new(10.0), new(15.0), new(22.5), nil, new(11.25)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Smooth(values, tt.smoothing)
+			if !equalSeries(got, tt.want) {
+				t.Errorf("got %v, want %v", derefSeries(got), derefSeries(tt.want))
+			}
+		})
+	}
+}
+
+func equalSeries(a, b []*float64) bool {
+	return slices.EqualFunc(a, b, func(x, y *float64) bool {
+		return (x == nil && y == nil) || (x != nil && y != nil && *x == *y)
+	})
+}
+
+func derefSeries(series []*float64) []any {
+	out := make([]any, len(series))
+	for i, v := range series {
+		out[i] = deref(v)
+	}
+	return out
+}
@@ -0,0 +1,180 @@
+package rollup
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"pkgstatsd/internal/config"
+	"pkgstatsd/internal/database"
+	"pkgstatsd/internal/popularity"
+	"pkgstatsd/internal/web"
+)
+
+// countTables lists the count tables and their identifier columns.
+var countTables = []struct {
+	table  string
+	column string
+}{
+	{"package", "name_id"},
+	{"country", "code"},
+	{"mirror", "url"},
+	{"system_architecture", "name"},
+	{"operating_system_architecture", "name"},
+	{"operating_system_id", "id"},
+}
+
+// Run executes the rollup subcommand. It builds the yearly rollups of every
+// complete year that has not been rolled up yet, or rebuilds the year given
+// with --year, and returns the process exit code. Meant to be run by an
+// external scheduler after a month closes; it only has work to do once a
+// year is complete.
+func Run(args []string, cfg config.Config) int {
+	fs := flag.NewFlagSet("rollup", flag.ExitOnError)
+	yearFlag := fs.Int("year", 0, "Complete year to rebuild (YYYY format, defaults to all years not rolled up yet)")
+	_ = fs.Parse(args)
+
+	db, err := database.Connect(cfg.Database)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	defer func() { _ = db.Close() }()
+
+	ctx := context.Background()
+
+	// Migrations are left to the server and the migrate subcommand.
+	if err := database.CheckMigrations(ctx, db); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	lastYear := LastCompleteYear()
+
+	years := []int{*yearFlag}
+	if *yearFlag == 0 {
+		if years, err = PendingYears(ctx, db, lastYear); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+	} else if *yearFlag > lastYear {
+		fmt.Fprintf(os.Stderr, "Error: year %d is not complete yet\n", *yearFlag)
+		return 1
+	}
+
+	for _, year := range years {
+		if err := rollupYear(ctx, db, year); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		fmt.Printf("Rolled up %d.\n", year)
+	}
+
+	if len(years) == 0 {
+		fmt.Println("All complete years are rolled up.")
+	}
+
+	return 0
+}
+
+// LastCompleteYear returns the last year whose twelve months have all
+// closed.
+func LastCompleteYear() int {
+	year, month := web.SplitYearMonth(web.GetLastCompleteMonth())
+	if month != time.December {
+		year--
+	}
+
+	return year
+}
+
+// PendingYears returns the years up to lastYear that have data but are not
+// rolled up for every count table.
+func PendingYears(ctx context.Context, db *sql.DB, lastYear int) ([]int, error) {
+	dialect := database.DialectOf(db)
+
+	var firstMonth sql.NullInt64
+	if err := db.QueryRowContext(ctx, `SELECT MIN(month) FROM package`).Scan(&firstMonth); err != nil {
+		return nil, fmt.Errorf("query first month: %w", err)
+	}
+	if !firstMonth.Valid {
+		return nil, nil
+	}
+
+	firstYear, _ := web.SplitYearMonth(int(firstMonth.Int64))
+
+	var years []int
+	for year := firstYear; year <= lastYear; year++ {
+		var rolledUp int
+		if err := db.QueryRowContext(ctx, dialect.Rebind(`SELECT COUNT(*) FROM rollup WHERE year = ?`), year).Scan(&rolledUp); err != nil {
+			return nil, fmt.Errorf("query rollups of %d: %w", year, err)
+		}
+
+		if rolledUp < len(countTables) {
+			years = append(years, year)
+		}
+	}
+
+	return years, nil
+}
+
+// rollupYear rolls up year in a transaction of its own.
+func rollupYear(ctx context.Context, db *sql.DB, year int) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err := Rollup(ctx, tx, database.DialectOf(db), year); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// Rollup sums the counts of every count table over the twelve months of year
+// into the yearly rollup tables and marks the year as rolled up, replacing
+// any earlier rollup of the year. Everything is written in tx, so queries
+// never see a partial rollup, and the caller can rebuild a rollup in the
+// transaction that changed the monthly counts.
+func Rollup(ctx context.Context, tx *sql.Tx, dialect database.Dialect, year int) error {
+	startMonth, endMonth := web.JoinYearMonth(year, time.January), web.JoinYearMonth(year, time.December)
+
+	for _, t := range countTables {
+		rollupTable := popularity.RollupTable(t.table)
+
+		//nolint:gosec
+		if _, err := tx.ExecContext(ctx, dialect.Rebind(`DELETE FROM `+rollupTable+` WHERE year = ?`), year); err != nil {
+			return fmt.Errorf("clear %s: %w", rollupTable, err)
+		}
+
+		//nolint:gosec
+		query := fmt.Sprintf(`
+			INSERT INTO %[1]s (%[2]s, year, count)
+			SELECT %[2]s, CAST(? AS INTEGER), SUM(count) FROM %[3]s
+			WHERE month >= ? AND month <= ?
+			GROUP BY %[2]s`,
+			rollupTable, t.column, t.table,
+		)
+		if _, err := tx.ExecContext(ctx, dialect.Rebind(query), year, startMonth, endMonth); err != nil {
+			return fmt.Errorf("fill %s: %w", rollupTable, err)
+		}
+
+		if _, err := tx.ExecContext(ctx,
+			dialect.Rebind(`INSERT INTO rollup (name, year) VALUES (?, ?) ON CONFLICT (name, year) DO NOTHING`),
+			t.table, year,
+		); err != nil {
+			return fmt.Errorf("mark %s rolled up: %w", rollupTable, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,84 @@
+package rollup
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"pkgstatsd/internal/database"
+)
+
+func TestRollup(t *testing.T) {
+	db, err := database.New(":memory:")
+	if err != nil {
+		t.Fatalf("create database: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	_, _ = db.Exec(`INSERT INTO package_name (id, name) VALUES (1, 'pacman'), (2, 'linux'); INSERT INTO package (name_id, month, count) VALUES
+		(1, 202312, 1), (1, 202401, 10), (1, 202412, 20), (1, 202501, 100),
+		(2, 202406, 5)`)
+	_, _ = db.Exec(`INSERT INTO country (code, month, count) VALUES ('DE', 202401, 3), ('DE', 202402, 4)`)
+
+	years, err := PendingYears(context.Background(), db, 2024)
+	if err != nil {
+		t.Fatalf("PendingYears error: %v", err)
+	}
+	if !slices.Equal(years, []int{2023, 2024}) {
+		t.Errorf("expected pending years [2023 2024], got %v", years)
+	}
+
+	if err := rollupYear(context.Background(), db, 2024); err != nil {
+		t.Fatalf("Rollup error: %v", err)
+	}
+	// Rolling up again replaces the earlier rollup.
+	if err := rollupYear(context.Background(), db, 2024); err != nil {
+		t.Fatalf("Rollup error: %v", err)
+	}
+
+	var count int
+	if err := db.QueryRow(`SELECT count FROM package_year WHERE name_id = 1 AND year = 2024`).Scan(&count); err != nil {
+		t.Fatalf("query package rollup: %v", err)
+	}
+	if count != 30 {
+		t.Errorf("expected pacman count 30, got %d", count)
+	}
+
+	if err := db.QueryRow(`SELECT count FROM country_year WHERE code = 'DE' AND year = 2024`).Scan(&count); err != nil {
+		t.Fatalf("query country rollup: %v", err)
+	}
+	if count != 7 {
+		t.Errorf("expected DE count 7, got %d", count)
+	}
+
+	if err := db.QueryRow(`SELECT COUNT(*) FROM rollup WHERE year = 2024`).Scan(&count); err != nil {
+		t.Fatalf("query rollups: %v", err)
+	}
+	if count != len(countTables) {
+		t.Errorf("expected %d rolled up tables, got %d", len(countTables), count)
+	}
+
+	years, err = PendingYears(context.Background(), db, 2024)
+	if err != nil {
+		t.Fatalf("PendingYears error: %v", err)
+	}
+	if !slices.Equal(years, []int{2023}) {
+		t.Errorf("expected pending years [2023], got %v", years)
+	}
+}
+
+func TestPendingYears_Empty(t *testing.T) {
+	db, err := database.New(":memory:")
+	if err != nil {
+		t.Fatalf("create database: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	years, err := PendingYears(context.Background(), db, 2024)
+	if err != nil {
+		t.Fatalf("PendingYears error: %v", err)
+	}
+	if len(years) != 0 {
+		t.Errorf("expected no pending years, got %v", years)
+	}
+}
@@ -67,7 +67,7 @@ func (h *Handler) HandleSitemap(w http.ResponseWriter, r *http.Request) {
 		urls = append(urls, URL{Loc: baseURL + "/fun/" + url.PathEscape(category.Name) + "/history", LastMod: lastMod})
 	}
 
-	countryList, err := h.countryRepo.FindAll(r.Context(), "", currentMonth, currentMonth, countryLimit, 0)
+	countryList, err := h.countryRepo.FindAll(r.Context(), "", currentMonth, currentMonth, countryLimit, 0, web.ListOptions{})
 	if err != nil {
 		if web.IsClientDisconnect(err) {
 			return
@@ -79,7 +79,7 @@ func (h *Handler) HandleSitemap(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	packageList, err := h.packageRepo.FindAll(r.Context(), "", currentMonth, currentMonth, packageLimit, 0)
+	packageList, err := h.packageRepo.FindAll(r.Context(), "", currentMonth, currentMonth, packageLimit, 0, web.ListOptions{})
 	if err != nil {
 		if web.IsClientDisconnect(err) {
 			return
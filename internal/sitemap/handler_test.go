@@ -10,6 +10,7 @@ import (
 
 	"pkgstatsd/internal/countries"
 	"pkgstatsd/internal/packages"
+	"pkgstatsd/internal/popularity"
 	"pkgstatsd/internal/web"
 )
 
@@ -29,11 +30,31 @@ func (m *mockPackageRepo) FindByName(_ context.Context, _ string, _, _ int) (*pa
 	return nil, nil
 }
 
-func (m *mockPackageRepo) FindSeriesByName(_ context.Context, _ string, _, _, _, _ int) (*packages.PackagePopularityList, error) {
+func (m *mockPackageRepo) FindSeriesByName(_ context.Context, _ string, _, _, _, _ int, _ web.SeriesOptions) (*packages.PackagePopularityList, error) {
 	return nil, nil
 }
 
-func (m *mockPackageRepo) FindAll(_ context.Context, _ string, _, _, _, _ int) (*packages.PackagePopularityList, error) {
+func (m *mockPackageRepo) FindNew(_ context.Context, _, _, _ int) (*packages.PackagePopularityList, error) {
+	return nil, nil
+}
+
+func (m *mockPackageRepo) FindGone(_ context.Context, _, _, _ int) (*packages.GonePackageList, error) {
+	return nil, nil
+}
+
+func (m *mockPackageRepo) Suggest(_ context.Context, _ string, _ int) (*packages.PackageSuggestions, error) {
+	return nil, nil
+}
+
+func (m *mockPackageRepo) FindForecastByName(_ context.Context, _ string, _ int) (*packages.PackageForecast, error) {
+	return nil, nil
+}
+
+func (m *mockPackageRepo) FindDiff(_ context.Context, _, _, _, _ int) (*popularity.DiffList, error) {
+	return nil, nil
+}
+
+func (m *mockPackageRepo) FindAll(_ context.Context, _ string, _, _, _, _ int, _ web.ListOptions) (*packages.PackagePopularityList, error) {
 	return &packages.PackagePopularityList{
 		PackagePopularities: []packages.PackagePopularity{
 			{Name: "linux"},
@@ -46,11 +67,11 @@ func (m *mockCountryRepo) FindByCode(_ context.Context, _ string, _, _ int) (*co
 	return nil, nil
 }
 
-func (m *mockCountryRepo) FindSeriesByCode(_ context.Context, _ string, _, _, _, _ int) (*countries.CountryPopularityList, error) {
+func (m *mockCountryRepo) FindSeriesByCode(_ context.Context, _ string, _, _, _, _ int, _ web.SeriesOptions) (*countries.CountryPopularityList, error) {
 	return nil, nil
 }
 
-func (m *mockCountryRepo) FindAll(_ context.Context, _ string, _, _, _, _ int) (*countries.CountryPopularityList, error) {
+func (m *mockCountryRepo) FindAll(_ context.Context, _ string, _, _, _, _ int, _ web.ListOptions) (*countries.CountryPopularityList, error) {
 	return &countries.CountryPopularityList{
 		CountryPopularities: []countries.CountryPopularity{
 			{Code: "DE"},
@@ -114,11 +135,31 @@ func (m *errorPackageRepo) FindByName(_ context.Context, _ string, _, _ int) (*p
 	return nil, m.err
 }
 
-func (m *errorPackageRepo) FindSeriesByName(_ context.Context, _ string, _, _, _, _ int) (*packages.PackagePopularityList, error) {
+func (m *errorPackageRepo) FindSeriesByName(_ context.Context, _ string, _, _, _, _ int, _ web.SeriesOptions) (*packages.PackagePopularityList, error) {
+	return nil, m.err
+}
+
+func (m *errorPackageRepo) FindNew(_ context.Context, _, _, _ int) (*packages.PackagePopularityList, error) {
+	return nil, m.err
+}
+
+func (m *errorPackageRepo) FindGone(_ context.Context, _, _, _ int) (*packages.GonePackageList, error) {
+	return nil, m.err
+}
+
+func (m *errorPackageRepo) Suggest(_ context.Context, _ string, _ int) (*packages.PackageSuggestions, error) {
+	return nil, m.err
+}
+
+func (m *errorPackageRepo) FindForecastByName(_ context.Context, _ string, _ int) (*packages.PackageForecast, error) {
+	return nil, m.err
+}
+
+func (m *errorPackageRepo) FindDiff(_ context.Context, _, _, _, _ int) (*popularity.DiffList, error) {
 	return nil, m.err
 }
 
-func (m *errorPackageRepo) FindAll(_ context.Context, _ string, _, _, _, _ int) (*packages.PackagePopularityList, error) {
+func (m *errorPackageRepo) FindAll(_ context.Context, _ string, _, _, _, _ int, _ web.ListOptions) (*packages.PackagePopularityList, error) {
 	return nil, m.err
 }
 
@@ -126,11 +167,11 @@ func (m *errorCountryRepo) FindByCode(_ context.Context, _ string, _, _ int) (*c
 	return nil, m.err
 }
 
-func (m *errorCountryRepo) FindSeriesByCode(_ context.Context, _ string, _, _, _, _ int) (*countries.CountryPopularityList, error) {
+func (m *errorCountryRepo) FindSeriesByCode(_ context.Context, _ string, _, _, _, _ int, _ web.SeriesOptions) (*countries.CountryPopularityList, error) {
 	return nil, m.err
 }
 
-func (m *errorCountryRepo) FindAll(_ context.Context, _ string, _, _, _, _ int) (*countries.CountryPopularityList, error) {
+func (m *errorCountryRepo) FindAll(_ context.Context, _ string, _, _, _, _ int, _ web.ListOptions) (*countries.CountryPopularityList, error) {
 	return nil, m.err
 }
 
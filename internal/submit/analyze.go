@@ -66,15 +66,17 @@ func validMonth(month int) bool {
 }
 
 func findMaterialReplays(ctx context.Context, db *sql.DB, month int) ([]replayGroup, int, error) {
+	dialect := database.DialectOf(db)
+
 	var total int
 	if err := db.QueryRowContext(ctx,
-		`SELECT COALESCE(SUM(count), 0) FROM package WHERE month = ?`, month,
+		dialect.Rebind(`SELECT COALESCE(SUM(count), 0) FROM package WHERE month = ?`), month,
 	).Scan(&total); err != nil {
 		return nil, 0, fmt.Errorf("query aggregate package observations: %w", err)
 	}
 
 	rows, err := db.QueryContext(ctx,
-		`SELECT ip, payload_hash, payload FROM submission_log WHERE month = ?`, month,
+		dialect.Rebind(`SELECT ip, payload_hash, payload FROM submission_log WHERE month = ?`), month,
 	)
 	if err != nil {
 		return nil, 0, fmt.Errorf("query submission log: %w", err)
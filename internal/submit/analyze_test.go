@@ -25,7 +25,7 @@ func TestFindMaterialReplays(t *testing.T) {
 		t.Fatalf("marshal payload: %v", err)
 	}
 
-	_, err = db.Exec(`INSERT INTO package (name, month, count) VALUES ('base', 202607, 100000)`)
+	_, err = db.Exec(`INSERT INTO package_name (id, name) VALUES (1, 'base'); INSERT INTO package (name_id, month, count) VALUES (1, 202607, 100000)`)
 	if err != nil {
 		t.Fatalf("insert aggregate count: %v", err)
 	}
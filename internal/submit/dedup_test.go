@@ -18,7 +18,7 @@ func TestSaveSubmission_CountsFingerprintAfterExpiry(t *testing.T) {
 	t.Cleanup(func() { _ = db.Close() })
 
 	now := time.Date(2026, time.July, 1, 12, 0, 0, 0, time.UTC)
-	repo := NewRepository(db)
+	repo := NewRepository(db, nil)
 	repo.now = func() time.Time { return now }
 	req := &Request{
 		System: SystemInfo{Architecture: "x86_64"},
@@ -39,7 +39,7 @@ func TestSaveSubmission_CountsFingerprintAfterExpiry(t *testing.T) {
 	}
 
 	var count int
-	if err := db.QueryRow(`SELECT count FROM package WHERE name = 'pacman'`).Scan(&count); err != nil {
+	if err := db.QueryRow(`SELECT count FROM package JOIN package_name ON package_name.id = package.name_id WHERE name = 'pacman'`).Scan(&count); err != nil {
 		t.Fatalf("read package count: %v", err)
 	}
 	if count != 2 {
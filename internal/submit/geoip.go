@@ -1,12 +1,21 @@
 package submit
 
 import (
+	"context"
+	"errors"
 	"log/slog"
 	"net/netip"
 
 	"github.com/oschwald/maxminddb-golang/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
 )
 
+var geoIPLookupFailures = promauto.NewCounter(prometheus.CounterOpts{
+	Name: "pkgstatsd_geoip_lookup_failures_total",
+	Help: "GeoIP lookups that failed, leaving the submission without a country.",
+})
+
 type GeoIPLookup interface {
 	GetCountryCode(ip netip.Addr) string
 	Close() error
@@ -30,10 +39,19 @@ func NewMaxMindGeoIP(dbPath string) (*MaxMindGeoIP, error) {
 	return &MaxMindGeoIP{reader: reader}, nil
 }
 
+// Check reports an error if no GeoIP database is loaded.
+func (g *MaxMindGeoIP) Check(_ context.Context) error {
+	if g.reader == nil {
+		return errors.New("geoip database not loaded")
+	}
+	return nil
+}
+
 func (g *MaxMindGeoIP) GetCountryCode(ip netip.Addr) string {
 	var record countryRecord
 	if err := g.reader.Lookup(ip).Decode(&record); err != nil {
 		slog.Warn("geoip lookup failed", "ip", ip, "error", err)
+		geoIPLookupFailures.Inc()
 		return ""
 	}
 	return record.Country.ISOCode
@@ -8,6 +8,9 @@ import (
 	"net/netip"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
 	"pkgstatsd/internal/web"
 )
 
@@ -16,6 +19,32 @@ const (
 	maxRequestBodySize = 5 << 20 // 5 MB
 )
 
+// Submission results and the reasons for rejected and failed submissions,
+// as recorded in the submissions metric.
+const (
+	resultAccepted     = "accepted"
+	resultDeduplicated = "deduplicated"
+	resultRejected     = "rejected"
+	resultFailed       = "failed"
+
+	reasonReadBody        = "read_body"
+	reasonInvalidRequest  = "invalid_request"
+	reasonMissingPackages = "missing_expected_packages"
+	reasonRateLimitCheck  = "rate_limit_check"
+	reasonSave            = "save"
+)
+
+var (
+	submissions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "pkgstatsd_submissions_total",
+		Help: "Submissions by result, with the reason for rejected and failed ones. Rate limited requests are counted separately.",
+	}, []string{"result", "reason"})
+	rateLimitDenials = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "pkgstatsd_rate_limit_denials_total",
+		Help: "Submissions denied by the rate limiter.",
+	})
+)
+
 type Handler struct {
 	repo             *Repository
 	geoip            GeoIPLookup
@@ -40,11 +69,13 @@ func (h *Handler) HandleSubmit(w http.ResponseWriter, r *http.Request) {
 	anonymizedIP := AnonymizeIP(clientIP)
 	allowed, retryAfter, err := h.limiter.Allow(r.Context(), anonymizedIP)
 	if err != nil {
-		web.ServerError(w, "rate limit check failed", err)
+		submissions.WithLabelValues(resultFailed, reasonRateLimitCheck).Inc()
+		web.ServerError(w, r, "rate limit check failed", err)
 		return
 	}
 
 	if !allowed {
+		rateLimitDenials.Inc()
 		retrySeconds := max(1, int(time.Until(retryAfter).Seconds()))
 		web.TooManyRequests(w,
 			fmt.Sprintf("Rate limit exceeded. Retry after %s.", retryAfter.Format(time.RFC3339)),
@@ -56,17 +87,20 @@ func (h *Handler) HandleSubmit(w http.ResponseWriter, r *http.Request) {
 	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBodySize)
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
+		submissions.WithLabelValues(resultRejected, reasonReadBody).Inc()
 		web.BadRequest(w, err.Error())
 		return
 	}
 
 	req, err := ParseRequest(bytes.NewReader(body))
 	if err != nil {
+		submissions.WithLabelValues(resultRejected, reasonInvalidRequest).Inc()
 		web.BadRequest(w, err.Error())
 		return
 	}
 
 	if err := ValidateExpectedPackages(req.Pacman.Packages, h.expectedPackages, h.maxMissing); err != nil {
+		submissions.WithLabelValues(resultRejected, reasonMissingPackages).Inc()
 		web.BadRequest(w, err.Error())
 		return
 	}
@@ -81,10 +115,12 @@ func (h *Handler) HandleSubmit(w http.ResponseWriter, r *http.Request) {
 
 	counted, err := h.repo.SaveSubmission(r.Context(), req, mirrorURL, logEntry)
 	if err != nil {
-		web.ServerError(w, "failed to save submission", err)
+		submissions.WithLabelValues(resultFailed, reasonSave).Inc()
+		web.ServerError(w, r, "failed to save submission", err)
 		return
 	}
 	if !counted {
+		submissions.WithLabelValues(resultDeduplicated, "").Inc()
 		// A deduplicated retry is an idempotent success, so returning 204 stops
 		// the client's failure backoff without counting the submission again.
 		w.Header().Set("Cache-Control", "no-store")
@@ -92,6 +128,7 @@ func (h *Handler) HandleSubmit(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	submissions.WithLabelValues(resultAccepted, "").Inc()
 	w.Header().Set("Cache-Control", "no-store")
 	w.WriteHeader(http.StatusNoContent)
 }
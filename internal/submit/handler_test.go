@@ -11,6 +11,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
 	"pkgstatsd/internal/database"
 )
 
@@ -35,7 +37,7 @@ func setupTestHandler(t *testing.T) (*Handler, *sql.DB) {
 	}
 	t.Cleanup(func() { _ = db.Close() })
 
-	repo := NewRepository(db)
+	repo := NewRepository(db, nil)
 	geoip := &mockGeoIP{code: "DE"}
 	handler := NewHandler(repo, geoip, NoopRateLimiter{}, []string{"pkgstats", "pacman"})
 
@@ -143,14 +145,14 @@ func TestHandleSubmit_DeduplicatesIdenticalRequest(t *testing.T) {
 
 	// A retry has the same successful response but does not change aggregates.
 	var count int
-	_ = db.QueryRow("SELECT count FROM package WHERE name = 'pacman'").Scan(&count)
+	_ = db.QueryRow("SELECT count FROM package JOIN package_name ON package_name.id = package.name_id WHERE name = 'pacman'").Scan(&count)
 	if count != 1 {
 		t.Errorf("expected package count 1, got %d", count)
 	}
 
 	// Verify single row (not duplicate)
 	var rows int
-	_ = db.QueryRow("SELECT COUNT(*) FROM package WHERE name = 'pacman'").Scan(&rows)
+	_ = db.QueryRow("SELECT COUNT(*) FROM package JOIN package_name ON package_name.id = package.name_id WHERE name = 'pacman'").Scan(&rows)
 	if rows != 1 {
 		t.Errorf("expected 1 package row, got %d", rows)
 	}
@@ -180,7 +182,7 @@ func TestHandleSubmit_DeduplicationIncludesIPAndUserAgent(t *testing.T) {
 	}
 
 	var count int
-	_ = db.QueryRow("SELECT count FROM package WHERE name = 'pacman'").Scan(&count)
+	_ = db.QueryRow("SELECT count FROM package JOIN package_name ON package_name.id = package.name_id WHERE name = 'pacman'").Scan(&count)
 	if count != 3 {
 		t.Errorf("expected all distinct fingerprints to count, got %d", count)
 	}
@@ -193,7 +195,7 @@ func TestHandleSubmit_RateLimited(t *testing.T) {
 	}
 	t.Cleanup(func() { _ = db.Close() })
 
-	repo := NewRepository(db)
+	repo := NewRepository(db, nil)
 	geoip := &mockGeoIP{code: "DE"}
 	limiter := NewInMemoryRateLimiter()
 	limiter.limit = 2
@@ -212,10 +214,14 @@ func TestHandleSubmit_RateLimited(t *testing.T) {
 	}
 
 	// Third should be rate limited
+	denials := testutil.ToFloat64(rateLimitDenials)
 	w3 := submitRequest(handler, body)
 	if w3.Code != http.StatusTooManyRequests {
 		t.Errorf("request 3: expected 429, got %d", w3.Code)
 	}
+	if got := testutil.ToFloat64(rateLimitDenials) - denials; got != 1 {
+		t.Errorf("expected 1 rate limit denial, got %v", got)
+	}
 }
 
 func TestHandleSubmit_LocalMirrorIgnored(t *testing.T) {
@@ -366,7 +372,7 @@ func TestHandleSubmit_NoCountryWhenGeoIPEmpty(t *testing.T) {
 	}
 	t.Cleanup(func() { _ = db.Close() })
 
-	repo := NewRepository(db)
+	repo := NewRepository(db, nil)
 	geoip := &mockGeoIP{code: ""}
 	handler := NewHandler(repo, geoip, NoopRateLimiter{}, []string{"pkgstats", "pacman"})
 
@@ -454,7 +460,7 @@ func TestHandleSubmit_RateLimitError(t *testing.T) {
 	}
 	t.Cleanup(func() { _ = db.Close() })
 
-	repo := NewRepository(db)
+	repo := NewRepository(db, nil)
 	geoip := &mockGeoIP{code: "DE"}
 	limiter := &errorRateLimiter{}
 	handler := NewHandler(repo, geoip, limiter, []string{"pkgstats", "pacman"})
@@ -734,3 +740,25 @@ func TestHandleSubmit_MethodNotAllowed(t *testing.T) {
 		t.Errorf("expected 405, got %d", w.Code)
 	}
 }
+
+func TestHandleSubmit_Metrics(t *testing.T) {
+	handler, _ := setupTestHandler(t)
+
+	accepted := testutil.ToFloat64(submissions.WithLabelValues(resultAccepted, ""))
+	deduplicated := testutil.ToFloat64(submissions.WithLabelValues(resultDeduplicated, ""))
+	invalid := testutil.ToFloat64(submissions.WithLabelValues(resultRejected, reasonInvalidRequest))
+
+	submitRequest(handler, validRequestBody())
+	submitRequest(handler, validRequestBody())
+	submitRequest(handler, "{invalid")
+
+	if got := testutil.ToFloat64(submissions.WithLabelValues(resultAccepted, "")) - accepted; got != 1 {
+		t.Errorf("expected 1 accepted submission, got %v", got)
+	}
+	if got := testutil.ToFloat64(submissions.WithLabelValues(resultDeduplicated, "")) - deduplicated; got != 1 {
+		t.Errorf("expected 1 deduplicated submission, got %v", got)
+	}
+	if got := testutil.ToFloat64(submissions.WithLabelValues(resultRejected, reasonInvalidRequest)) - invalid; got != 1 {
+		t.Errorf("expected 1 invalid submission, got %v", got)
+	}
+}
@@ -164,7 +164,7 @@ func TestPrune(t *testing.T) {
 		t.Fatalf("expected 2 rows before prune, got %d", before)
 	}
 
-	deleted, err := NewRepository(db).PruneLog(context.Background())
+	deleted, err := NewRepository(db, nil).PruneLog(context.Background())
 	if err != nil {
 		t.Fatalf("prune failed: %v", err)
 	}
@@ -0,0 +1,90 @@
+package submit
+
+import (
+	"context"
+	"net/http"
+	"net/netip"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"pkgstatsd/internal/database"
+)
+
+// TestSaveSubmission_Postgres runs against the server in POSTGRES_TEST_URL
+// and is skipped without it.
+func TestSaveSubmission_Postgres(t *testing.T) {
+	dsn := os.Getenv("POSTGRES_TEST_URL")
+	if dsn == "" {
+		t.Skip("POSTGRES_TEST_URL not set")
+	}
+
+	db, err := database.New(dsn)
+	if err != nil {
+		t.Fatalf("create database: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	for _, table := range []string{"package", "country", "mirror", "system_architecture", "operating_system_architecture", "operating_system_id", "submission_log"} {
+		if _, err := db.Exec(`DELETE FROM ` + table + ` WHERE month = 202607`); err != nil {
+			t.Fatalf("clear %s: %v", table, err)
+		}
+	}
+	if _, err := db.Exec(`TRUNCATE rate_limit, submission_dedup`); err != nil {
+		t.Fatalf("truncate tables: %v", err)
+	}
+
+	now := time.Date(2026, time.July, 1, 12, 0, 0, 0, time.UTC)
+	repo := NewRepository(db, nil)
+	repo.now = func() time.Time { return now }
+
+	req := &Request{
+		System: SystemInfo{Architecture: "x86_64"},
+		OS:     OSInfo{Architecture: "x86_64", ID: "arch"},
+		Pacman: PacmanInfo{Packages: []string{"pkgstats", "pacman"}},
+	}
+
+	for i, ip := range []string{"203.0.113.50", "203.0.113.51"} {
+		entry := NewLogEntry(http.Header{"User-Agent": {"pkgstats/3.5.4"}}, netip.MustParseAddr(ip), []byte(`{"packages":"stable"}`), "DE")
+		counted, err := repo.SaveSubmission(context.Background(), req, "https://mirror.example/", entry)
+		if err != nil || !counted {
+			t.Fatalf("submission %d: counted=%v err=%v", i, counted, err)
+		}
+	}
+
+	var count int
+	if err := db.QueryRow(`SELECT count FROM package JOIN package_name ON package_name.id = package.name_id WHERE name = 'pacman' AND month = 202607`).Scan(&count); err != nil {
+		t.Fatalf("query package count: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("expected pacman count 2, got %d", count)
+	}
+
+	limiter := NewSQLRateLimiter(db)
+	allowed, _, err := limiter.Allow(context.Background(), "203.0.113.0")
+	if err != nil || !allowed {
+		t.Fatalf("first request: allowed=%v err=%v", allowed, err)
+	}
+
+	// Concurrent requests of a key never exceed the limit.
+	limiter.limit = 5
+	var wg sync.WaitGroup
+	var granted atomic.Int32
+	for range 20 {
+		wg.Go(func() {
+			allowed, _, err := limiter.Allow(context.Background(), "198.51.100.0")
+			if err != nil {
+				t.Errorf("concurrent request: %v", err)
+			}
+			if allowed {
+				granted.Add(1)
+			}
+		})
+	}
+	wg.Wait()
+	if n := granted.Load(); n != 5 {
+		t.Errorf("expected 5 concurrent requests to be allowed, got %d", n)
+	}
+}
@@ -21,7 +21,7 @@ func RunPruneLog(_ []string, cfg config.Config) int {
 	}
 	defer func() { _ = db.Close() }()
 
-	deleted, err := NewRepository(db).PruneLog(context.Background())
+	deleted, err := NewRepository(db, nil).PruneLog(context.Background())
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		return 1
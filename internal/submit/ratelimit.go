@@ -8,6 +8,8 @@ import (
 	"net/netip"
 	"sync"
 	"time"
+
+	"pkgstatsd/internal/database"
 )
 
 const (
@@ -19,44 +21,35 @@ type RateLimiter interface {
 	Allow(ctx context.Context, key string) (bool, time.Time, error)
 }
 
-type SQLiteRateLimiter struct {
+type SQLRateLimiter struct {
 	db       *sql.DB
+	dialect  database.Dialect
 	limit    int
 	interval time.Duration
 	now      func() time.Time
 }
 
-func NewSQLiteRateLimiter(db *sql.DB) *SQLiteRateLimiter {
-	return &SQLiteRateLimiter{
+func NewSQLRateLimiter(db *sql.DB) *SQLRateLimiter {
+	return &SQLRateLimiter{
 		db:       db,
+		dialect:  database.DialectOf(db),
 		limit:    defaultRateLimit,
 		interval: defaultRateInterval,
 		now:      time.Now,
 	}
 }
 
-func (r *SQLiteRateLimiter) Allow(ctx context.Context, key string) (bool, time.Time, error) {
+func (r *SQLRateLimiter) Allow(ctx context.Context, key string) (bool, time.Time, error) {
 	now := r.now()
 	windowStart := now.Add(-r.interval)
 
-	// Atomically insert only if under the limit
-	result, err := r.db.ExecContext(ctx,
-		`INSERT INTO rate_limit (key, timestamp)
-		SELECT ?, ?
-		WHERE (SELECT COUNT(*) FROM rate_limit WHERE key = ? AND timestamp > ?) < ?`,
-		key, now.Unix(), key, windowStart.Unix(), r.limit,
-	)
-	if err != nil {
-		return false, time.Time{}, fmt.Errorf("rate limit check: %w", err)
-	}
-
-	inserted, err := result.RowsAffected()
+	inserted, err := r.record(ctx, key, now, windowStart)
 	if err != nil {
-		return false, time.Time{}, fmt.Errorf("rate limit rows affected: %w", err)
+		return false, time.Time{}, err
 	}
 
 	// Cleanup old entries (best effort)
-	if _, err := r.db.ExecContext(ctx, `DELETE FROM rate_limit WHERE timestamp < ?`, windowStart.Unix()); err != nil {
+	if _, err := r.db.ExecContext(ctx, r.dialect.Rebind(`DELETE FROM rate_limit WHERE timestamp < ?`), windowStart.Unix()); err != nil {
 		slog.Warn("failed to cleanup old rate limit entries", "error", err)
 	}
 
@@ -64,7 +57,7 @@ func (r *SQLiteRateLimiter) Allow(ctx context.Context, key string) (bool, time.T
 		// Over the limit — find oldest entry to calculate retry-after
 		var oldestTimestamp int64
 		err := r.db.QueryRowContext(ctx,
-			`SELECT MIN(timestamp) FROM rate_limit WHERE key = ? AND timestamp > ?`,
+			r.dialect.Rebind(`SELECT MIN(timestamp) FROM rate_limit WHERE key = ? AND timestamp > ?`),
 			key, windowStart.Unix(),
 		).Scan(&oldestTimestamp)
 		if err != nil {
@@ -78,6 +71,48 @@ func (r *SQLiteRateLimiter) Allow(ctx context.Context, key string) (bool, time.T
 	return true, time.Time{}, nil
 }
 
+// record inserts a request for key unless the limit is reached and returns
+// the number of rows inserted. SQLite runs writes one at a time, so the
+// count and the insert cannot interleave with another request. Under the
+// READ COMMITTED isolation of PostgreSQL, concurrent requests could all
+// count the same rows and exceed the limit, so they take a transaction-level
+// advisory lock on the key first.
+func (r *SQLRateLimiter) record(ctx context.Context, key string, now, windowStart time.Time) (int64, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("begin rate limit transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if r.dialect == database.Postgres {
+		if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, key); err != nil {
+			return 0, fmt.Errorf("lock rate limit key: %w", err)
+		}
+	}
+
+	// Insert only if under the limit
+	result, err := tx.ExecContext(ctx,
+		r.dialect.Rebind(`INSERT INTO rate_limit (key, timestamp)
+		SELECT CAST(? AS TEXT), CAST(? AS BIGINT)
+		WHERE (SELECT COUNT(*) FROM rate_limit WHERE key = ? AND timestamp > ?) < ?`),
+		key, now.Unix(), key, windowStart.Unix(), r.limit,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("rate limit check: %w", err)
+	}
+
+	inserted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("rate limit rows affected: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit rate limit transaction: %w", err)
+	}
+
+	return inserted, nil
+}
+
 type InMemoryRateLimiter struct {
 	mu       sync.Mutex
 	requests map[string][]time.Time
@@ -4,7 +4,11 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"slices"
 	"time"
+
+	"pkgstatsd/internal/database"
+	"pkgstatsd/internal/tracing"
 )
 
 const (
@@ -13,25 +17,34 @@ const (
 )
 
 type Repository struct {
-	db  *sql.DB
-	now func() time.Time
+	db      *sql.DB
+	dialect database.Dialect
+	results *database.ResultCache
+	now     func() time.Time
 }
 
-func NewRepository(db *sql.DB) *Repository {
+// NewRepository creates the submission repository. Cached results covering
+// the written month are dropped from results, which may be nil.
+func NewRepository(db *sql.DB, results *database.ResultCache) *Repository {
 	return &Repository{
-		db:  db,
-		now: time.Now,
+		db:      db,
+		dialect: database.DialectOf(db),
+		results: results,
+		now:     time.Now,
 	}
 }
 
 // SaveSubmission records a submission unless an identical recent request from
 // the same client address has already been accepted. It reports whether the
 // submission contributed to the aggregate tables.
-func (r *Repository) SaveSubmission(ctx context.Context, req *Request, mirrorURL string, logEntry *LogEntry) (bool, error) {
+func (r *Repository) SaveSubmission(ctx context.Context, req *Request, mirrorURL string, logEntry *LogEntry) (_ bool, err error) {
+	ctx, span := tracing.Start(ctx, "submit.SaveSubmission")
+	defer func() { tracing.End(span, err) }()
+
 	now := r.now()
 	month := yearMonth(now)
 
-	tx, err := r.db.BeginTx(ctx, nil)
+	tx, err := beginTx(ctx, r.db)
 	if err != nil {
 		return false, fmt.Errorf("begin transaction: %w", err)
 	}
@@ -42,7 +55,7 @@ func (r *Repository) SaveSubmission(ctx context.Context, req *Request, mirrorURL
 		return false, fmt.Errorf("claim submission fingerprint: %w", err)
 	}
 	if !accepted {
-		if err := tx.Commit(); err != nil {
+		if err := commitTx(ctx, tx); err != nil {
 			return false, fmt.Errorf("commit duplicate submission: %w", err)
 		}
 		return false, nil
@@ -85,9 +98,10 @@ func (r *Repository) SaveSubmission(ctx context.Context, req *Request, mirrorURL
 		return false, fmt.Errorf("save submission log: %w", err)
 	}
 
-	if err := tx.Commit(); err != nil {
+	if err := commitTx(ctx, tx); err != nil {
 		return false, fmt.Errorf("commit transaction: %w", err)
 	}
+	r.results.InvalidateMonth(month)
 
 	return true, nil
 }
@@ -97,10 +111,10 @@ func (r *Repository) claimSubmissionFingerprint(ctx context.Context, tx *sql.Tx,
 		return true, nil
 	}
 
-	result, err := tx.ExecContext(ctx,
-		`INSERT INTO submission_dedup (fingerprint, expires_at) VALUES (?, ?)
+	result, err := exec(ctx, tx, "INSERT submission_dedup",
+		r.dialect.Rebind(`INSERT INTO submission_dedup (fingerprint, expires_at) VALUES (?, ?)
 		 ON CONFLICT(fingerprint) DO UPDATE SET expires_at = excluded.expires_at
-		 WHERE submission_dedup.expires_at < ?`,
+		 WHERE submission_dedup.expires_at < ?`),
 		fingerprint, now.Add(deduplicationWindow).Unix(), now.Unix(),
 	)
 	if err != nil {
@@ -116,14 +130,26 @@ func (r *Repository) claimSubmissionFingerprint(ctx context.Context, tx *sql.Tx,
 
 func (r *Repository) insertLogEntry(ctx context.Context, tx *sql.Tx, entry *LogEntry, month int, timestamp int64) error {
 	//nolint:gosec // Query uses a hardcoded string and parameterized inputs
-	_, err := tx.ExecContext(ctx,
-		`INSERT INTO submission_log (month, timestamp, ip, headers, payload, payload_hash, country)
-		 VALUES (?, ?, ?, ?, ?, ?, ?)`,
+	_, err := exec(ctx, tx, "INSERT submission_log",
+		r.dialect.Rebind(`INSERT INTO submission_log (month, timestamp, ip, headers, payload, payload_hash, country)
+		 VALUES (?, ?, ?, ?, ?, ?, ?)`),
 		month, timestamp, entry.IP, entry.Headers,
 		entry.Payload, entry.PayloadHash, entry.Country)
 	return err
 }
 
+// LastSubmission returns the ID of the last logged submission, 0 if there is
+// none. Every accepted submission is logged in the transaction that counts
+// it, so the ID changes with every write to the current month on any
+// replica and serves as the write generation of API responses.
+func LastSubmission(ctx context.Context, db *sql.DB) (int64, error) {
+	var id int64
+	if err := db.QueryRowContext(ctx, `SELECT COALESCE(MAX(id), 0) FROM submission_log`).Scan(&id); err != nil {
+		return 0, fmt.Errorf("query last submission: %w", err)
+	}
+	return id, nil
+}
+
 // retentionMonths is the number of previous calendar months kept in the
 // submission log in addition to the current one. Older entries are pruned
 // to limit how long client IPs and headers are retained.
@@ -140,29 +166,58 @@ func retentionCutoff(now time.Time) int {
 // returns the number of log entries removed and runs as scheduled maintenance.
 func (r *Repository) PruneLog(ctx context.Context) (int64, error) {
 	result, err := r.db.ExecContext(ctx,
-		`DELETE FROM submission_log WHERE month < ?`, retentionCutoff(r.now()))
+		r.dialect.Rebind(`DELETE FROM submission_log WHERE month < ?`), retentionCutoff(r.now()))
 	if err != nil {
 		return 0, fmt.Errorf("prune submission log: %w", err)
 	}
 	if _, err := r.db.ExecContext(ctx,
-		`DELETE FROM submission_dedup WHERE expires_at < ?`, r.now().Unix()); err != nil {
+		r.dialect.Rebind(`DELETE FROM submission_dedup WHERE expires_at < ?`), r.now().Unix()); err != nil {
 		return 0, fmt.Errorf("prune submission deduplication: %w", err)
 	}
 	return result.RowsAffected()
 }
 
+// savePackages upserts the packages with prepared statements, traced in a
+// span per execution.
 func (r *Repository) savePackages(ctx context.Context, tx *sql.Tx, packages []string, month int) error {
+	upsertQuery := r.dialect.Rebind(`INSERT INTO package (name_id, month, count)
+		 SELECT id, ?, 1 FROM package_name WHERE name = ?
+		 ON CONFLICT(name_id, month) DO UPDATE SET count = package.count + 1`)
 	//nolint:gosec // Query uses a hardcoded string and parameterized inputs
-	stmt, err := tx.PrepareContext(ctx,
-		`INSERT INTO package (name, month, count) VALUES (?, ?, 1)
-		 ON CONFLICT(name, month) DO UPDATE SET count = count + 1`)
+	upsert, err := tx.PrepareContext(ctx, upsertQuery)
 	if err != nil {
 		return err
 	}
-	defer func() { _ = stmt.Close() }()
+	defer func() { _ = upsert.Close() }()
+
+	addNameQuery := r.dialect.Rebind(`INSERT INTO package_name (name) VALUES (?) ON CONFLICT(name) DO NOTHING`)
+	//nolint:gosec // Query uses a hardcoded string and parameterized inputs
+	addName, err := tx.PrepareContext(ctx, addNameQuery)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = addName.Close() }()
+
+	// Upserting in name order locks the rows in the same order in every
+	// transaction, so concurrent submissions cannot deadlock on PostgreSQL.
+	for _, pkg := range slices.Sorted(slices.Values(packages)) {
+		result, err := execStmt(ctx, upsert, "INSERT package", upsertQuery, month, pkg)
+		if err != nil {
+			return fmt.Errorf("insert package %s: %w", pkg, err)
+		}
 
-	for _, pkg := range packages {
-		if _, err := stmt.ExecContext(ctx, pkg, month); err != nil {
+		// Names are only added when the upsert finds none, as a conflicting
+		// insert would still use up a PostgreSQL identity value.
+		if upserted, err := result.RowsAffected(); err != nil {
+			return fmt.Errorf("insert package %s: %w", pkg, err)
+		} else if upserted > 0 {
+			continue
+		}
+
+		if _, err := execStmt(ctx, addName, "INSERT package_name", addNameQuery, pkg); err != nil {
+			return fmt.Errorf("insert package name %s: %w", pkg, err)
+		}
+		if _, err := execStmt(ctx, upsert, "INSERT package", upsertQuery, month, pkg); err != nil {
 			return fmt.Errorf("insert package %s: %w", pkg, err)
 		}
 	}
@@ -172,49 +227,79 @@ func (r *Repository) savePackages(ctx context.Context, tx *sql.Tx, packages []st
 
 func (r *Repository) upsertCountry(ctx context.Context, tx *sql.Tx, code string, month int) error {
 	//nolint:gosec // Query uses a hardcoded string and parameterized inputs
-	_, err := tx.ExecContext(ctx,
-		`INSERT INTO country (code, month, count) VALUES (?, ?, 1)
-		 ON CONFLICT(code, month) DO UPDATE SET count = count + 1`,
+	_, err := exec(ctx, tx, "INSERT country",
+		r.dialect.Rebind(`INSERT INTO country (code, month, count) VALUES (?, ?, 1)
+		 ON CONFLICT(code, month) DO UPDATE SET count = country.count + 1`),
 		code, month)
 	return err
 }
 
 func (r *Repository) upsertMirror(ctx context.Context, tx *sql.Tx, url string, month int) error {
 	//nolint:gosec // Query uses a hardcoded string and parameterized inputs
-	_, err := tx.ExecContext(ctx,
-		`INSERT INTO mirror (url, month, count) VALUES (?, ?, 1)
-		 ON CONFLICT(url, month) DO UPDATE SET count = count + 1`,
+	_, err := exec(ctx, tx, "INSERT mirror",
+		r.dialect.Rebind(`INSERT INTO mirror (url, month, count) VALUES (?, ?, 1)
+		 ON CONFLICT(url, month) DO UPDATE SET count = mirror.count + 1`),
 		url, month)
 	return err
 }
 
 func (r *Repository) upsertSystemArchitecture(ctx context.Context, tx *sql.Tx, name string, month int) error {
 	//nolint:gosec // Query uses a hardcoded string and parameterized inputs
-	_, err := tx.ExecContext(ctx,
-		`INSERT INTO system_architecture (name, month, count) VALUES (?, ?, 1)
-		 ON CONFLICT(name, month) DO UPDATE SET count = count + 1`,
+	_, err := exec(ctx, tx, "INSERT system_architecture",
+		r.dialect.Rebind(`INSERT INTO system_architecture (name, month, count) VALUES (?, ?, 1)
+		 ON CONFLICT(name, month) DO UPDATE SET count = system_architecture.count + 1`),
 		name, month)
 	return err
 }
 
 func (r *Repository) upsertOSArchitecture(ctx context.Context, tx *sql.Tx, name string, month int) error {
 	//nolint:gosec // Query uses a hardcoded string and parameterized inputs
-	_, err := tx.ExecContext(ctx,
-		`INSERT INTO operating_system_architecture (name, month, count) VALUES (?, ?, 1)
-		 ON CONFLICT(name, month) DO UPDATE SET count = count + 1`,
+	_, err := exec(ctx, tx, "INSERT operating_system_architecture",
+		r.dialect.Rebind(`INSERT INTO operating_system_architecture (name, month, count) VALUES (?, ?, 1)
+		 ON CONFLICT(name, month) DO UPDATE SET count = operating_system_architecture.count + 1`),
 		name, month)
 	return err
 }
 
 func (r *Repository) upsertOperatingSystemId(ctx context.Context, tx *sql.Tx, id string, month int) error {
 	//nolint:gosec // Query uses a hardcoded string and parameterized inputs
-	_, err := tx.ExecContext(ctx,
-		`INSERT INTO operating_system_id (id, month, count) VALUES (?, ?, 1)
-		 ON CONFLICT(id, month) DO UPDATE SET count = count + 1`,
+	_, err := exec(ctx, tx, "INSERT operating_system_id",
+		r.dialect.Rebind(`INSERT INTO operating_system_id (id, month, count) VALUES (?, ?, 1)
+		 ON CONFLICT(id, month) DO UPDATE SET count = operating_system_id.count + 1`),
 		id, month)
 	return err
 }
 
+// exec runs a statement of a submission in a span named after it.
+func exec(ctx context.Context, tx *sql.Tx, name, query string, args ...any) (sql.Result, error) {
+	ctx, span := tracing.StartQuery(ctx, name, query)
+	result, err := tx.ExecContext(ctx, query, args...)
+	tracing.End(span, err)
+	return result, err
+}
+
+// execStmt executes a prepared statement in a span like exec.
+func execStmt(ctx context.Context, stmt *sql.Stmt, name, query string, args ...any) (sql.Result, error) {
+	ctx, span := tracing.StartQuery(ctx, name, query)
+	result, err := stmt.ExecContext(ctx, args...)
+	tracing.End(span, err)
+	return result, err
+}
+
+func beginTx(ctx context.Context, db *sql.DB) (*sql.Tx, error) {
+	ctx, span := tracing.StartQuery(ctx, "BEGIN", "BEGIN")
+	tx, err := db.BeginTx(ctx, nil)
+	tracing.End(span, err)
+	return tx, err
+}
+
+func commitTx(ctx context.Context, tx *sql.Tx) error {
+	_, span := tracing.StartQuery(ctx, "COMMIT", "COMMIT")
+	err := tx.Commit()
+	tracing.End(span, err)
+	return err
+}
+
 func yearMonth(t time.Time) int {
 	return t.Year()*monthMultiplier + int(t.Month())
 }
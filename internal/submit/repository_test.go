@@ -0,0 +1,136 @@
+package submit
+
+import (
+	"context"
+	"net/http"
+	"net/netip"
+	"slices"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"pkgstatsd/internal/database"
+)
+
+func TestSaveSubmission_InvalidatesResultCache(t *testing.T) {
+	db, err := database.New(":memory:")
+	if err != nil {
+		t.Fatalf("create database: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	results := database.NewResultCache(10)
+	now := time.Date(2026, time.July, 1, 12, 0, 0, 0, time.UTC)
+	repo := NewRepository(db, results)
+	repo.now = func() time.Time { return now }
+
+	load := func(context.Context) (int, error) { return 1, nil }
+	_, _ = database.Cached(context.Background(), results, "test", "past", 202606, 202606, load)
+	_, _ = database.Cached(context.Background(), results, "test", "current", 202606, 202607, load)
+
+	req := &Request{
+		System: SystemInfo{Architecture: "x86_64"},
+		OS:     OSInfo{Architecture: "x86_64", ID: "arch"},
+		Pacman: PacmanInfo{Packages: []string{"pkgstats", "pacman"}},
+	}
+	entry := NewLogEntry(http.Header{"User-Agent": {"pkgstats/3.5.4"}}, netip.MustParseAddr("203.0.113.50"), []byte(`{"packages":"stable"}`), "DE")
+
+	counted, err := repo.SaveSubmission(context.Background(), req, "", entry)
+	if err != nil || !counted {
+		t.Fatalf("submission: counted=%v err=%v", counted, err)
+	}
+
+	loads := 0
+	reload := func(context.Context) (int, error) { loads++; return 1, nil }
+	_, _ = database.Cached(context.Background(), results, "test", "past", 202606, 202606, reload)
+	_, _ = database.Cached(context.Background(), results, "test", "current", 202606, 202607, reload)
+	if loads != 1 {
+		t.Errorf("expected only the current month's result to be reloaded, got %d loads", loads)
+	}
+}
+
+func TestLastSubmission(t *testing.T) {
+	db, err := database.New(":memory:")
+	if err != nil {
+		t.Fatalf("create database: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	id, err := LastSubmission(context.Background(), db)
+	if err != nil || id != 0 {
+		t.Fatalf("expected 0 without submissions, got %d, %v", id, err)
+	}
+
+	req := &Request{
+		System: SystemInfo{Architecture: "x86_64"},
+		OS:     OSInfo{Architecture: "x86_64"},
+		Pacman: PacmanInfo{Packages: []string{"pacman"}},
+	}
+	entry := NewLogEntry(http.Header{}, netip.MustParseAddr("203.0.113.50"), []byte(`{}`), "")
+	if _, err := NewRepository(db, nil).SaveSubmission(context.Background(), req, "", entry); err != nil {
+		t.Fatalf("submission: %v", err)
+	}
+
+	next, err := LastSubmission(context.Background(), db)
+	if err != nil || next <= id {
+		t.Errorf("expected the generation to grow after a submission, got %d, %v", next, err)
+	}
+}
+
+func TestSaveSubmission_Spans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	db, err := database.New(":memory:")
+	if err != nil {
+		t.Fatalf("create database: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	req := &Request{
+		Country: "DE",
+		System:  SystemInfo{Architecture: "x86_64"},
+		OS:      OSInfo{Architecture: "x86_64", ID: "arch"},
+		Pacman:  PacmanInfo{Packages: []string{"pkgstats", "pacman"}},
+	}
+	entry := NewLogEntry(http.Header{}, netip.MustParseAddr("203.0.113.50"), []byte(`{}`), "DE")
+
+	if _, err := NewRepository(db, nil).SaveSubmission(context.Background(), req, "https://geo.mirror.pkgbuild.com/", entry); err != nil {
+		t.Fatalf("SaveSubmission error: %v", err)
+	}
+
+	var names []string
+	var root trace.SpanID
+	for _, span := range recorder.Ended() {
+		if span.Name() == "submit.SaveSubmission" {
+			root = span.SpanContext().SpanID()
+			continue
+		}
+		names = append(names, span.Name())
+	}
+	for _, span := range recorder.Ended() {
+		if span.Name() != "submit.SaveSubmission" && span.Parent().SpanID() != root {
+			t.Errorf("expected %s to be a child of the repository span", span.Name())
+		}
+	}
+
+	want := []string{
+		"BEGIN", "INSERT submission_dedup",
+		// pacman and pkgstats are new: each upsert finds no name, adds it and
+		// upserts again.
+		"INSERT package", "INSERT package_name", "INSERT package",
+		"INSERT package", "INSERT package_name", "INSERT package",
+		"INSERT country", "INSERT mirror",
+		"INSERT system_architecture", "INSERT operating_system_architecture", "INSERT operating_system_id",
+		"INSERT submission_log", "COMMIT",
+	}
+	if !slices.Equal(names, want) {
+		t.Errorf("expected spans %v, got %v", want, names)
+	}
+}
@@ -5,36 +5,39 @@ import (
 	"database/sql"
 	"net/http"
 
+	"pkgstatsd/internal/database"
 	"pkgstatsd/internal/popularity"
+	"pkgstatsd/internal/web"
 )
 
-type SQLiteRepository struct {
+type SQLRepository struct {
 	*popularity.Repository[SystemArchitecturePopularity, SystemArchitecturePopularityList]
 }
 
-func NewSQLiteRepository(db *sql.DB) *SQLiteRepository {
-	return &SQLiteRepository{
+func NewSQLRepository(db *sql.DB, results *database.ResultCache) *SQLRepository {
+	return &SQLRepository{
 		Repository: popularity.NewRepository(db, popularity.Config{
 			Table:         "system_architecture",
 			Column:        "name",
 			QueryContains: true,
+			Results:       results,
 		}, newItem, newList),
 	}
 }
 
-func (r *SQLiteRepository) FindByName(ctx context.Context, name string, startMonth, endMonth int) (*SystemArchitecturePopularity, error) {
+func (r *SQLRepository) FindByName(ctx context.Context, name string, startMonth, endMonth int) (*SystemArchitecturePopularity, error) {
 	return r.FindByIdentifier(ctx, name, startMonth, endMonth)
 }
 
-func (r *SQLiteRepository) FindSeriesByName(ctx context.Context, name string, startMonth, endMonth, limit, offset int) (*SystemArchitecturePopularityList, error) {
-	return r.FindSeries(ctx, name, startMonth, endMonth, limit, offset)
+func (r *SQLRepository) FindSeriesByName(ctx context.Context, name string, startMonth, endMonth, limit, offset int, opts web.SeriesOptions) (*SystemArchitecturePopularityList, error) {
+	return r.FindSeries(ctx, name, startMonth, endMonth, limit, offset, opts)
 }
 
 type Handler struct {
 	pop *popularity.Handler[SystemArchitecturePopularity, SystemArchitecturePopularityList]
 }
 
-func NewHandler(repo *SQLiteRepository) *Handler {
+func NewHandler(repo *SQLRepository) *Handler {
 	return &Handler{
 		pop: popularity.NewHandler[SystemArchitecturePopularity, SystemArchitecturePopularityList](
 			repo, "/api/system-architectures", "name", "architecture name required",
@@ -9,25 +9,31 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"pkgstatsd/internal/popularity"
 	"pkgstatsd/internal/web"
 )
 
 type mockQuerier struct {
 	findByIdentifierFunc func(ctx context.Context, identifier string, startMonth, endMonth int) (*SystemArchitecturePopularity, error)
-	findAllFunc          func(ctx context.Context, query string, startMonth, endMonth, limit, offset int) (*SystemArchitecturePopularityList, error)
-	findSeriesFunc       func(ctx context.Context, identifier string, startMonth, endMonth, limit, offset int) (*SystemArchitecturePopularityList, error)
+	findAllFunc          func(ctx context.Context, query string, startMonth, endMonth, limit, offset int, opts web.ListOptions) (*SystemArchitecturePopularityList, error)
+	findSeriesFunc       func(ctx context.Context, identifier string, startMonth, endMonth, limit, offset int, opts web.SeriesOptions) (*SystemArchitecturePopularityList, error)
+	findDiffFunc         func(ctx context.Context, fromMonth, toMonth, limit, offset int) (*popularity.DiffList, error)
 }
 
 func (m *mockQuerier) FindByIdentifier(ctx context.Context, identifier string, startMonth, endMonth int) (*SystemArchitecturePopularity, error) {
 	return m.findByIdentifierFunc(ctx, identifier, startMonth, endMonth)
 }
 
-func (m *mockQuerier) FindAll(ctx context.Context, query string, startMonth, endMonth, limit, offset int) (*SystemArchitecturePopularityList, error) {
-	return m.findAllFunc(ctx, query, startMonth, endMonth, limit, offset)
+func (m *mockQuerier) FindAll(ctx context.Context, query string, startMonth, endMonth, limit, offset int, opts web.ListOptions) (*SystemArchitecturePopularityList, error) {
+	return m.findAllFunc(ctx, query, startMonth, endMonth, limit, offset, opts)
 }
 
-func (m *mockQuerier) FindSeries(ctx context.Context, identifier string, startMonth, endMonth, limit, offset int) (*SystemArchitecturePopularityList, error) {
-	return m.findSeriesFunc(ctx, identifier, startMonth, endMonth, limit, offset)
+func (m *mockQuerier) FindSeries(ctx context.Context, identifier string, startMonth, endMonth, limit, offset int, opts web.SeriesOptions) (*SystemArchitecturePopularityList, error) {
+	return m.findSeriesFunc(ctx, identifier, startMonth, endMonth, limit, offset, opts)
+}
+
+func (m *mockQuerier) FindDiff(ctx context.Context, fromMonth, toMonth, limit, offset int) (*popularity.DiffList, error) {
+	return m.findDiffFunc(ctx, fromMonth, toMonth, limit, offset)
 }
 
 func newTestMux(q *mockQuerier) *http.ServeMux {
@@ -88,7 +94,7 @@ func TestHandleGet_RepositoryError(t *testing.T) {
 
 func TestHandleList_ResponseStructure(t *testing.T) {
 	q := &mockQuerier{
-		findAllFunc: func(_ context.Context, query string, _, _, limit, offset int) (*SystemArchitecturePopularityList, error) {
+		findAllFunc: func(_ context.Context, query string, _, _, limit, offset int, _ web.ListOptions) (*SystemArchitecturePopularityList, error) {
 			return &SystemArchitecturePopularityList{
 				SystemArchitecturePopularities: []SystemArchitecturePopularity{},
 				Limit:                          limit,
@@ -134,7 +140,7 @@ func TestHandleList_PaginationValidCases(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			var capturedLimit, capturedOffset int
 			q := &mockQuerier{
-				findAllFunc: func(_ context.Context, query string, _, _, limit, offset int) (*SystemArchitecturePopularityList, error) {
+				findAllFunc: func(_ context.Context, query string, _, _, limit, offset int, _ web.ListOptions) (*SystemArchitecturePopularityList, error) {
 					capturedLimit = limit
 					capturedOffset = offset
 					return &SystemArchitecturePopularityList{
@@ -177,7 +183,7 @@ func TestHandleList_PaginationInvalidCases(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			q := &mockQuerier{
-				findAllFunc: func(_ context.Context, query string, _, _, limit, offset int) (*SystemArchitecturePopularityList, error) {
+				findAllFunc: func(_ context.Context, query string, _, _, limit, offset int, _ web.ListOptions) (*SystemArchitecturePopularityList, error) {
 					return &SystemArchitecturePopularityList{
 						SystemArchitecturePopularities: []SystemArchitecturePopularity{},
 						Limit:                          limit,
@@ -206,7 +212,7 @@ func currentMonth() int {
 func TestHandleList_MonthZeroMeansCurrentMonth(t *testing.T) {
 	var capturedStart, capturedEnd int
 	q := &mockQuerier{
-		findAllFunc: func(_ context.Context, query string, startMonth, endMonth, limit, offset int) (*SystemArchitecturePopularityList, error) {
+		findAllFunc: func(_ context.Context, query string, startMonth, endMonth, limit, offset int, _ web.ListOptions) (*SystemArchitecturePopularityList, error) {
 			capturedStart = startMonth
 			capturedEnd = endMonth
 			return &SystemArchitecturePopularityList{
@@ -238,7 +244,7 @@ func TestHandleList_MonthZeroMeansCurrentMonth(t *testing.T) {
 func TestHandleSeries_MonthZeroMeansCurrentMonth(t *testing.T) {
 	var capturedStart, capturedEnd int
 	q := &mockQuerier{
-		findSeriesFunc: func(_ context.Context, _ string, startMonth, endMonth, _, _ int) (*SystemArchitecturePopularityList, error) {
+		findSeriesFunc: func(_ context.Context, _ string, startMonth, endMonth, _, _ int, _ web.SeriesOptions) (*SystemArchitecturePopularityList, error) {
 			capturedStart = startMonth
 			capturedEnd = endMonth
 			return &SystemArchitecturePopularityList{
@@ -266,7 +272,7 @@ func TestHandleSeries_MonthZeroMeansCurrentMonth(t *testing.T) {
 
 func TestHandleSeries(t *testing.T) {
 	q := &mockQuerier{
-		findSeriesFunc: func(_ context.Context, name string, _, _, limit, _ int) (*SystemArchitecturePopularityList, error) {
+		findSeriesFunc: func(_ context.Context, name string, _, _, limit, _ int, _ web.SeriesOptions) (*SystemArchitecturePopularityList, error) {
 			return &SystemArchitecturePopularityList{
 				Total:                          1,
 				Count:                          1,
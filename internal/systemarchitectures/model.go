@@ -1,14 +1,20 @@
 package systemarchitectures
 
-import "context"
+import (
+	"context"
+
+	"pkgstatsd/internal/popularity"
+	"pkgstatsd/internal/web"
+)
 
 type SystemArchitecturePopularity struct {
 	Name       string  `json:"name"`
 	Samples    int     `json:"samples"`
 	Count      int     `json:"count"`
 	Popularity float64 `json:"popularity"`
-	StartMonth int     `json:"startMonth"`
-	EndMonth   int     `json:"endMonth"`
+	*popularity.Interval
+	StartMonth int `json:"startMonth"`
+	EndMonth   int `json:"endMonth"`
 }
 
 func (s SystemArchitecturePopularity) GetName() string        { return s.Name }
@@ -22,24 +28,26 @@ type SystemArchitecturePopularityList struct {
 	Limit                          int                            `json:"limit"`
 	Offset                         int                            `json:"offset"`
 	Query                          *string                        `json:"query"`
+	Next                           *string                        `json:"next,omitempty"`
+	Smoothing                      string                         `json:"smoothing,omitempty"`
 }
 
 type Repository interface {
 	FindByName(ctx context.Context, name string, startMonth, endMonth int) (*SystemArchitecturePopularity, error)
-	FindAll(ctx context.Context, query string, startMonth, endMonth, limit, offset int) (*SystemArchitecturePopularityList, error)
-	FindSeriesByName(ctx context.Context, name string, startMonth, endMonth, limit, offset int) (*SystemArchitecturePopularityList, error)
+	FindAll(ctx context.Context, query string, startMonth, endMonth, limit, offset int, opts web.ListOptions) (*SystemArchitecturePopularityList, error)
+	FindSeriesByName(ctx context.Context, name string, startMonth, endMonth, limit, offset int, opts web.SeriesOptions) (*SystemArchitecturePopularityList, error)
 }
 
-func newItem(identifier string, samples, count int, popularity float64, startMonth, endMonth int) SystemArchitecturePopularity {
+func newItem(identifier string, samples, count int, popularity float64, interval *popularity.Interval, startMonth, endMonth int) SystemArchitecturePopularity {
 	return SystemArchitecturePopularity{
 		Name: identifier, Samples: samples, Count: count,
-		Popularity: popularity, StartMonth: startMonth, EndMonth: endMonth,
+		Popularity: popularity, Interval: interval, StartMonth: startMonth, EndMonth: endMonth,
 	}
 }
 
-func newList(total, count int, items []SystemArchitecturePopularity, limit, offset int, query *string) SystemArchitecturePopularityList {
+func newList(total, count int, items []SystemArchitecturePopularity, limit, offset int, query, next *string, smoothing string) SystemArchitecturePopularityList {
 	return SystemArchitecturePopularityList{
 		Total: total, Count: count, SystemArchitecturePopularities: items,
-		Limit: limit, Offset: offset, Query: query,
+		Limit: limit, Offset: offset, Query: query, Next: next, Smoothing: smoothing,
 	}
 }
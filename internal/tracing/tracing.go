@@ -0,0 +1,125 @@
+// Package tracing sets up OpenTelemetry tracing and starts the spans of
+// handlers, repositories and SQL statements. Without Setup, or with tracing
+// disabled, spans are no-ops.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"pkgstatsd/internal/config"
+)
+
+const (
+	instrumentationName = "pkgstatsd"
+	serviceName         = "pkgstatsd"
+
+	tracesFileMode = 0o640
+)
+
+// Setup installs the global tracer provider for cfg.TracesExporter and
+// returns a function that flushes pending spans and shuts it down. Without
+// an exporter it does nothing.
+func Setup(ctx context.Context, cfg config.Config) (shutdown func(context.Context) error, err error) {
+	var exporter sdktrace.SpanExporter
+	switch cfg.TracesExporter {
+	case "":
+		return func(context.Context) error { return nil }, nil
+	case config.TracesExporterOTLP:
+		// Configured with the standard OTEL_EXPORTER_OTLP_* variables.
+		exporter, err = otlptracehttp.New(ctx)
+	case config.TracesExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case config.TracesExporterFile:
+		exporter, err = newFileExporter(cfg.TracesFile)
+	default:
+		err = fmt.Errorf("unknown traces exporter %q", cfg.TracesExporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("create traces exporter: %w", err)
+	}
+
+	// OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES override the service name.
+	res, err := resource.New(ctx,
+		resource.WithSchemaURL(semconv.SchemaURL),
+		resource.WithAttributes(semconv.ServiceName(serviceName)),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("create trace resource: %w", err)
+	}
+
+	// The sampler is configured with OTEL_TRACES_SAMPLER and defaults to
+	// sampling every trace not sampled out by the caller.
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	return provider.Shutdown, nil
+}
+
+// fileExporter writes spans as JSON lines to a file it closes on shutdown.
+type fileExporter struct {
+	*stdouttrace.Exporter
+	file *os.File
+}
+
+func newFileExporter(path string) (*fileExporter, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, tracesFileMode)
+	if err != nil {
+		return nil, err
+	}
+
+	exporter, err := stdouttrace.New(stdouttrace.WithWriter(file))
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+
+	return &fileExporter{Exporter: exporter, file: file}, nil
+}
+
+func (e *fileExporter) Shutdown(ctx context.Context) error {
+	return errors.Join(e.Exporter.Shutdown(ctx), e.file.Close())
+}
+
+// Start starts a span named name as a child of the span in ctx.
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, opts...)
+}
+
+// StartQuery starts a client span for a SQL statement, named after the
+// operation and table it works on, such as "INSERT package".
+func StartQuery(ctx context.Context, name, query string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBQueryText(query)),
+		trace.WithAttributes(attrs...),
+	)
+}
+
+// End records err on span, if any, and ends it.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
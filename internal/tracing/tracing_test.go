@@ -0,0 +1,61 @@
+package tracing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+
+	"pkgstatsd/internal/config"
+)
+
+func TestSetup_OTLP(t *testing.T) {
+	var mu sync.Mutex
+	var paths []string
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		paths = append(paths, r.Method+" "+r.URL.Path)
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			t.Errorf("expected the configured header, got %q", r.Header.Get("Authorization"))
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer collector.Close()
+
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", collector.URL)
+	t.Setenv("OTEL_EXPORTER_OTLP_HEADERS", "Authorization=Bearer%20secret")
+
+	previous := otel.GetTracerProvider()
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	shutdown, err := Setup(t.Context(), config.Config{TracesExporter: config.TracesExporterOTLP})
+	if err != nil {
+		t.Fatalf("Setup error: %v", err)
+	}
+
+	_, span := Start(t.Context(), "test")
+	span.End()
+
+	if err := shutdown(t.Context()); err != nil {
+		t.Fatalf("shutdown error: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(paths) != 1 || paths[0] != "POST /v1/traces" {
+		t.Errorf("expected one export to /v1/traces, got %v", paths)
+	}
+}
+
+func TestSetup_Disabled(t *testing.T) {
+	shutdown, err := Setup(t.Context(), config.Config{})
+	if err != nil {
+		t.Fatalf("Setup error: %v", err)
+	}
+	if err := shutdown(t.Context()); err != nil {
+		t.Errorf("shutdown error: %v", err)
+	}
+}
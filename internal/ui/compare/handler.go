@@ -33,6 +33,8 @@ func (h *Handler) HandleCompare(w http.ResponseWriter, r *http.Request) {
 		names = names[:layout.MaxCompareChartPackages]
 	}
 
+	opts := layout.SeriesOptions(r)
+
 	var allSeries []packages.PackagePopularity
 	for _, name := range names {
 		name = strings.TrimSpace(name)
@@ -40,9 +42,9 @@ func (h *Handler) HandleCompare(w http.ResponseWriter, r *http.Request) {
 			continue
 		}
 
-		list, err := h.repo.FindSeriesByName(r.Context(), name, 0, web.GetLastCompleteMonth(), layout.SeriesLimit, 0)
+		list, err := h.repo.FindSeriesByName(r.Context(), name, 0, web.GetLastCompleteMonth(), layout.SeriesLimit, 0, web.SeriesOptions{Granularity: opts.Granularity})
 		if err != nil {
-			layout.ServerError(w, "failed to fetch package series", err)
+			layout.ServerError(w, r, "failed to fetch package series", err)
 			return
 		}
 
@@ -54,7 +56,7 @@ func (h *Handler) HandleCompare(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	data := chartdata.Build(allSeries)
+	data := chartdata.Build(allSeries, opts)
 
 	layout.Render(w, r,
 		layout.Page{Title: "Compare packages", Description: "Compare the popularity of Arch Linux packages side by side.", Path: "/packages", Manifest: h.manifest, NoIndex: true},
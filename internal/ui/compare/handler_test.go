@@ -9,23 +9,45 @@ import (
 	"testing"
 
 	"pkgstatsd/internal/packages"
+	"pkgstatsd/internal/popularity"
 	"pkgstatsd/internal/ui/layout"
+	"pkgstatsd/internal/web"
 )
 
 type mockRepo struct {
-	findSeriesByNameFunc func(ctx context.Context, name string, startMonth, endMonth, limit, offset int) (*packages.PackagePopularityList, error)
+	findSeriesByNameFunc func(ctx context.Context, name string, startMonth, endMonth, limit, offset int, opts web.SeriesOptions) (*packages.PackagePopularityList, error)
 }
 
 func (m *mockRepo) FindByName(ctx context.Context, name string, startMonth, endMonth int) (*packages.PackagePopularity, error) {
 	return nil, nil
 }
 
-func (m *mockRepo) FindAll(ctx context.Context, query string, startMonth, endMonth, limit, offset int) (*packages.PackagePopularityList, error) {
+func (m *mockRepo) FindAll(ctx context.Context, query string, startMonth, endMonth, limit, offset int, opts web.ListOptions) (*packages.PackagePopularityList, error) {
 	return nil, nil
 }
 
-func (m *mockRepo) FindSeriesByName(ctx context.Context, name string, startMonth, endMonth, limit, offset int) (*packages.PackagePopularityList, error) {
-	return m.findSeriesByNameFunc(ctx, name, startMonth, endMonth, limit, offset)
+func (m *mockRepo) FindSeriesByName(ctx context.Context, name string, startMonth, endMonth, limit, offset int, opts web.SeriesOptions) (*packages.PackagePopularityList, error) {
+	return m.findSeriesByNameFunc(ctx, name, startMonth, endMonth, limit, offset, opts)
+}
+
+func (m *mockRepo) FindNew(_ context.Context, _, _, _ int) (*packages.PackagePopularityList, error) {
+	return nil, nil
+}
+
+func (m *mockRepo) FindGone(_ context.Context, _, _, _ int) (*packages.GonePackageList, error) {
+	return nil, nil
+}
+
+func (m *mockRepo) Suggest(_ context.Context, _ string, _ int) (*packages.PackageSuggestions, error) {
+	return nil, nil
+}
+
+func (m *mockRepo) FindForecastByName(_ context.Context, _ string, _ int) (*packages.PackageForecast, error) {
+	return nil, nil
+}
+
+func (m *mockRepo) FindDiff(_ context.Context, _, _, _, _ int) (*popularity.DiffList, error) {
+	return nil, nil
 }
 
 func TestHandleCompare(t *testing.T) {
@@ -35,7 +57,7 @@ func TestHandleCompare(t *testing.T) {
 	// client, so the decoding must not change.
 	var lookedUp []string
 	repo := &mockRepo{
-		findSeriesByNameFunc: func(ctx context.Context, name string, _, _, _, _ int) (*packages.PackagePopularityList, error) {
+		findSeriesByNameFunc: func(ctx context.Context, name string, _, _, _, _ int, _ web.SeriesOptions) (*packages.PackagePopularityList, error) {
 			lookedUp = append(lookedUp, name)
 			return &packages.PackagePopularityList{
 				Total: 1,
@@ -66,7 +88,7 @@ func TestHandleCompare_EncodedComma(t *testing.T) {
 	manifest, _ := layout.NewManifest([]byte(`{}`))
 	var lookedUp []string
 	repo := &mockRepo{
-		findSeriesByNameFunc: func(ctx context.Context, name string, _, _, _, _ int) (*packages.PackagePopularityList, error) {
+		findSeriesByNameFunc: func(ctx context.Context, name string, _, _, _, _ int, _ web.SeriesOptions) (*packages.PackagePopularityList, error) {
 			lookedUp = append(lookedUp, name)
 			return &packages.PackagePopularityList{
 				Total: 1,
@@ -97,7 +119,7 @@ func TestHandleCompare_EncodedComma(t *testing.T) {
 func TestHandleCompare_SeriesError(t *testing.T) {
 	manifest, _ := layout.NewManifest([]byte(`{}`))
 	repo := &mockRepo{
-		findSeriesByNameFunc: func(_ context.Context, _ string, _, _, _, _ int) (*packages.PackagePopularityList, error) {
+		findSeriesByNameFunc: func(_ context.Context, _ string, _, _, _, _ int, _ web.SeriesOptions) (*packages.PackagePopularityList, error) {
 			return nil, errors.New("db error")
 		},
 	}
@@ -117,7 +139,7 @@ func TestHandleCompare_SeriesError(t *testing.T) {
 func TestHandleCompare_ExceedsLimit(t *testing.T) {
 	manifest, _ := layout.NewManifest([]byte(`{}`))
 	repo := &mockRepo{
-		findSeriesByNameFunc: func(ctx context.Context, name string, _, _, _, _ int) (*packages.PackagePopularityList, error) {
+		findSeriesByNameFunc: func(ctx context.Context, name string, _, _, _, _ int, _ web.SeriesOptions) (*packages.PackagePopularityList, error) {
 			return &packages.PackagePopularityList{
 				Total: 1,
 				PackagePopularities: []packages.PackagePopularity{
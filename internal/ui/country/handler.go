@@ -24,9 +24,9 @@ func NewHandler(repo countries.Repository, manifest *layout.Manifest) *Handler {
 func (h *Handler) HandleCountries(w http.ResponseWriter, r *http.Request) {
 	currentMonth := web.GetLastCompleteMonth()
 
-	list, err := h.repo.FindAll(r.Context(), "", currentMonth, currentMonth, allCountries, 0)
+	list, err := h.repo.FindAll(r.Context(), "", currentMonth, currentMonth, allCountries, 0, web.ListOptions{})
 	if err != nil {
-		layout.ServerError(w, "failed to fetch countries", err)
+		layout.ServerError(w, r, "failed to fetch countries", err)
 		return
 	}
 
@@ -43,9 +43,10 @@ func (h *Handler) HandleCountryDetail(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	list, err := h.repo.FindSeriesByCode(r.Context(), code, 0, web.GetLastCompleteMonth(), layout.SeriesLimit, 0)
+	opts := layout.SeriesOptions(r)
+	list, err := h.repo.FindSeriesByCode(r.Context(), code, 0, web.GetLastCompleteMonth(), layout.SeriesLimit, 0, web.SeriesOptions{Granularity: opts.Granularity})
 	if err != nil {
-		layout.ServerError(w, "failed to fetch country series", err)
+		layout.ServerError(w, r, "failed to fetch country series", err)
 		return
 	}
 
@@ -54,7 +55,7 @@ func (h *Handler) HandleCountryDetail(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	data := chartdata.Build(list.CountryPopularities)
+	data := chartdata.Build(list.CountryPopularities, opts)
 
 	layout.Render(w, r,
 		layout.Page{Title: code + " - Country statistics", Description: "Popularity of Arch Linux in " + code + " over time.", Path: "/countries", Manifest: h.manifest, CanonicalPath: "/countries/" + strings.ToLower(code)},
@@ -10,24 +10,25 @@ import (
 
 	"pkgstatsd/internal/countries"
 	"pkgstatsd/internal/ui/layout"
+	"pkgstatsd/internal/web"
 )
 
 type mockRepo struct {
-	findAllFunc          func(ctx context.Context, query string, startMonth, endMonth, limit, offset int) (*countries.CountryPopularityList, error)
-	findSeriesByCodeFunc func(ctx context.Context, code string, startMonth, endMonth, limit, offset int) (*countries.CountryPopularityList, error)
+	findAllFunc          func(ctx context.Context, query string, startMonth, endMonth, limit, offset int, opts web.ListOptions) (*countries.CountryPopularityList, error)
+	findSeriesByCodeFunc func(ctx context.Context, code string, startMonth, endMonth, limit, offset int, opts web.SeriesOptions) (*countries.CountryPopularityList, error)
 }
 
 func (m *mockRepo) FindByCode(ctx context.Context, code string, startMonth, endMonth int) (*countries.CountryPopularity, error) {
 	return nil, nil
 }
 
-func (m *mockRepo) FindAll(ctx context.Context, query string, startMonth, endMonth, limit, offset int) (*countries.CountryPopularityList, error) {
-	return m.findAllFunc(ctx, query, startMonth, endMonth, limit, offset)
+func (m *mockRepo) FindAll(ctx context.Context, query string, startMonth, endMonth, limit, offset int, opts web.ListOptions) (*countries.CountryPopularityList, error) {
+	return m.findAllFunc(ctx, query, startMonth, endMonth, limit, offset, opts)
 }
 
-func (m *mockRepo) FindSeriesByCode(ctx context.Context, code string, startMonth, endMonth, limit, offset int) (*countries.CountryPopularityList, error) {
+func (m *mockRepo) FindSeriesByCode(ctx context.Context, code string, startMonth, endMonth, limit, offset int, opts web.SeriesOptions) (*countries.CountryPopularityList, error) {
 	if m.findSeriesByCodeFunc != nil {
-		return m.findSeriesByCodeFunc(ctx, code, startMonth, endMonth, limit, offset)
+		return m.findSeriesByCodeFunc(ctx, code, startMonth, endMonth, limit, offset, opts)
 	}
 	return nil, nil
 }
@@ -35,7 +36,7 @@ func (m *mockRepo) FindSeriesByCode(ctx context.Context, code string, startMonth
 func TestHandleCountries(t *testing.T) {
 	manifest, _ := layout.NewManifest([]byte(`{}`))
 	repo := &mockRepo{
-		findAllFunc: func(ctx context.Context, query string, _, _, _, _ int) (*countries.CountryPopularityList, error) {
+		findAllFunc: func(ctx context.Context, query string, _, _, _, _ int, _ web.ListOptions) (*countries.CountryPopularityList, error) {
 			return &countries.CountryPopularityList{
 				Total: 1,
 				CountryPopularities: []countries.CountryPopularity{
@@ -68,7 +69,7 @@ func TestHandleCountryDetail(t *testing.T) {
 	manifest, _ := layout.NewManifest([]byte(`{}`))
 	var queriedCode string
 	repo := &mockRepo{
-		findSeriesByCodeFunc: func(ctx context.Context, code string, _, _, _, _ int) (*countries.CountryPopularityList, error) {
+		findSeriesByCodeFunc: func(ctx context.Context, code string, _, _, _, _ int, _ web.SeriesOptions) (*countries.CountryPopularityList, error) {
 			queriedCode = code
 			return &countries.CountryPopularityList{
 				Total: 1,
@@ -103,7 +104,7 @@ func TestHandleCountryDetail(t *testing.T) {
 func TestHandleCountryDetail_NotFound(t *testing.T) {
 	manifest, _ := layout.NewManifest([]byte(`{}`))
 	repo := &mockRepo{
-		findSeriesByCodeFunc: func(ctx context.Context, code string, _, _, _, _ int) (*countries.CountryPopularityList, error) {
+		findSeriesByCodeFunc: func(ctx context.Context, code string, _, _, _, _ int, _ web.SeriesOptions) (*countries.CountryPopularityList, error) {
 			return &countries.CountryPopularityList{Total: 0}, nil
 		},
 	}
@@ -123,7 +124,7 @@ func TestHandleCountryDetail_NotFound(t *testing.T) {
 func TestHandleCountryDetail_Error(t *testing.T) {
 	manifest, _ := layout.NewManifest([]byte(`{}`))
 	repo := &mockRepo{
-		findSeriesByCodeFunc: func(_ context.Context, _ string, _, _, _, _ int) (*countries.CountryPopularityList, error) {
+		findSeriesByCodeFunc: func(_ context.Context, _ string, _, _, _, _ int, _ web.SeriesOptions) (*countries.CountryPopularityList, error) {
 			return nil, errors.New("db error")
 		},
 	}
@@ -83,7 +83,7 @@ func (h *Handler) handleCurrent(w http.ResponseWriter, r *http.Request, category
 
 	pkgs, err := h.fetchSortedPackages(r, category, currentMonth)
 	if err != nil {
-		layout.ServerError(w, "failed to fetch package", err)
+		layout.ServerError(w, r, "failed to fetch package", err)
 		return
 	}
 
@@ -103,20 +103,21 @@ func (h *Handler) handleCurrent(w http.ResponseWriter, r *http.Request, category
 
 func (h *Handler) handleHistory(w http.ResponseWriter, r *http.Request, category *fun.Category) {
 	currentMonth := web.GetLastCompleteMonth()
+	opts := layout.SeriesOptions(r)
 
 	var allSeries []packages.PackagePopularity
 
 	for _, name := range category.Packages {
-		list, err := h.repo.FindSeriesByName(r.Context(), name, 0, currentMonth, layout.SeriesLimit, 0)
+		list, err := h.repo.FindSeriesByName(r.Context(), name, 0, currentMonth, layout.SeriesLimit, 0, web.SeriesOptions{Granularity: opts.Granularity})
 		if err != nil {
-			layout.ServerError(w, "failed to fetch package series", err)
+			layout.ServerError(w, r, "failed to fetch package series", err)
 			return
 		}
 
 		allSeries = append(allSeries, list.PackagePopularities...)
 	}
 
-	data := chartdata.Build(allSeries)
+	data := chartdata.Build(allSeries, opts)
 
 	// Build compare URL from all datasets (sorted by latest popularity) before truncating for the chart
 	cmpURL := compareURLFromDatasets(data.Datasets)
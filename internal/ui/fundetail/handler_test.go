@@ -9,12 +9,14 @@ import (
 	"testing"
 
 	"pkgstatsd/internal/packages"
+	"pkgstatsd/internal/popularity"
 	"pkgstatsd/internal/ui/layout"
+	"pkgstatsd/internal/web"
 )
 
 type mockRepo struct {
 	findByNameFunc       func(ctx context.Context, name string, startMonth, endMonth int) (*packages.PackagePopularity, error)
-	findSeriesByNameFunc func(ctx context.Context, name string, startMonth, endMonth, limit, offset int) (*packages.PackagePopularityList, error)
+	findSeriesByNameFunc func(ctx context.Context, name string, startMonth, endMonth, limit, offset int, opts web.SeriesOptions) (*packages.PackagePopularityList, error)
 }
 
 func (m *mockRepo) FindByName(ctx context.Context, name string, startMonth, endMonth int) (*packages.PackagePopularity, error) {
@@ -24,13 +26,13 @@ func (m *mockRepo) FindByName(ctx context.Context, name string, startMonth, endM
 	return &packages.PackagePopularity{Name: name, Popularity: 1.0}, nil
 }
 
-func (m *mockRepo) FindAll(ctx context.Context, query string, startMonth, endMonth, limit, offset int) (*packages.PackagePopularityList, error) {
+func (m *mockRepo) FindAll(ctx context.Context, query string, startMonth, endMonth, limit, offset int, opts web.ListOptions) (*packages.PackagePopularityList, error) {
 	return nil, nil
 }
 
-func (m *mockRepo) FindSeriesByName(ctx context.Context, name string, startMonth, endMonth, limit, offset int) (*packages.PackagePopularityList, error) {
+func (m *mockRepo) FindSeriesByName(ctx context.Context, name string, startMonth, endMonth, limit, offset int, opts web.SeriesOptions) (*packages.PackagePopularityList, error) {
 	if m.findSeriesByNameFunc != nil {
-		return m.findSeriesByNameFunc(ctx, name, startMonth, endMonth, limit, offset)
+		return m.findSeriesByNameFunc(ctx, name, startMonth, endMonth, limit, offset, opts)
 	}
 	return &packages.PackagePopularityList{
 		Total: 1,
@@ -40,6 +42,26 @@ func (m *mockRepo) FindSeriesByName(ctx context.Context, name string, startMonth
 	}, nil
 }
 
+func (m *mockRepo) FindNew(_ context.Context, _, _, _ int) (*packages.PackagePopularityList, error) {
+	return nil, nil
+}
+
+func (m *mockRepo) FindGone(_ context.Context, _, _, _ int) (*packages.GonePackageList, error) {
+	return nil, nil
+}
+
+func (m *mockRepo) Suggest(_ context.Context, _ string, _ int) (*packages.PackageSuggestions, error) {
+	return nil, nil
+}
+
+func (m *mockRepo) FindForecastByName(_ context.Context, _ string, _ int) (*packages.PackageForecast, error) {
+	return nil, nil
+}
+
+func (m *mockRepo) FindDiff(_ context.Context, _, _, _, _ int) (*popularity.DiffList, error) {
+	return nil, nil
+}
+
 func TestHandleCurrent_SmallCategory(t *testing.T) {
 	manifest, _ := layout.NewManifest([]byte(`{}`))
 	popularity := 10.0
@@ -136,7 +158,7 @@ func TestHandleCurrent_LargeCategory(t *testing.T) {
 func TestHandleHistory_ChartLimited(t *testing.T) {
 	manifest, _ := layout.NewManifest([]byte(`{}`))
 	repo := &mockRepo{
-		findSeriesByNameFunc: func(_ context.Context, name string, _, _, _, _ int) (*packages.PackagePopularityList, error) {
+		findSeriesByNameFunc: func(_ context.Context, name string, _, _, _, _ int, _ web.SeriesOptions) (*packages.PackagePopularityList, error) {
 			return &packages.PackagePopularityList{
 				Total: 1,
 				PackagePopularities: []packages.PackagePopularity{
@@ -177,7 +199,7 @@ func TestHandleHistory_ChartLimited(t *testing.T) {
 func TestHandleHistory_CompareURLIncludesAllPackages(t *testing.T) {
 	manifest, _ := layout.NewManifest([]byte(`{}`))
 	repo := &mockRepo{
-		findSeriesByNameFunc: func(_ context.Context, name string, _, _, _, _ int) (*packages.PackagePopularityList, error) {
+		findSeriesByNameFunc: func(_ context.Context, name string, _, _, _, _ int, _ web.SeriesOptions) (*packages.PackagePopularityList, error) {
 			return &packages.PackagePopularityList{
 				Total: 1,
 				PackagePopularities: []packages.PackagePopularity{
@@ -301,7 +323,7 @@ func TestHandleCurrent_FetchError(t *testing.T) {
 func TestHandleHistory_FetchError(t *testing.T) {
 	manifest, _ := layout.NewManifest([]byte(`{}`))
 	repo := &mockRepo{
-		findSeriesByNameFunc: func(_ context.Context, _ string, _, _, _, _ int) (*packages.PackagePopularityList, error) {
+		findSeriesByNameFunc: func(_ context.Context, _ string, _, _, _, _ int, _ web.SeriesOptions) (*packages.PackagePopularityList, error) {
 			return nil, errors.New("db error")
 		},
 	}
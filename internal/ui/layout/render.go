@@ -30,10 +30,10 @@ func Render(w http.ResponseWriter, r *http.Request, page Page, content templ.Com
 	}
 }
 
-func ServerError(w http.ResponseWriter, msg string, err error) {
+func ServerError(w http.ResponseWriter, r *http.Request, msg string, err error) {
 	if web.IsClientDisconnect(err) {
 		return
 	}
-	slog.Error(msg, "error", err)
+	slog.Error(msg, "error", err, "request_id", web.RequestID(r.Context()))
 	http.Error(w, "internal server error", http.StatusInternalServerError)
 }
@@ -0,0 +1,24 @@
+package layout
+
+import (
+	"net/http"
+
+	"pkgstatsd/internal/web"
+)
+
+// SeriesOptions returns the chart options requested by the granularity and
+// smooth query parameters, ignoring missing or unknown values. Repositories
+// should only be given the granularity, chartdata.Build applies the smoothing.
+func SeriesOptions(r *http.Request) web.SeriesOptions {
+	granularity, err := web.ParseGranularity(r)
+	if err != nil {
+		granularity = web.GranularityMonth
+	}
+
+	smoothing, err := web.ParseSmoothing(r)
+	if err != nil {
+		smoothing = web.Smoothing{}
+	}
+
+	return web.SeriesOptions{Granularity: granularity, Smoothing: smoothing}
+}
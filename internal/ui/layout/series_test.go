@@ -0,0 +1,29 @@
+package layout
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"pkgstatsd/internal/web"
+)
+
+func TestSeriesOptions(t *testing.T) {
+	tests := []struct {
+		url  string
+		want web.SeriesOptions
+	}{
+		{"/", web.SeriesOptions{Granularity: web.GranularityMonth}},
+		{"/?granularity=quarter", web.SeriesOptions{Granularity: web.GranularityQuarter}},
+		{"/?granularity=year&smooth=ma3", web.SeriesOptions{Granularity: web.GranularityYear, Smoothing: web.Smoothing{Method: web.SmoothMovingAverage, Window: 3}}},
+		{"/?granularity=decade&smooth=ema6", web.SeriesOptions{Granularity: web.GranularityMonth, Smoothing: web.Smoothing{Method: web.SmoothExponential, Window: 6}}},
+		{"/?smooth=ma100", web.SeriesOptions{Granularity: web.GranularityMonth}},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, tt.url, nil)
+		if got := SeriesOptions(r); got != tt.want {
+			t.Errorf("SeriesOptions(%s) = %+v, want %+v", tt.url, got, tt.want)
+		}
+	}
+}
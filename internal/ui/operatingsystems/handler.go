@@ -25,26 +25,27 @@ func NewHandler(repo operatingsystems.Repository, manifest *layout.Manifest) *Ha
 
 func (h *Handler) HandleCompare(w http.ResponseWriter, r *http.Request) {
 	endMonth := web.GetLastCompleteMonth()
+	opts := layout.SeriesOptions(r)
 
-	list, err := h.repo.FindAll(r.Context(), "", startMonth, endMonth, topLimit, 0)
+	list, err := h.repo.FindAll(r.Context(), "", startMonth, endMonth, topLimit, 0, web.ListOptions{})
 	if err != nil {
-		layout.ServerError(w, "failed to fetch operating systems", err)
+		layout.ServerError(w, r, "failed to fetch operating systems", err)
 		return
 	}
 
 	var allSeries []operatingsystems.OperatingSystemIdPopularity
 
 	for _, osID := range list.OperatingSystemIdPopularities {
-		series, err := h.repo.FindSeriesByID(r.Context(), osID.ID, startMonth, endMonth, layout.SeriesLimit, 0)
+		series, err := h.repo.FindSeriesByID(r.Context(), osID.ID, startMonth, endMonth, layout.SeriesLimit, 0, web.SeriesOptions{Granularity: opts.Granularity})
 		if err != nil {
-			layout.ServerError(w, "failed to fetch operating system series", err)
+			layout.ServerError(w, r, "failed to fetch operating system series", err)
 			return
 		}
 
 		allSeries = append(allSeries, series.OperatingSystemIdPopularities...)
 	}
 
-	data := chartdata.Build(allSeries)
+	data := chartdata.Build(allSeries, opts)
 
 	layout.Render(w, r,
 		layout.Page{Title: "Compare Operating Systems", Description: "Usage share of operating system distributions reported by Arch Linux pkgstats.", Path: "/compare/operating-systems", Manifest: h.manifest},
@@ -10,29 +10,30 @@ import (
 
 	"pkgstatsd/internal/operatingsystems"
 	"pkgstatsd/internal/ui/layout"
+	"pkgstatsd/internal/web"
 )
 
 type mockRepo struct {
-	findAllFunc        func(ctx context.Context, query string, startMonth, endMonth, limit, offset int) (*operatingsystems.OperatingSystemIdPopularityList, error)
-	findSeriesByIDFunc func(ctx context.Context, id string, startMonth, endMonth, limit, offset int) (*operatingsystems.OperatingSystemIdPopularityList, error)
+	findAllFunc        func(ctx context.Context, query string, startMonth, endMonth, limit, offset int, opts web.ListOptions) (*operatingsystems.OperatingSystemIdPopularityList, error)
+	findSeriesByIDFunc func(ctx context.Context, id string, startMonth, endMonth, limit, offset int, opts web.SeriesOptions) (*operatingsystems.OperatingSystemIdPopularityList, error)
 }
 
 func (m *mockRepo) FindByID(ctx context.Context, id string, startMonth, endMonth int) (*operatingsystems.OperatingSystemIdPopularity, error) {
 	return nil, nil
 }
 
-func (m *mockRepo) FindAll(ctx context.Context, query string, startMonth, endMonth, limit, offset int) (*operatingsystems.OperatingSystemIdPopularityList, error) {
-	return m.findAllFunc(ctx, query, startMonth, endMonth, limit, offset)
+func (m *mockRepo) FindAll(ctx context.Context, query string, startMonth, endMonth, limit, offset int, opts web.ListOptions) (*operatingsystems.OperatingSystemIdPopularityList, error) {
+	return m.findAllFunc(ctx, query, startMonth, endMonth, limit, offset, opts)
 }
 
-func (m *mockRepo) FindSeriesByID(ctx context.Context, id string, startMonth, endMonth, limit, offset int) (*operatingsystems.OperatingSystemIdPopularityList, error) {
-	return m.findSeriesByIDFunc(ctx, id, startMonth, endMonth, limit, offset)
+func (m *mockRepo) FindSeriesByID(ctx context.Context, id string, startMonth, endMonth, limit, offset int, opts web.SeriesOptions) (*operatingsystems.OperatingSystemIdPopularityList, error) {
+	return m.findSeriesByIDFunc(ctx, id, startMonth, endMonth, limit, offset, opts)
 }
 
 func TestHandleCompare(t *testing.T) {
 	manifest, _ := layout.NewManifest([]byte(`{}`))
 	repo := &mockRepo{
-		findAllFunc: func(_ context.Context, _ string, _, _, _, _ int) (*operatingsystems.OperatingSystemIdPopularityList, error) {
+		findAllFunc: func(_ context.Context, _ string, _, _, _, _ int, _ web.ListOptions) (*operatingsystems.OperatingSystemIdPopularityList, error) {
 			return &operatingsystems.OperatingSystemIdPopularityList{
 				Total: 2,
 				OperatingSystemIdPopularities: []operatingsystems.OperatingSystemIdPopularity{
@@ -41,7 +42,7 @@ func TestHandleCompare(t *testing.T) {
 				},
 			}, nil
 		},
-		findSeriesByIDFunc: func(_ context.Context, id string, _, _, _, _ int) (*operatingsystems.OperatingSystemIdPopularityList, error) {
+		findSeriesByIDFunc: func(_ context.Context, id string, _, _, _, _ int, _ web.SeriesOptions) (*operatingsystems.OperatingSystemIdPopularityList, error) {
 			return &operatingsystems.OperatingSystemIdPopularityList{
 				Total: 1,
 				OperatingSystemIdPopularities: []operatingsystems.OperatingSystemIdPopularity{
@@ -70,7 +71,7 @@ func TestHandleCompare(t *testing.T) {
 func TestHandleCompare_SeriesError(t *testing.T) {
 	manifest, _ := layout.NewManifest([]byte(`{}`))
 	repo := &mockRepo{
-		findAllFunc: func(_ context.Context, _ string, _, _, _, _ int) (*operatingsystems.OperatingSystemIdPopularityList, error) {
+		findAllFunc: func(_ context.Context, _ string, _, _, _, _ int, _ web.ListOptions) (*operatingsystems.OperatingSystemIdPopularityList, error) {
 			return &operatingsystems.OperatingSystemIdPopularityList{
 				Total: 1,
 				OperatingSystemIdPopularities: []operatingsystems.OperatingSystemIdPopularity{
@@ -78,7 +79,7 @@ func TestHandleCompare_SeriesError(t *testing.T) {
 				},
 			}, nil
 		},
-		findSeriesByIDFunc: func(_ context.Context, _ string, _, _, _, _ int) (*operatingsystems.OperatingSystemIdPopularityList, error) {
+		findSeriesByIDFunc: func(_ context.Context, _ string, _, _, _, _ int, _ web.SeriesOptions) (*operatingsystems.OperatingSystemIdPopularityList, error) {
 			return nil, errors.New("db error")
 		},
 	}
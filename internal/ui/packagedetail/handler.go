@@ -6,10 +6,15 @@ import (
 
 	"pkgstatsd/internal/chartdata"
 	"pkgstatsd/internal/packages"
+	"pkgstatsd/internal/popularity"
 	"pkgstatsd/internal/ui/layout"
 	"pkgstatsd/internal/web"
 )
 
+// forecastMonths is the number of months projected on monthly charts with
+// forecast=1.
+const forecastMonths = 6
+
 type Handler struct {
 	repo     packages.Repository
 	manifest *layout.Manifest
@@ -26,9 +31,10 @@ func (h *Handler) HandlePackageDetail(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	list, err := h.repo.FindSeriesByName(r.Context(), name, 0, web.GetLastCompleteMonth(), layout.SeriesLimit, 0)
+	opts := layout.SeriesOptions(r)
+	list, err := h.repo.FindSeriesByName(r.Context(), name, 0, web.GetLastCompleteMonth(), layout.SeriesLimit, 0, web.SeriesOptions{Granularity: opts.Granularity})
 	if err != nil {
-		layout.ServerError(w, "failed to fetch package series", err)
+		layout.ServerError(w, r, "failed to fetch package series", err)
 		return
 	}
 
@@ -37,14 +43,46 @@ func (h *Handler) HandlePackageDetail(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	data := chartdata.Build(list.PackagePopularities)
+	data := chartdata.Build(list.PackagePopularities, opts)
+
+	forecast := r.URL.Query().Get("forecast") == "1"
+	if forecast && opts.Granularity == web.GranularityMonth {
+		projection, err := h.repo.FindForecastByName(r.Context(), name, forecastMonths)
+		if err != nil {
+			layout.ServerError(w, r, "failed to fetch package forecast", err)
+			return
+		}
+
+		data.Forecast = forecastData(projection.Forecasts)
+	}
 
 	layout.Render(w, r,
 		layout.Page{Title: name + " - Package statistics", Description: "Popularity of " + name + " on Arch Linux over time.", Path: "/packages", Manifest: h.manifest, CanonicalPath: "/packages/" + url.PathEscape(name)},
-		PackageDetailContent(name, data),
+		PackageDetailContent(name, data, forecast),
 	)
 }
 
 func (h *Handler) RegisterRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("GET /packages/{name}", h.HandlePackageDetail)
 }
+
+func forecastData(points []popularity.ForecastPoint) *chartdata.Forecast {
+	if len(points) == 0 {
+		return nil
+	}
+
+	f := &chartdata.Forecast{
+		Labels: make([]int, len(points)),
+		Data:   make([]float64, len(points)),
+		Low:    make([]float64, len(points)),
+		High:   make([]float64, len(points)),
+	}
+	for i, p := range points {
+		f.Labels[i] = p.Month
+		f.Data[i] = p.Popularity
+		f.Low[i] = p.Low
+		f.High[i] = p.High
+	}
+
+	return f
+}
@@ -8,29 +8,56 @@ import (
 	"testing"
 
 	"pkgstatsd/internal/packages"
+	"pkgstatsd/internal/popularity"
 	"pkgstatsd/internal/ui/layout"
+	"pkgstatsd/internal/web"
 )
 
 type mockRepo struct {
-	findSeriesByNameFunc func(ctx context.Context, name string, startMonth, endMonth, limit, offset int) (*packages.PackagePopularityList, error)
+	findSeriesByNameFunc func(ctx context.Context, name string, startMonth, endMonth, limit, offset int, opts web.SeriesOptions) (*packages.PackagePopularityList, error)
+	findForecastFunc     func(ctx context.Context, name string, months int) (*packages.PackageForecast, error)
 }
 
 func (m *mockRepo) FindByName(ctx context.Context, name string, startMonth, endMonth int) (*packages.PackagePopularity, error) {
 	return nil, nil
 }
 
-func (m *mockRepo) FindAll(ctx context.Context, query string, startMonth, endMonth, limit, offset int) (*packages.PackagePopularityList, error) {
+func (m *mockRepo) FindAll(ctx context.Context, query string, startMonth, endMonth, limit, offset int, opts web.ListOptions) (*packages.PackagePopularityList, error) {
 	return nil, nil
 }
 
-func (m *mockRepo) FindSeriesByName(ctx context.Context, name string, startMonth, endMonth, limit, offset int) (*packages.PackagePopularityList, error) {
-	return m.findSeriesByNameFunc(ctx, name, startMonth, endMonth, limit, offset)
+func (m *mockRepo) FindSeriesByName(ctx context.Context, name string, startMonth, endMonth, limit, offset int, opts web.SeriesOptions) (*packages.PackagePopularityList, error) {
+	return m.findSeriesByNameFunc(ctx, name, startMonth, endMonth, limit, offset, opts)
+}
+
+func (m *mockRepo) FindNew(_ context.Context, _, _, _ int) (*packages.PackagePopularityList, error) {
+	return nil, nil
+}
+
+func (m *mockRepo) FindGone(_ context.Context, _, _, _ int) (*packages.GonePackageList, error) {
+	return nil, nil
+}
+
+func (m *mockRepo) Suggest(_ context.Context, _ string, _ int) (*packages.PackageSuggestions, error) {
+	return nil, nil
+}
+
+func (m *mockRepo) FindForecastByName(ctx context.Context, name string, months int) (*packages.PackageForecast, error) {
+	if m.findForecastFunc == nil {
+		return &packages.PackageForecast{Name: name}, nil
+	}
+
+	return m.findForecastFunc(ctx, name, months)
+}
+
+func (m *mockRepo) FindDiff(_ context.Context, _, _, _, _ int) (*popularity.DiffList, error) {
+	return nil, nil
 }
 
 func TestHandlePackageDetail(t *testing.T) {
 	manifest, _ := layout.NewManifest([]byte(`{}`))
 	repo := &mockRepo{
-		findSeriesByNameFunc: func(ctx context.Context, name string, _, _, _, _ int) (*packages.PackagePopularityList, error) {
+		findSeriesByNameFunc: func(ctx context.Context, name string, _, _, _, _ int, _ web.SeriesOptions) (*packages.PackagePopularityList, error) {
 			return &packages.PackagePopularityList{
 				Total: 1,
 				PackagePopularities: []packages.PackagePopularity{
@@ -57,10 +84,48 @@ func TestHandlePackageDetail(t *testing.T) {
 	}
 }
 
+func TestHandlePackageDetail_Granularity(t *testing.T) {
+	manifest, _ := layout.NewManifest([]byte(`{}`))
+	var capturedGranularity string
+	repo := &mockRepo{
+		findSeriesByNameFunc: func(ctx context.Context, name string, _, _, _, _ int, opts web.SeriesOptions) (*packages.PackagePopularityList, error) {
+			capturedGranularity = opts.Granularity
+			return &packages.PackagePopularityList{
+				Total: 1,
+				PackagePopularities: []packages.PackagePopularity{
+					{Name: name, StartMonth: 202501, EndMonth: 202503, Popularity: 10.5},
+				},
+			}, nil
+		},
+	}
+	handler := NewHandler(repo, manifest)
+
+	req := httptest.NewRequest(http.MethodGet, "/packages/pacman?granularity=quarter", nil)
+	req.SetPathValue("name", "pacman")
+	rr := httptest.NewRecorder()
+
+	handler.HandlePackageDetail(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rr.Code)
+	}
+	if capturedGranularity != web.GranularityQuarter {
+		t.Errorf("expected granularity %q, got %q", web.GranularityQuarter, capturedGranularity)
+	}
+
+	body := rr.Body.String()
+	if !strings.Contains(body, `"granularity":"quarter"`) {
+		t.Error("expected chart data to carry the granularity")
+	}
+	if !strings.Contains(body, `aria-current="page" rel="nofollow" href="?granularity=quarter"`) {
+		t.Error("expected quarterly granularity link to be active")
+	}
+}
+
 func TestHandlePackageDetail_NotFound(t *testing.T) {
 	manifest, _ := layout.NewManifest([]byte(`{}`))
 	repo := &mockRepo{
-		findSeriesByNameFunc: func(ctx context.Context, name string, _, _, _, _ int) (*packages.PackagePopularityList, error) {
+		findSeriesByNameFunc: func(ctx context.Context, name string, _, _, _, _ int, _ web.SeriesOptions) (*packages.PackagePopularityList, error) {
 			return &packages.PackagePopularityList{Total: 0}, nil
 		},
 	}
@@ -76,3 +141,68 @@ func TestHandlePackageDetail_NotFound(t *testing.T) {
 		t.Errorf("expected status 404, got %d", rr.Code)
 	}
 }
+
+func TestHandlePackageDetail_Forecast(t *testing.T) {
+	manifest, _ := layout.NewManifest([]byte(`{}`))
+	forecastCalls := 0
+	repo := &mockRepo{
+		findSeriesByNameFunc: func(ctx context.Context, name string, _, _, _, _ int, _ web.SeriesOptions) (*packages.PackagePopularityList, error) {
+			return &packages.PackagePopularityList{
+				Total: 1,
+				PackagePopularities: []packages.PackagePopularity{
+					{Name: name, StartMonth: 202501, EndMonth: 202501, Popularity: 10.5},
+				},
+			}, nil
+		},
+		findForecastFunc: func(_ context.Context, name string, months int) (*packages.PackageForecast, error) {
+			forecastCalls++
+			if months != forecastMonths {
+				t.Errorf("expected %d forecast months, got %d", forecastMonths, months)
+			}
+			return &packages.PackageForecast{Name: name, Forecasts: []popularity.ForecastPoint{
+				{Month: 202502, Popularity: 11, Interval: popularity.Interval{Low: 9, High: 13}},
+			}}, nil
+		},
+	}
+	handler := NewHandler(repo, manifest)
+
+	// The forecast is opt-in.
+	req := httptest.NewRequest(http.MethodGet, "/packages/pacman", nil)
+	req.SetPathValue("name", "pacman")
+	rr := httptest.NewRecorder()
+	handler.HandlePackageDetail(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rr.Code)
+	}
+	if forecastCalls != 0 || strings.Contains(rr.Body.String(), `"forecast"`) {
+		t.Errorf("expected no forecast without forecast=1, got %d calls", forecastCalls)
+	}
+	if !strings.Contains(rr.Body.String(), `href="?forecast=1"`) {
+		t.Error("expected a link to enable the forecast")
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/packages/pacman?forecast=1", nil)
+	req.SetPathValue("name", "pacman")
+	rr = httptest.NewRecorder()
+	handler.HandlePackageDetail(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rr.Code)
+	}
+	if !strings.Contains(rr.Body.String(), `"forecast":{"labels":[202502],"data":[11],"low":[9],"high":[13]}`) {
+		t.Error("expected chart data to contain the forecast")
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/packages/pacman?granularity=year&forecast=1", nil)
+	req.SetPathValue("name", "pacman")
+	rr = httptest.NewRecorder()
+	handler.HandlePackageDetail(rr, req)
+
+	if forecastCalls != 1 {
+		t.Errorf("expected no forecast for yearly charts, got %d calls", forecastCalls)
+	}
+	if strings.Contains(rr.Body.String(), `"forecast"`) {
+		t.Error("expected no forecast in yearly chart data")
+	}
+}
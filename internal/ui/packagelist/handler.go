@@ -40,9 +40,9 @@ func (h *Handler) HandlePackages(w http.ResponseWriter, r *http.Request) {
 	var list *packages.PackagePopularityList
 	if query != "" {
 		var err error
-		list, err = h.repo.FindAll(r.Context(), query, currentMonth, currentMonth, limit, offset)
+		list, err = h.repo.FindAll(r.Context(), query, currentMonth, currentMonth, limit, offset, web.ListOptions{})
 		if err != nil {
-			layout.ServerError(w, "failed to fetch packages", err)
+			layout.ServerError(w, r, "failed to fetch packages", err)
 			return
 		}
 	} else {
@@ -51,7 +51,7 @@ func (h *Handler) HandlePackages(w http.ResponseWriter, r *http.Request) {
 
 	selectedPackages, err := h.fetchComparePackages(r, compare, currentMonth)
 	if err != nil {
-		layout.ServerError(w, "failed to fetch compare packages", err)
+		layout.ServerError(w, r, "failed to fetch compare packages", err)
 		return
 	}
 
@@ -9,11 +9,13 @@ import (
 	"testing"
 
 	"pkgstatsd/internal/packages"
+	"pkgstatsd/internal/popularity"
 	"pkgstatsd/internal/ui/layout"
+	"pkgstatsd/internal/web"
 )
 
 type mockRepo struct {
-	findAllFunc    func(ctx context.Context, query string, startMonth, endMonth, limit, offset int) (*packages.PackagePopularityList, error)
+	findAllFunc    func(ctx context.Context, query string, startMonth, endMonth, limit, offset int, opts web.ListOptions) (*packages.PackagePopularityList, error)
 	findByNameFunc func(ctx context.Context, name string, startMonth, endMonth int) (*packages.PackagePopularity, error)
 }
 
@@ -24,21 +26,41 @@ func (m *mockRepo) FindByName(ctx context.Context, name string, startMonth, endM
 	return &packages.PackagePopularity{Name: name, Popularity: 5.0}, nil
 }
 
-func (m *mockRepo) FindAll(ctx context.Context, query string, startMonth, endMonth, limit, offset int) (*packages.PackagePopularityList, error) {
+func (m *mockRepo) FindAll(ctx context.Context, query string, startMonth, endMonth, limit, offset int, opts web.ListOptions) (*packages.PackagePopularityList, error) {
 	if m.findAllFunc != nil {
-		return m.findAllFunc(ctx, query, startMonth, endMonth, limit, offset)
+		return m.findAllFunc(ctx, query, startMonth, endMonth, limit, offset, opts)
 	}
 	return &packages.PackagePopularityList{Total: 0}, nil
 }
 
-func (m *mockRepo) FindSeriesByName(ctx context.Context, name string, startMonth, endMonth, limit, offset int) (*packages.PackagePopularityList, error) {
+func (m *mockRepo) FindSeriesByName(ctx context.Context, name string, startMonth, endMonth, limit, offset int, opts web.SeriesOptions) (*packages.PackagePopularityList, error) {
+	return nil, nil
+}
+
+func (m *mockRepo) FindNew(_ context.Context, _, _, _ int) (*packages.PackagePopularityList, error) {
+	return nil, nil
+}
+
+func (m *mockRepo) FindGone(_ context.Context, _, _, _ int) (*packages.GonePackageList, error) {
+	return nil, nil
+}
+
+func (m *mockRepo) Suggest(_ context.Context, _ string, _ int) (*packages.PackageSuggestions, error) {
+	return nil, nil
+}
+
+func (m *mockRepo) FindForecastByName(_ context.Context, _ string, _ int) (*packages.PackageForecast, error) {
+	return nil, nil
+}
+
+func (m *mockRepo) FindDiff(_ context.Context, _, _, _, _ int) (*popularity.DiffList, error) {
 	return nil, nil
 }
 
 func TestHandlePackages(t *testing.T) {
 	manifest, _ := layout.NewManifest([]byte(`{}`))
 	repo := &mockRepo{
-		findAllFunc: func(ctx context.Context, query string, _, _, _, _ int) (*packages.PackagePopularityList, error) {
+		findAllFunc: func(ctx context.Context, query string, _, _, _, _ int, _ web.ListOptions) (*packages.PackagePopularityList, error) {
 			t.Error("FindAll should not be called without a query")
 			return &packages.PackagePopularityList{Total: 0}, nil
 		},
@@ -63,7 +85,7 @@ func TestHandlePackages(t *testing.T) {
 func TestHandlePackages_WithQuery(t *testing.T) {
 	manifest, _ := layout.NewManifest([]byte(`{}`))
 	repo := &mockRepo{
-		findAllFunc: func(ctx context.Context, query string, _, _, _, _ int) (*packages.PackagePopularityList, error) {
+		findAllFunc: func(ctx context.Context, query string, _, _, _, _ int, _ web.ListOptions) (*packages.PackagePopularityList, error) {
 			return &packages.PackagePopularityList{
 				Total: 1,
 				PackagePopularities: []packages.PackagePopularity{
@@ -100,7 +122,7 @@ func TestHandlePackages_WithCompare(t *testing.T) {
 			lookedUp = append(lookedUp, name)
 			return &packages.PackagePopularity{Name: name, Popularity: 5.0}, nil
 		},
-		findAllFunc: func(ctx context.Context, query string, _, _, _, _ int) (*packages.PackagePopularityList, error) {
+		findAllFunc: func(ctx context.Context, query string, _, _, _, _ int, _ web.ListOptions) (*packages.PackagePopularityList, error) {
 			t.Error("FindAll should not be called when compare is set without query")
 			return &packages.PackagePopularityList{Total: 0}, nil
 		},
@@ -151,7 +173,7 @@ func TestHandlePackages_WithCompareAndQuery(t *testing.T) {
 	manifest, _ := layout.NewManifest([]byte(`{}`))
 	findAllCalled := false
 	repo := &mockRepo{
-		findAllFunc: func(ctx context.Context, query string, _, _, _, _ int) (*packages.PackagePopularityList, error) {
+		findAllFunc: func(ctx context.Context, query string, _, _, _, _ int, _ web.ListOptions) (*packages.PackagePopularityList, error) {
 			findAllCalled = true
 			return &packages.PackagePopularityList{
 				Total: 1,
@@ -192,7 +214,7 @@ func TestHandlePackages_CompareExceedsSelectLimit(t *testing.T) {
 	}
 
 	repo := &mockRepo{
-		findAllFunc: func(_ context.Context, _ string, _, _, _, _ int) (*packages.PackagePopularityList, error) {
+		findAllFunc: func(_ context.Context, _ string, _, _, _, _ int, _ web.ListOptions) (*packages.PackagePopularityList, error) {
 			return &packages.PackagePopularityList{
 				Total: 1,
 				PackagePopularities: []packages.PackagePopularity{
@@ -81,17 +81,18 @@ func (h *Handler) HandleCompare(w http.ResponseWriter, r *http.Request) {
 	var allSeries []systemarchitectures.SystemArchitecturePopularity
 
 	endMonth := min(p.EndMonth, web.GetLastCompleteMonth())
+	opts := layout.SeriesOptions(r)
 	for _, arch := range p.Architectures {
-		list, err := h.repo.FindSeriesByName(r.Context(), arch, p.StartMonth, endMonth, layout.SeriesLimit, 0)
+		list, err := h.repo.FindSeriesByName(r.Context(), arch, p.StartMonth, endMonth, layout.SeriesLimit, 0, web.SeriesOptions{Granularity: opts.Granularity})
 		if err != nil {
-			layout.ServerError(w, "failed to fetch architecture series", err)
+			layout.ServerError(w, r, "failed to fetch architecture series", err)
 			return
 		}
 
 		allSeries = append(allSeries, list.SystemArchitecturePopularities...)
 	}
 
-	data := chartdata.Build(allSeries)
+	data := chartdata.Build(allSeries, opts)
 
 	layout.Render(w, r,
 		layout.Page{Title: "Compare System Architectures", Description: "Usage share of CPU architectures reported by Arch Linux pkgstats.", Path: "/compare/system-architectures", Manifest: h.manifest, CanonicalPath: "/compare/system-architectures/" + p.Label},
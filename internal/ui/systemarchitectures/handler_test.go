@@ -10,28 +10,29 @@ import (
 
 	"pkgstatsd/internal/systemarchitectures"
 	"pkgstatsd/internal/ui/layout"
+	"pkgstatsd/internal/web"
 )
 
 type mockRepo struct {
-	findSeriesByNameFunc func(ctx context.Context, name string, startMonth, endMonth, limit, offset int) (*systemarchitectures.SystemArchitecturePopularityList, error)
+	findSeriesByNameFunc func(ctx context.Context, name string, startMonth, endMonth, limit, offset int, opts web.SeriesOptions) (*systemarchitectures.SystemArchitecturePopularityList, error)
 }
 
 func (m *mockRepo) FindByName(ctx context.Context, name string, startMonth, endMonth int) (*systemarchitectures.SystemArchitecturePopularity, error) {
 	return nil, nil
 }
 
-func (m *mockRepo) FindAll(ctx context.Context, query string, startMonth, endMonth, limit, offset int) (*systemarchitectures.SystemArchitecturePopularityList, error) {
+func (m *mockRepo) FindAll(ctx context.Context, query string, startMonth, endMonth, limit, offset int, opts web.ListOptions) (*systemarchitectures.SystemArchitecturePopularityList, error) {
 	return nil, nil
 }
 
-func (m *mockRepo) FindSeriesByName(ctx context.Context, name string, startMonth, endMonth, limit, offset int) (*systemarchitectures.SystemArchitecturePopularityList, error) {
-	return m.findSeriesByNameFunc(ctx, name, startMonth, endMonth, limit, offset)
+func (m *mockRepo) FindSeriesByName(ctx context.Context, name string, startMonth, endMonth, limit, offset int, opts web.SeriesOptions) (*systemarchitectures.SystemArchitecturePopularityList, error) {
+	return m.findSeriesByNameFunc(ctx, name, startMonth, endMonth, limit, offset, opts)
 }
 
 func TestHandleCompare(t *testing.T) {
 	manifest, _ := layout.NewManifest([]byte(`{}`))
 	repo := &mockRepo{
-		findSeriesByNameFunc: func(ctx context.Context, name string, _, _, _, _ int) (*systemarchitectures.SystemArchitecturePopularityList, error) {
+		findSeriesByNameFunc: func(ctx context.Context, name string, _, _, _, _ int, _ web.SeriesOptions) (*systemarchitectures.SystemArchitecturePopularityList, error) {
 			return &systemarchitectures.SystemArchitecturePopularityList{
 				Total: 1,
 				SystemArchitecturePopularities: []systemarchitectures.SystemArchitecturePopularity{
@@ -61,7 +62,7 @@ func TestHandleCompare(t *testing.T) {
 func TestHandleCompare_SeriesError(t *testing.T) {
 	manifest, _ := layout.NewManifest([]byte(`{}`))
 	repo := &mockRepo{
-		findSeriesByNameFunc: func(_ context.Context, _ string, _, _, _, _ int) (*systemarchitectures.SystemArchitecturePopularityList, error) {
+		findSeriesByNameFunc: func(_ context.Context, _ string, _, _, _, _ int, _ web.SeriesOptions) (*systemarchitectures.SystemArchitecturePopularityList, error) {
 			return nil, errors.New("db error")
 		},
 	}
@@ -0,0 +1,86 @@
+package web
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// RequestIDHeader carries the request ID, propagated from the request or
+// generated by AccessLog, and is echoed in the response.
+const RequestIDHeader = "X-Request-ID"
+
+const (
+	maxRequestIDLength = 128
+	requestIDBytes     = 16
+)
+
+type requestIDKey struct{}
+
+// RequestID returns the ID AccessLog stored in ctx, or an empty string.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// responseRequestID returns the request ID AccessLog set on the response, so
+// error helpers that only get the ResponseWriter can report it.
+func responseRequestID(w http.ResponseWriter) string {
+	return w.Header().Get(RequestIDHeader)
+}
+
+// AccessLog logs every request with its route pattern, as recorded by
+// Metrics, status, response size and duration. It takes the request ID from
+// the X-Request-ID header or generates one, stores it in the request context
+// and sets it on the response. It is the outermost middleware, so it logs the
+// response as sent after compression and error pages.
+func AccessLog() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+
+			id := r.Header.Get(RequestIDHeader)
+			if !validRequestID(id) {
+				id = newRequestID()
+			}
+			w.Header().Set(RequestIDHeader, id)
+			r = r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id))
+			r, route := withRouteRecord(r)
+
+			sw := &statusWriter{ResponseWriter: w}
+			next.ServeHTTP(sw, r)
+
+			slog.Info("request",
+				"method", r.Method,
+				"route", route.route(),
+				"status", sw.Status(),
+				"bytes", sw.bytes,
+				"duration", time.Since(start),
+				"request_id", id,
+			)
+		})
+	}
+}
+
+// validRequestID reports whether a propagated request ID is safe to log and
+// echo: non-empty, bounded and limited to printable ASCII without spaces.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := range len(id) {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, requestIDBytes)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
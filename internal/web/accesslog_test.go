@@ -0,0 +1,108 @@
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func captureLog(t *testing.T) *bytes.Buffer {
+	t.Helper()
+
+	var buf bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&buf, nil)))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	return &buf
+}
+
+func TestAccessLog(t *testing.T) {
+	logs := captureLog(t)
+
+	var contextID string
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/packages/{name}", func(w http.ResponseWriter, r *http.Request) {
+		contextID = RequestID(r.Context())
+		_, _ = w.Write([]byte("hello"))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/packages/pacman", nil)
+	req.Header.Set(RequestIDHeader, "abc-123")
+	rec := httptest.NewRecorder()
+	Chain(mux, AccessLog(), Recovery(), Metrics()).ServeHTTP(rec, req)
+
+	if got := rec.Header().Get(RequestIDHeader); got != "abc-123" {
+		t.Errorf("expected propagated request ID, got %q", got)
+	}
+	if contextID != "abc-123" {
+		t.Errorf("expected request ID in context, got %q", contextID)
+	}
+
+	var entry map[string]any
+	if err := json.Unmarshal(logs.Bytes(), &entry); err != nil {
+		t.Fatalf("decode log entry: %v", err)
+	}
+	for key, want := range map[string]any{
+		"method":     "GET",
+		"route":      "GET /api/packages/{name}",
+		"status":     float64(200),
+		"bytes":      float64(5),
+		"request_id": "abc-123",
+	} {
+		if entry[key] != want {
+			t.Errorf("expected %s %v, got %v", key, want, entry[key])
+		}
+	}
+	if _, ok := entry["duration"]; !ok {
+		t.Error("expected duration in log entry")
+	}
+}
+
+func TestAccessLog_GeneratesRequestID(t *testing.T) {
+	captureLog(t)
+
+	handler := AccessLog()(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {}))
+
+	for _, header := range []string{"", "has space", strings.Repeat("a", 129), "bad\nline"} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if header != "" {
+			req.Header.Set(RequestIDHeader, header)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if got := rec.Header().Get(RequestIDHeader); len(got) != 32 {
+			t.Errorf("expected a generated request ID for %q, got %q", header, got)
+		}
+	}
+}
+
+func TestAccessLog_ServerError(t *testing.T) {
+	logs := captureLog(t)
+
+	handler := AccessLog()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ServerError(w, r, "query failed", errors.New("connection refused"))
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/packages", nil)
+	req.Header.Set(RequestIDHeader, "abc-123")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	var pd ProblemDetails
+	if err := json.Unmarshal(rec.Body.Bytes(), &pd); err != nil {
+		t.Fatalf("decode problem: %v", err)
+	}
+	if !strings.Contains(rec.Body.String(), `"requestId":"abc-123"`) {
+		t.Errorf("expected request ID in problem details, got %s", rec.Body.String())
+	}
+	if !strings.Contains(logs.String(), `"msg":"query failed","error":"connection refused","request_id":"abc-123"`) {
+		t.Errorf("expected request ID in error log, got:\n%s", logs.String())
+	}
+}
@@ -0,0 +1,293 @@
+package web
+
+import (
+	"bufio"
+	"compress/gzip"
+	"io"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	encodingBrotli = "br"
+	encodingZstd   = "zstd"
+	encodingGzip   = "gzip"
+
+	// minCompressSize is the smallest body worth compressing; smaller
+	// responses are sent as is.
+	minCompressSize = 1024
+)
+
+// encodingPreference lists the supported encodings, best first. It breaks
+// ties between encodings the client accepts with the same quality.
+var encodingPreference = []string{encodingBrotli, encodingZstd, encodingGzip}
+
+var compressibleTypes = []string{
+	"text/",
+	"application/json",
+	"application/javascript",
+	"application/xml",
+	"application/manifest+json",
+	"image/svg+xml",
+}
+
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+var encoderPools = map[string]*sync.Pool{
+	encodingBrotli: {New: func() any { return brotli.NewWriterLevel(nil, brotli.DefaultCompression) }},
+	encodingZstd: {New: func() any {
+		w, _ := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedDefault), zstd.WithEncoderConcurrency(1))
+		return w
+	}},
+	encodingGzip: {New: func() any { return gzip.NewWriter(nil) }},
+}
+
+// Compress encodes responses with brotli, zstd or gzip as negotiated via
+// Accept-Encoding. Files under /assets/ are already compressed and served
+// as is, as are problem+json errors, non-text content types and bodies
+// smaller than minCompressSize.
+func Compress() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if strings.HasPrefix(r.URL.Path, "/assets/") {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Add("Vary", "Accept-Encoding")
+
+			encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+			if encoding == "" || r.Method == http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			cw := &compressWriter{ResponseWriter: w, encoding: encoding, status: http.StatusOK}
+			defer func() { _ = cw.Close() }()
+			next.ServeHTTP(cw, r)
+		})
+	}
+}
+
+// negotiateEncoding picks the supported encoding with the highest quality in
+// an Accept-Encoding header, or an empty string for identity.
+func negotiateEncoding(header string) string {
+	qualities := make(map[string]float64)
+	for part := range strings.SplitSeq(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		qualities[name] = q
+	}
+
+	best, bestQ := "", 0.0
+	for _, encoding := range encodingPreference {
+		q, ok := qualities[encoding]
+		if !ok {
+			q, ok = qualities["*"]
+		}
+		if ok && q > bestQ {
+			best, bestQ = encoding, q
+		}
+	}
+
+	return best
+}
+
+// compressWriter buffers the start of a response until it knows whether the
+// body is worth compressing, then either streams it through an encoder or
+// passes it on unchanged.
+type compressWriter struct {
+	http.ResponseWriter
+	encoding    string
+	status      int
+	wroteHeader bool
+	decided     bool
+	buf         []byte
+	enc         encoder
+}
+
+func (w *compressWriter) WriteHeader(code int) {
+	if w.wroteHeader {
+		return
+	}
+	if code >= 100 && code < 200 {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+
+	w.wroteHeader = true
+	w.status = code
+	if !w.eligible() {
+		w.decide(false)
+	}
+}
+
+func (w *compressWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	if !w.decided {
+		w.buf = append(w.buf, b...)
+		if len(w.buf) < minCompressSize {
+			return len(b), nil
+		}
+
+		buffered := w.buf
+		w.buf = nil
+		if err := w.start(buffered); err != nil {
+			return 0, err
+		}
+		return len(b), nil
+	}
+
+	if w.enc != nil {
+		return w.enc.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Close sends a buffered body too small for compression and finishes the
+// encoder.
+func (w *compressWriter) Close() error {
+	if !w.decided {
+		if !w.wroteHeader {
+			return nil
+		}
+
+		w.decide(false)
+		if len(w.buf) > 0 {
+			if _, err := w.ResponseWriter.Write(w.buf); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if w.enc == nil {
+		return nil
+	}
+
+	err := w.enc.Close()
+	w.enc.Reset(io.Discard)
+	encoderPools[w.encoding].Put(w.enc)
+	w.enc = nil
+
+	return err
+}
+
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		if !w.wroteHeader {
+			w.WriteHeader(http.StatusOK)
+		}
+
+		buffered := w.buf
+		w.buf = nil
+		_ = w.start(buffered)
+	}
+
+	if w.enc != nil {
+		_ = w.enc.Flush()
+	}
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+func (w *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(w.ResponseWriter).Hijack()
+}
+
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// start decides on compression once the body is known to be large enough,
+// or is being flushed, and writes the buffered bytes.
+func (w *compressWriter) start(buffered []byte) error {
+	h := w.Header()
+	if h.Get("Content-Type") == "" && len(buffered) > 0 {
+		h.Set("Content-Type", http.DetectContentType(buffered))
+	}
+
+	w.decide(w.eligible())
+	if len(buffered) == 0 {
+		return nil
+	}
+
+	var err error
+	if w.enc != nil {
+		_, err = w.enc.Write(buffered)
+	} else {
+		_, err = w.ResponseWriter.Write(buffered)
+	}
+	return err
+}
+
+func (w *compressWriter) decide(compress bool) {
+	w.decided = true
+	if compress {
+		h := w.Header()
+		h.Del("Content-Length")
+		h.Del("Accept-Ranges")
+		h.Set("Content-Encoding", w.encoding)
+
+		w.enc = encoderPools[w.encoding].Get().(encoder)
+		w.enc.Reset(w.ResponseWriter)
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+}
+
+func (w *compressWriter) eligible() bool {
+	switch w.status {
+	case http.StatusNoContent, http.StatusNotModified, http.StatusPartialContent:
+		return false
+	}
+
+	h := w.Header()
+	if h.Get("Content-Encoding") != "" {
+		return false
+	}
+
+	if length, err := strconv.Atoi(h.Get("Content-Length")); err == nil && length < minCompressSize {
+		return false
+	}
+
+	contentType := h.Get("Content-Type")
+	if contentType == "" {
+		// Detected from the body once it is buffered.
+		return true
+	}
+
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.TrimSpace(mediaType)
+	if mediaType == "application/problem+json" {
+		return false
+	}
+
+	return slices.ContainsFunc(compressibleTypes, func(prefix string) bool {
+		return strings.HasPrefix(mediaType, prefix)
+	})
+}
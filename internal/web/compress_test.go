@@ -0,0 +1,215 @@
+package web
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"", ""},
+		{"identity", ""},
+		{"gzip", "gzip"},
+		{"gzip, deflate, br, zstd", "br"},
+		{"gzip, zstd", "zstd"},
+		{"br;q=0.5, gzip", "gzip"},
+		{"br;q=0, gzip;q=0", ""},
+		{"GZIP", "gzip"},
+		{"*", "br"},
+		{"*;q=0.1, gzip;q=0.5", "gzip"},
+		{"br;q=abc, gzip", "gzip"},
+	}
+
+	for _, tt := range tests {
+		if got := negotiateEncoding(tt.header); got != tt.want {
+			t.Errorf("negotiateEncoding(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
+
+func decode(t *testing.T, encoding string, body io.Reader) string {
+	t.Helper()
+
+	var r io.Reader
+	switch encoding {
+	case encodingGzip:
+		gr, err := gzip.NewReader(body)
+		if err != nil {
+			t.Fatalf("gzip reader: %v", err)
+		}
+		r = gr
+	case encodingZstd:
+		zr, err := zstd.NewReader(body)
+		if err != nil {
+			t.Fatalf("zstd reader: %v", err)
+		}
+		defer zr.Close()
+		r = zr
+	case encodingBrotli:
+		r = brotli.NewReader(body)
+	default:
+		r = body
+	}
+
+	b, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("decode %s: %v", encoding, err)
+	}
+	return string(b)
+}
+
+func serveCompressed(h http.HandlerFunc, path, acceptEncoding string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.Header.Set("Accept-Encoding", acceptEncoding)
+	rr := httptest.NewRecorder()
+	Compress()(h).ServeHTTP(rr, req)
+	return rr
+}
+
+func TestCompress(t *testing.T) {
+	body := strings.Repeat(`{"name":"pacman","popularity":99.99},`, 100)
+	h := func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		// Written in chunks to cross the buffering threshold mid-write.
+		for i := 0; i < len(body); i += 100 {
+			_, _ = io.WriteString(w, body[i:min(i+100, len(body))])
+		}
+	}
+
+	for _, encoding := range []string{encodingGzip, encodingZstd, encodingBrotli} {
+		t.Run(encoding, func(t *testing.T) {
+			rr := serveCompressed(h, "/api/packages", encoding)
+
+			if got := rr.Header().Get("Content-Encoding"); got != encoding {
+				t.Fatalf("expected Content-Encoding %q, got %q", encoding, got)
+			}
+			if got := rr.Header().Get("Vary"); got != "Accept-Encoding" {
+				t.Errorf("expected Vary: Accept-Encoding, got %q", got)
+			}
+			if rr.Body.Len() >= len(body) {
+				t.Errorf("expected compressed body smaller than %d, got %d", len(body), rr.Body.Len())
+			}
+			if got := decode(t, encoding, rr.Body); got != body {
+				t.Error("decoded body does not match")
+			}
+		})
+	}
+}
+
+func TestCompress_WriteEntityJSON(t *testing.T) {
+	items := make([]string, 200)
+	for i := range items {
+		items[i] = "package"
+	}
+	h := func(w http.ResponseWriter, r *http.Request) {
+		WriteEntityJSON(w, r, Validators{}, items)
+	}
+
+	rr := serveCompressed(h, "/api/packages", "gzip")
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+	if rr.Header().Get("Content-Encoding") != encodingGzip {
+		t.Fatalf("expected gzip encoding, got %q", rr.Header().Get("Content-Encoding"))
+	}
+	if rr.Header().Get("Content-Length") != "" {
+		t.Errorf("expected Content-Length to be removed, got %q", rr.Header().Get("Content-Length"))
+	}
+	if got := decode(t, encodingGzip, rr.Body); !strings.HasPrefix(got, `["package",`) {
+		t.Errorf("unexpected body %q", got)
+	}
+}
+
+func TestCompress_Skipped(t *testing.T) {
+	large := strings.Repeat("a", 2*minCompressSize)
+
+	tests := []struct {
+		name           string
+		path           string
+		acceptEncoding string
+		handler        http.HandlerFunc
+	}{
+		{"no accept-encoding", "/", "", func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = io.WriteString(w, large)
+		}},
+		{"assets", "/assets/main.js", "gzip", func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", "text/javascript")
+			_, _ = io.WriteString(w, large)
+		}},
+		{"small body", "/", "gzip", func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = io.WriteString(w, "<p>hello</p>")
+		}},
+		{"problem json", "/api/packages", "gzip", func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", "application/problem+json")
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = io.WriteString(w, large)
+		}},
+		{"image", "/static/logo.png", "gzip", func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", "image/png")
+			_, _ = io.WriteString(w, large)
+		}},
+		{"already encoded", "/", "gzip", func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", "text/html")
+			w.Header().Set("Content-Encoding", "br")
+			_, _ = io.WriteString(w, large)
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := serveCompressed(tt.handler, tt.path, tt.acceptEncoding)
+
+			if got := rr.Header().Get("Content-Encoding"); got != "" && got != "br" {
+				t.Errorf("expected no compression, got Content-Encoding %q", got)
+			}
+			if rr.Body.Len() == 0 || strings.Contains(rr.Body.String(), "\x1f\x8b") {
+				t.Errorf("expected unmodified body, got %d bytes", rr.Body.Len())
+			}
+		})
+	}
+}
+
+func TestCompress_NotModified(t *testing.T) {
+	h := func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotModified)
+	}
+
+	rr := serveCompressed(h, "/api/packages", "gzip")
+
+	if rr.Code != http.StatusNotModified {
+		t.Fatalf("expected status %d, got %d", http.StatusNotModified, rr.Code)
+	}
+	if rr.Header().Get("Content-Encoding") != "" || rr.Body.Len() != 0 {
+		t.Errorf("expected empty unencoded response, got %q with %d bytes", rr.Header().Get("Content-Encoding"), rr.Body.Len())
+	}
+}
+
+func TestCompress_DetectsContentType(t *testing.T) {
+	body := "<!DOCTYPE html><html>" + strings.Repeat("<p>pkgstats</p>", 100) + "</html>"
+	h := func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, body)
+	}
+
+	rr := serveCompressed(h, "/", "gzip")
+
+	if !strings.HasPrefix(rr.Header().Get("Content-Type"), "text/html") {
+		t.Errorf("expected detected text/html, got %q", rr.Header().Get("Content-Type"))
+	}
+	if got := decode(t, rr.Header().Get("Content-Encoding"), rr.Body); got != body {
+		t.Error("decoded body does not match")
+	}
+}
@@ -0,0 +1,136 @@
+package web
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type (
+	writeGenerationKey struct{}
+	dataVersionKey     struct{}
+)
+
+// DataVersion makes version available to CheckNotModified, which adds it to
+// every ETag and takes Last-Modified of complete months from it. version
+// returns a counter and the time it last changed; it has to change whenever
+// data of complete months does, e.g. by an import, on every replica.
+func DataVersion(version func() (int64, time.Time)) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), dataVersionKey{}, version)))
+		})
+	}
+}
+
+// WriteGeneration makes generation available to CheckNotModified, which adds
+// it to the ETag of responses covering the current month. generation has to
+// change with every write to the current month, on every replica.
+func WriteGeneration(generation func(ctx context.Context) (int64, error)) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), writeGenerationKey{}, generation)))
+		})
+	}
+}
+
+// Validators identify the version of an API response. The zero value makes
+// WriteEntityJSON hash the response body instead.
+type Validators struct {
+	etag         string
+	lastModified time.Time
+}
+
+// CheckNotModified derives the validators of the response to r, which
+// covers data up to endMonth, without querying it: the ETag hashes the
+// request URL, the last complete month, the data version and, if endMonth
+// is the current month, the write generation. Last-Modified is set only for
+// responses of complete months, which became final when the current month
+// started or the data version last changed, whichever is later. If the
+// client's copy is current, it responds with 304 Not Modified and returns
+// done. Handlers call it after validating the request and pass the
+// validators on to WriteEntityJSON.
+func CheckNotModified(w http.ResponseWriter, r *http.Request, endMonth int) (validators Validators, done bool) {
+	dataVersion, ok := r.Context().Value(dataVersionKey{}).(func() (int64, time.Time))
+	if !ok {
+		return Validators{}, false
+	}
+	version, modified := dataVersion()
+
+	hash := fnv.New64a()
+	_, _ = fmt.Fprintf(hash, "%s?%s\n%d\n%d", r.URL.Path, r.URL.Query().Encode(), GetLastCompleteMonth(), version)
+
+	if endMonth >= GetCurrentMonth() {
+		generation, ok := r.Context().Value(writeGenerationKey{}).(func(context.Context) (int64, error))
+		if !ok {
+			return Validators{}, false
+		}
+		g, err := generation(r.Context())
+		if err != nil {
+			slog.Warn("failed to read write generation", "error", err)
+			return Validators{}, false
+		}
+		_, _ = fmt.Fprintf(hash, "\n%d", g)
+	} else {
+		validators.lastModified = lastCompleteMonthEnd()
+		if modified.After(validators.lastModified) {
+			validators.lastModified = modified
+		}
+	}
+	validators.etag = fmt.Sprintf(`W/"%016x"`, hash.Sum64())
+
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return validators, false
+	}
+	if !notModified(r, validators) {
+		return validators, false
+	}
+
+	validators.setHeaders(w)
+	setAPICacheControl(w, apiCacheMaxAge)
+	w.WriteHeader(http.StatusNotModified)
+
+	return validators, true
+}
+
+func (v Validators) setHeaders(w http.ResponseWriter) {
+	w.Header().Set("ETag", v.etag)
+	if !v.lastModified.IsZero() {
+		w.Header().Set("Last-Modified", v.lastModified.Format(http.TimeFormat))
+	}
+}
+
+// notModified evaluates If-None-Match, or without it If-Modified-Since, the
+// way http.ServeContent does.
+func notModified(r *http.Request, v Validators) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for tag := range strings.SplitSeq(inm, ",") {
+			tag = strings.TrimSpace(tag)
+			if tag == "*" || strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(v.etag, "W/") {
+				return true
+			}
+		}
+		return false
+	}
+
+	if v.lastModified.IsZero() {
+		return false
+	}
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+
+	return !v.lastModified.Truncate(time.Second).After(since)
+}
+
+// lastCompleteMonthEnd returns the start of the current month, when data of
+// the last complete month became final.
+func lastCompleteMonthEnd() time.Time {
+	now := time.Now().UTC()
+	return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,185 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// unmodifiedData is a data version that never changed.
+func unmodifiedData() (int64, time.Time) { return 0, time.Time{} }
+
+// checkNotModified serves target with CheckNotModified for data up to
+// endMonth, followed by WriteEntityJSON unless it responded.
+func checkNotModified(t *testing.T, target string, endMonth int, header http.Header, dataVersion func() (int64, time.Time), generation func(context.Context) (int64, error)) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var handler http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		validators, done := CheckNotModified(w, r, endMonth)
+		if done {
+			return
+		}
+		WriteEntityJSON(w, r, validators, map[string]string{"key": "value"})
+	})
+	if generation != nil {
+		handler = WriteGeneration(generation)(handler)
+	}
+	if dataVersion != nil {
+		handler = DataVersion(dataVersion)(handler)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	return rr
+}
+
+func TestCheckNotModified_CompleteMonths(t *testing.T) {
+	month := GetLastCompleteMonth()
+
+	rr := checkNotModified(t, "/api/test?b=2&a=1", month, nil, unmodifiedData, nil)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+	etag := rr.Header().Get("ETag")
+	if !strings.HasPrefix(etag, `W/"`) {
+		t.Fatalf("expected weak ETag, got %q", etag)
+	}
+	lastModified := rr.Header().Get("Last-Modified")
+	if want := lastCompleteMonthEnd().Format(http.TimeFormat); lastModified != want {
+		t.Errorf("expected Last-Modified %q, got %q", want, lastModified)
+	}
+
+	tests := []struct {
+		name   string
+		target string
+		header string
+		value  string
+		want   int
+	}{
+		{"matching ETag", "/api/test?b=2&a=1", "If-None-Match", etag, http.StatusNotModified},
+		{"reordered query", "/api/test?a=1&b=2", "If-None-Match", etag, http.StatusNotModified},
+		{"listed ETag", "/api/test?a=1&b=2", "If-None-Match", `W/"0", ` + etag, http.StatusNotModified},
+		{"other query", "/api/test?a=1&b=3", "If-None-Match", etag, http.StatusOK},
+		{"other ETag", "/api/test?a=1&b=2", "If-None-Match", `W/"0"`, http.StatusOK},
+		{"not modified since", "/api/test", "If-Modified-Since", lastModified, http.StatusNotModified},
+		{"modified since", "/api/test", "If-Modified-Since", lastCompleteMonthEnd().AddDate(0, 0, -1).Format(http.TimeFormat), http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := checkNotModified(t, tt.target, month, http.Header{tt.header: {tt.value}}, unmodifiedData, nil)
+			if rr.Code != tt.want {
+				t.Fatalf("expected status %d, got %d", tt.want, rr.Code)
+			}
+			if tt.want == http.StatusNotModified {
+				if rr.Body.Len() != 0 {
+					t.Errorf("expected empty body, got %q", rr.Body.String())
+				}
+				if !strings.Contains(rr.Header().Get("Cache-Control"), "s-maxage=") {
+					t.Errorf("expected Cache-Control on 304, got %q", rr.Header().Get("Cache-Control"))
+				}
+			}
+		})
+	}
+}
+
+func TestCheckNotModified_CurrentMonth(t *testing.T) {
+	month := GetCurrentMonth()
+	var generation int64 = 1
+	readGeneration := func(context.Context) (int64, error) { return generation, nil }
+
+	rr := checkNotModified(t, "/api/test", month, nil, unmodifiedData, readGeneration)
+	etag := rr.Header().Get("ETag")
+	if lastModified := rr.Header().Get("Last-Modified"); lastModified != "" {
+		t.Errorf("expected no Last-Modified for the current month, got %q", lastModified)
+	}
+
+	rr = checkNotModified(t, "/api/test", month, http.Header{"If-None-Match": {etag}}, unmodifiedData, readGeneration)
+	if rr.Code != http.StatusNotModified {
+		t.Errorf("expected status %d before a write, got %d", http.StatusNotModified, rr.Code)
+	}
+
+	generation++
+	rr = checkNotModified(t, "/api/test", month, http.Header{"If-None-Match": {etag}}, unmodifiedData, readGeneration)
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected status %d after a write, got %d", http.StatusOK, rr.Code)
+	}
+	if rr.Header().Get("ETag") == etag {
+		t.Error("expected the ETag to change after a write")
+	}
+}
+
+func TestCheckNotModified_NoGeneration(t *testing.T) {
+	month := GetCurrentMonth()
+	failing := func(context.Context) (int64, error) { return 0, errors.New("unavailable") }
+
+	for name, generation := range map[string]func(context.Context) (int64, error){"missing": nil, "failing": failing} {
+		t.Run(name, func(t *testing.T) {
+			// The ETag falls back to a hash of the body.
+			rr := checkNotModified(t, "/api/test", month, nil, unmodifiedData, generation)
+			etag := rr.Header().Get("ETag")
+			if etag == "" {
+				t.Fatal("expected an ETag")
+			}
+
+			rr = checkNotModified(t, "/api/test", month, http.Header{"If-None-Match": {etag}}, unmodifiedData, generation)
+			if rr.Code != http.StatusNotModified {
+				t.Errorf("expected status %d, got %d", http.StatusNotModified, rr.Code)
+			}
+		})
+	}
+}
+
+func TestCheckNotModified_DataVersion(t *testing.T) {
+	month := GetLastCompleteMonth()
+	var version int64
+	var modified time.Time
+	readVersion := func() (int64, time.Time) { return version, modified }
+
+	rr := checkNotModified(t, "/api/test", month, nil, readVersion, nil)
+	etag := rr.Header().Get("ETag")
+
+	version++
+	modified = lastCompleteMonthEnd().Add(36 * time.Hour)
+	rr = checkNotModified(t, "/api/test", month, http.Header{"If-None-Match": {etag}}, readVersion, nil)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d after an import, got %d", http.StatusOK, rr.Code)
+	}
+	if rr.Header().Get("ETag") == etag {
+		t.Error("expected the ETag to change after an import")
+	}
+	if lastModified, want := rr.Header().Get("Last-Modified"), modified.Format(http.TimeFormat); lastModified != want {
+		t.Errorf("expected Last-Modified %q, got %q", want, lastModified)
+	}
+
+	rr = checkNotModified(t, "/api/test", month, http.Header{"If-Modified-Since": {lastCompleteMonthEnd().Format(http.TimeFormat)}}, readVersion, nil)
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected status %d for a copy older than the import, got %d", http.StatusOK, rr.Code)
+	}
+}
+
+func TestCheckNotModified_NoDataVersion(t *testing.T) {
+	// The ETag falls back to a hash of the body.
+	rr := checkNotModified(t, "/api/test", GetLastCompleteMonth(), nil, nil, nil)
+	etag := rr.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("expected an ETag")
+	}
+	if lastModified := rr.Header().Get("Last-Modified"); lastModified != "" {
+		t.Errorf("expected no Last-Modified, got %q", lastModified)
+	}
+
+	rr = checkNotModified(t, "/api/test", GetLastCompleteMonth(), http.Header{"If-None-Match": {etag}}, nil, nil)
+	if rr.Code != http.StatusNotModified {
+		t.Errorf("expected status %d, got %d", http.StatusNotModified, rr.Code)
+	}
+}
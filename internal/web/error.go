@@ -15,14 +15,17 @@ type ProblemDetails struct {
 	Title  string `json:"title"`
 	Status int    `json:"status"`
 	Detail string `json:"detail,omitempty"`
+	// RequestID echoes the X-Request-ID of the response for support requests.
+	RequestID string `json:"requestId,omitempty"`
 }
 
 func WriteError(w http.ResponseWriter, status int, detail string) {
 	problem := ProblemDetails{
-		Type:   "https://tools.ietf.org/html/rfc2616#section-10",
-		Title:  http.StatusText(status),
-		Status: status,
-		Detail: detail,
+		Type:      "https://tools.ietf.org/html/rfc2616#section-10",
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    detail,
+		RequestID: responseRequestID(w),
 	}
 
 	w.Header().Set("Cache-Control", "no-store")
@@ -45,13 +48,14 @@ func InternalServerError(w http.ResponseWriter, detail string) {
 	WriteError(w, http.StatusInternalServerError, detail)
 }
 
-// ServerError logs the error and writes a 500 response, unless the error
-// is due to a canceled context (client disconnect), in which case it's a no-op.
-func ServerError(w http.ResponseWriter, msg string, err error) {
+// ServerError logs the error with the ID of request r and writes a 500
+// response, unless the error is due to a canceled context (client
+// disconnect), in which case it's a no-op.
+func ServerError(w http.ResponseWriter, r *http.Request, msg string, err error) {
 	if IsClientDisconnect(err) {
 		return
 	}
-	slog.Error(msg, "error", err)
+	slog.Error(msg, "error", err, "request_id", RequestID(r.Context()))
 	InternalServerError(w, "internal server error")
 }
 
@@ -57,7 +57,7 @@ func TestErrorResponses(t *testing.T) {
 
 	t.Run("ServerError writes 500 for real errors", func(t *testing.T) {
 		rr := httptest.NewRecorder()
-		ServerError(rr, "db failed", errors.New("connection refused"))
+		ServerError(rr, httptest.NewRequest(http.MethodGet, "/", nil), "db failed", errors.New("connection refused"))
 		if rr.Code != http.StatusInternalServerError {
 			t.Errorf("got %d, want %d", rr.Code, http.StatusInternalServerError)
 		}
@@ -71,7 +71,7 @@ func TestErrorResponses(t *testing.T) {
 
 	t.Run("ServerError is no-op for context.Canceled", func(t *testing.T) {
 		rr := httptest.NewRecorder()
-		ServerError(rr, "should not log", context.Canceled)
+		ServerError(rr, httptest.NewRequest(http.MethodGet, "/", nil), "should not log", context.Canceled)
 		if rr.Code != http.StatusOK {
 			t.Errorf("got %d, want %d (no response written)", rr.Code, http.StatusOK)
 		}
@@ -82,7 +82,7 @@ func TestErrorResponses(t *testing.T) {
 
 	t.Run("ServerError is no-op for wrapped context.Canceled", func(t *testing.T) {
 		rr := httptest.NewRecorder()
-		ServerError(rr, "should not log", fmt.Errorf("query: %w", context.Canceled))
+		ServerError(rr, httptest.NewRequest(http.MethodGet, "/", nil), "should not log", fmt.Errorf("query: %w", context.Canceled))
 		if rr.Code != http.StatusOK {
 			t.Errorf("got %d, want %d (no response written)", rr.Code, http.StatusOK)
 		}
@@ -0,0 +1,93 @@
+package web
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "pkgstatsd_http_requests_total",
+		Help: "HTTP requests by route pattern and status code.",
+	}, []string{"route", "status"})
+	httpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "pkgstatsd_http_request_duration_seconds",
+		Help:    "HTTP request latencies by route pattern.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"route"})
+)
+
+// Metrics counts requests and records their latency per route pattern. It
+// reads the pattern the ServeMux stored in the request, so it has to wrap
+// the mux directly, as the innermost middleware. It also records the pattern
+// for AccessLog and Trace. Requests are recorded even if the handler
+// panics; Recovery responds to those with a 500, so they are counted as one
+// unless the handler wrote a status already.
+func Metrics() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			sw := &statusWriter{ResponseWriter: w}
+			completed := false
+
+			defer func() {
+				recordRoute(r)
+
+				route := r.Pattern
+				if route == "" {
+					route = unmatchedRoute
+				}
+				status := sw.Status()
+				if !completed && sw.status == 0 {
+					status = http.StatusInternalServerError
+				}
+				httpRequests.WithLabelValues(route, strconv.Itoa(status)).Inc()
+				httpRequestDuration.WithLabelValues(route).Observe(time.Since(start).Seconds())
+			}()
+
+			next.ServeHTTP(sw, r)
+			completed = true
+		})
+	}
+}
+
+// statusWriter records the status code and the number of body bytes written
+// to the response.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += n
+	return n, err
+}
+
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Status returns the status code of the response, 200 if the handler wrote
+// none.
+func (w *statusWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
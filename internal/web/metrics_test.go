@@ -0,0 +1,65 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMetrics(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/packages/{name}", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotModified)
+	})
+	handler := Metrics()(mux)
+
+	before := testutil.ToFloat64(httpRequests.WithLabelValues("GET /api/packages/{name}", "304"))
+	unmatchedBefore := testutil.ToFloat64(httpRequests.WithLabelValues(unmatchedRoute, "404"))
+
+	for _, path := range []string{"/api/packages/pacman", "/api/packages/linux", "/unknown"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	if got := testutil.ToFloat64(httpRequests.WithLabelValues("GET /api/packages/{name}", "304")) - before; got != 2 {
+		t.Errorf("expected 2 requests counted for the route pattern, got %v", got)
+	}
+	if got := testutil.ToFloat64(httpRequests.WithLabelValues(unmatchedRoute, "404")) - unmatchedBefore; got != 1 {
+		t.Errorf("expected 1 unmatched request, got %v", got)
+	}
+}
+
+func TestMetrics_Panic(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/panic", func(http.ResponseWriter, *http.Request) {
+		panic("boom")
+	})
+	handler := Chain(mux, Recovery(), Metrics())
+
+	requests := httpRequests.WithLabelValues("GET /api/panic", "500")
+	before := testutil.ToFloat64(requests)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/panic", nil))
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("expected status 500, got %d", rec.Code)
+	}
+	if got := testutil.ToFloat64(requests) - before; got != 1 {
+		t.Errorf("expected the panicking request to be counted as a 500, got %v", got)
+	}
+}
+
+func TestStatusWriter_DefaultStatus(t *testing.T) {
+	sw := &statusWriter{ResponseWriter: httptest.NewRecorder()}
+	if sw.Status() != http.StatusOK {
+		t.Errorf("expected 200 without a write, got %d", sw.Status())
+	}
+
+	_, _ = sw.Write([]byte("ok"))
+	sw.WriteHeader(http.StatusInternalServerError)
+	if sw.Status() != http.StatusOK {
+		t.Errorf("expected the first status to be kept, got %d", sw.Status())
+	}
+}
@@ -28,6 +28,7 @@ func Recovery() Middleware {
 					slog.Error("panic recovered",
 						"error", err,
 						"stack", string(debug.Stack()),
+						"request_id", RequestID(r.Context()),
 					)
 					InternalServerError(w, "internal server error")
 				}
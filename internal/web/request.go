@@ -1,13 +1,17 @@
 package web
 
 import (
+	"bytes"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"hash/fnv"
 	"log/slog"
 	"net/http"
 	"regexp"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -15,6 +19,7 @@ const (
 	DefaultLimit    = 100
 	MaxLimit        = 10000
 	MaxOffset       = 100000
+	MinCountFloor   = 5
 	monthMultiplier = 100
 	minYear         = 2002
 	apiCacheMaxAge  = 5 * time.Minute
@@ -66,24 +71,202 @@ func ParseMonthRange(r *http.Request) (startMonth, endMonth int, err error) {
 
 	// Validate month format (startMonth=0 means "no lower bound")
 	if startMonth != 0 {
-		if err := validateMonth(startMonth, actualMonth); err != nil {
+		if err := ValidateMonth(startMonth, actualMonth); err != nil {
 			return 0, 0, fmt.Errorf("invalid startMonth: %w", err)
 		}
 	}
 
-	if err := validateMonth(endMonth, actualMonth); err != nil {
+	if err := ValidateMonth(endMonth, actualMonth); err != nil {
 		return 0, 0, fmt.Errorf("invalid endMonth: %w", err)
 	}
 
 	return startMonth, endMonth, nil
 }
 
+// ParseMonth parses the single "month" query parameter, defaulting to the
+// last complete month.
+func ParseMonth(r *http.Request) (int, error) {
+	return parseMonthParam(r, "month", GetLastCompleteMonth())
+}
+
+// ParseDiffMonths parses the "from" and "to" query parameters of diff
+// endpoints. to defaults to the last complete month and from to the month
+// before to.
+func ParseDiffMonths(r *http.Request) (from, to int, err error) {
+	to, err = parseMonthParam(r, "to", GetLastCompleteMonth())
+	if err != nil {
+		return 0, 0, err
+	}
+
+	from, err = parseMonthParam(r, "from", OffsetMonth(to, -1))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if from == to {
+		return 0, 0, errors.New("from and to must differ")
+	}
+
+	return from, to, nil
+}
+
+func parseMonthParam(r *http.Request, key string, defaultMonth int) (int, error) {
+	month, err := ParseIntParam(r, key, defaultMonth)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := ValidateMonth(month, GetCurrentMonth()); err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+
+	return month, nil
+}
+
 // SplitYearMonth splits a YYYYMM encoded int into its year and month components.
 func SplitYearMonth(yearMonth int) (int, time.Month) {
 	return yearMonth / monthMultiplier, time.Month(yearMonth % monthMultiplier)
 }
 
-func validateMonth(yearMonth, currentMonth int) error {
+// JoinYearMonth encodes a year and month as a YYYYMM int.
+func JoinYearMonth(year int, month time.Month) int {
+	return year*monthMultiplier + int(month)
+}
+
+// OffsetMonth shifts a YYYYMM encoded int by the given number of months.
+func OffsetMonth(yearMonth, months int) int {
+	year, month := SplitYearMonth(yearMonth)
+	t := time.Date(year, month+time.Month(months), 1, 0, 0, 0, 0, time.UTC)
+	return JoinYearMonth(t.Year(), t.Month())
+}
+
+const (
+	GranularityMonth   = "month"
+	GranularityQuarter = "quarter"
+	GranularityYear    = "year"
+
+	monthsPerQuarter = 3
+	monthsPerYear    = 12
+)
+
+// ParseGranularity reads the granularity parameter of series endpoints,
+// defaulting to one point per month.
+func ParseGranularity(r *http.Request) (string, error) {
+	granularity := r.URL.Query().Get("granularity")
+	switch granularity {
+	case "":
+		return GranularityMonth, nil
+	case GranularityMonth, GranularityQuarter, GranularityYear:
+		return granularity, nil
+	default:
+		return "", fmt.Errorf("granularity must be one of %s, %s or %s", GranularityMonth, GranularityQuarter, GranularityYear)
+	}
+}
+
+const (
+	SmoothMovingAverage = "ma"
+	SmoothExponential   = "ema"
+	MinSmoothingWindow  = 2
+	MaxSmoothingWindow  = 24
+)
+
+// Smoothing selects how series popularity is smoothed: a trailing moving
+// average over Window points or exponential smoothing with a span of Window
+// points. The zero value leaves values untouched.
+type Smoothing struct {
+	Method string
+	Window int
+}
+
+// String returns the smooth parameter value, e.g. "ma6", or "" for none.
+func (s Smoothing) String() string {
+	if s.Method == "" {
+		return ""
+	}
+
+	return s.Method + strconv.Itoa(s.Window)
+}
+
+// ParseSmoothing parses the smooth parameter of series endpoints.
+func ParseSmoothing(r *http.Request) (Smoothing, error) {
+	value := r.URL.Query().Get("smooth")
+	if value == "" {
+		return Smoothing{}, nil
+	}
+
+	var s Smoothing
+	for _, method := range []string{SmoothExponential, SmoothMovingAverage} {
+		if window, ok := strings.CutPrefix(value, method); ok {
+			s.Method = method
+			s.Window, _ = strconv.Atoi(window)
+			break
+		}
+	}
+
+	if s.Method == "" || s.Window < MinSmoothingWindow || s.Window > MaxSmoothingWindow {
+		return Smoothing{}, fmt.Errorf("smooth must be %s<N> or %s<N> with N between %d and %d",
+			SmoothMovingAverage, SmoothExponential, MinSmoothingWindow, MaxSmoothingWindow)
+	}
+
+	return s, nil
+}
+
+// SeriesOptions controls the shape of series endpoints. The zero value
+// returns one unsmoothed point per month.
+type SeriesOptions struct {
+	Granularity string
+	Smoothing   Smoothing
+}
+
+func ParseSeriesOptions(r *http.Request) (SeriesOptions, error) {
+	granularity, err := ParseGranularity(r)
+	if err != nil {
+		return SeriesOptions{}, err
+	}
+
+	smoothing, err := ParseSmoothing(r)
+	if err != nil {
+		return SeriesOptions{}, err
+	}
+
+	return SeriesOptions{Granularity: granularity, Smoothing: smoothing}, nil
+}
+
+// PeriodStart returns the first month of the period containing yearMonth.
+func PeriodStart(yearMonth int, granularity string) int {
+	year, month := SplitYearMonth(yearMonth)
+	switch granularity {
+	case GranularityQuarter:
+		return year*monthMultiplier + int(month) - (int(month)-1)%monthsPerQuarter
+	case GranularityYear:
+		return year*monthMultiplier + 1
+	default:
+		return yearMonth
+	}
+}
+
+// NextPeriod returns the first month of the period following the one
+// containing yearMonth.
+func NextPeriod(yearMonth int, granularity string) int {
+	months := 1
+	switch granularity {
+	case GranularityQuarter:
+		months = monthsPerQuarter
+	case GranularityYear:
+		months = monthsPerYear
+	}
+
+	return OffsetMonth(PeriodStart(yearMonth, granularity), months)
+}
+
+// PeriodEnd returns the last month of the period containing yearMonth.
+func PeriodEnd(yearMonth int, granularity string) int {
+	return OffsetMonth(NextPeriod(yearMonth, granularity), -1)
+}
+
+// ValidateMonth checks that yearMonth is a YYYYMM month between the first
+// year of data and currentMonth.
+func ValidateMonth(yearMonth, currentMonth int) error {
 	year, month := SplitYearMonth(yearMonth)
 
 	if year < minYear {
@@ -128,22 +311,229 @@ func ParsePagination(r *http.Request) (limit, offset int, err error) {
 	return limit, offset, nil
 }
 
-var queryRegexp = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9@:.+_/~-]*$`)
+const (
+	SortPopularity = "popularity"
+	SortName       = "name"
+	SortGrowth     = "growth"
+
+	OrderAsc  = "asc"
+	OrderDesc = "desc"
+
+	MatchPrefix   = "prefix"
+	MatchContains = "contains"
+	MatchGlob     = "glob"
+	MatchFuzzy    = "fuzzy"
+)
+
+// ListOptions controls the ordering and filtering of list endpoints.
+// The zero value sorts by popularity and applies the entity's default
+// minimum count and query matching.
+type ListOptions struct {
+	Sort     string
+	Order    string
+	MinCount int
+	Match    string
+	Cursor   *Cursor
+}
+
+// Cursor is an opaque keyset position in a list ordered by (count, key)
+// or by key alone. It records the ordering it was issued for so it cannot
+// be replayed against a different one.
+type Cursor struct {
+	Sort  string `json:"s"`
+	Order string `json:"o"`
+	Count int    `json:"c,omitempty"`
+	Key   string `json:"k"`
+}
+
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func DecodeCursor(s string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+
+	var c Cursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+
+	return &c, nil
+}
+
+// NextCursor returns the encoded cursor following the given row, or nil when
+// the ordering does not support keyset pagination.
+func (o ListOptions) NextCursor(count int, key string) *string {
+	if o.Sort == SortGrowth {
+		return nil
+	}
+
+	order := OrderAsc
+	if o.Descending() {
+		order = OrderDesc
+	}
+
+	sort := o.Sort
+	if sort == "" {
+		sort = SortPopularity
+	}
+
+	next := Cursor{Sort: sort, Order: order, Count: count, Key: key}.Encode()
+	return &next
+}
+
+// Descending reports whether the list should be ordered descending,
+// falling back to the default direction of the sort field.
+func (o ListOptions) Descending() bool {
+	switch o.Order {
+	case OrderAsc:
+		return false
+	case OrderDesc:
+		return true
+	}
+
+	return o.Sort != SortName
+}
+
+func ParseListOptions(r *http.Request) (ListOptions, error) {
+	var opts ListOptions
+
+	opts.Sort = r.URL.Query().Get("sort")
+	switch opts.Sort {
+	case "":
+		opts.Sort = SortPopularity
+	case SortPopularity, SortName, SortGrowth:
+	default:
+		return ListOptions{}, fmt.Errorf("sort must be one of %s, %s or %s", SortPopularity, SortName, SortGrowth)
+	}
+
+	opts.Order = r.URL.Query().Get("order")
+	switch opts.Order {
+	case "":
+		opts.Order = OrderDesc
+		if opts.Sort == SortName {
+			opts.Order = OrderAsc
+		}
+	case OrderAsc, OrderDesc:
+	default:
+		return ListOptions{}, fmt.Errorf("order must be %s or %s", OrderAsc, OrderDesc)
+	}
+
+	opts.Match = r.URL.Query().Get("match")
+	switch opts.Match {
+	case "", MatchPrefix, MatchContains, MatchGlob, MatchFuzzy:
+	default:
+		return ListOptions{}, fmt.Errorf("match must be one of %s, %s, %s or %s", MatchPrefix, MatchContains, MatchGlob, MatchFuzzy)
+	}
+
+	// minCount=0 (or absent) keeps the entity's default minimum
+	minCount, err := ParseIntParam(r, "minCount", 0)
+	if err != nil {
+		return ListOptions{}, err
+	}
+
+	if minCount != 0 && minCount < MinCountFloor {
+		return ListOptions{}, fmt.Errorf("minCount must be at least %d", MinCountFloor)
+	}
+	opts.MinCount = minCount
+
+	if c := r.URL.Query().Get("cursor"); c != "" {
+		if opts.Cursor, err = DecodeCursor(c); err != nil {
+			return ListOptions{}, err
+		}
+
+		if opts.Sort == SortGrowth {
+			return ListOptions{}, errors.New("cursor is not supported when sorting by growth")
+		}
+
+		if opts.Cursor.Sort != opts.Sort || opts.Cursor.Order != opts.Order {
+			return ListOptions{}, errors.New("cursor does not match sort order")
+		}
+
+		if offset := r.URL.Query().Get("offset"); offset != "" && offset != "0" {
+			return ListOptions{}, errors.New("cursor cannot be combined with offset")
+		}
+	}
+
+	return opts, nil
+}
+
+var (
+	// queryRegexp allows package name characters.
+	queryRegexp = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9@:.+_/~-]*$`)
+	// globQueryRegexp allows the glob wildcards * and ? as well.
+	globQueryRegexp = regexp.MustCompile(`^[a-zA-Z0-9*?][a-zA-Z0-9@:.+_/~*?-]*$`)
+)
 
+// ParseQuery reads the name filter of a list request. The glob wildcards *
+// and ? are only accepted with match=glob; other match modes would take
+// them literally.
 func ParseQuery(r *http.Request) (string, error) {
 	query := r.URL.Query().Get("query")
+	if query == "" {
+		return "", nil
+	}
+
+	if r.URL.Query().Get("match") == MatchGlob {
+		if !globQueryRegexp.MatchString(query) {
+			return "", errors.New("invalid query parameter")
+		}
+		return query, nil
+	}
+
+	if strings.ContainsAny(query, "*?") {
+		return "", fmt.Errorf("wildcards in query require match=%s", MatchGlob)
+	}
+
+	return ParseQueryParam(r, "query")
+}
+
+// ParseQueryParam reads a name filter without wildcards from the given query
+// parameter.
+func ParseQueryParam(r *http.Request, key string) (string, error) {
+	query := r.URL.Query().Get(key)
 	if query != "" && !queryRegexp.MatchString(query) {
-		return "", errors.New("invalid query parameter")
+		return "", fmt.Errorf("invalid %s parameter", key)
 	}
 
 	return query, nil
 }
 
-func WriteEntityJSON(w http.ResponseWriter, v any) {
+// WriteEntityJSON writes v as a cacheable API response with the validators
+// CheckNotModified derived. Without them, the ETag is a hash of the encoded
+// body and there is no Last-Modified. Matching If-None-Match or
+// If-Modified-Since requests get a 304 Not Modified. Range requests are
+// ignored, so clients always get a complete document.
+func WriteEntityJSON(w http.ResponseWriter, r *http.Request, validators Validators, v any) {
+	var body bytes.Buffer
+	if err := json.NewEncoder(&body).Encode(v); err != nil {
+		slog.Error("failed to encode JSON response", "error", err)
+		InternalServerError(w, "internal server error")
+		return
+	}
+
+	if validators.etag == "" {
+		hash := fnv.New64a()
+		_, _ = hash.Write(body.Bytes())
+		validators = Validators{etag: fmt.Sprintf(`W/"%016x"`, hash.Sum64())}
+	}
+
 	w.Header().Set("Content-Type", "application/json")
+	validators.setHeaders(w)
 	setAPICacheControl(w, apiCacheMaxAge)
-	if err := json.NewEncoder(w).Encode(v); err != nil {
-		slog.Error("failed to encode JSON response", "error", err)
-		http.Error(w, "internal server error", http.StatusInternalServerError)
+
+	if (r.Method == http.MethodGet || r.Method == http.MethodHead) && notModified(r, validators) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Length", strconv.Itoa(body.Len()))
+	w.WriteHeader(http.StatusOK)
+	if r.Method != http.MethodHead {
+		_, _ = w.Write(body.Bytes())
 	}
 }
@@ -97,6 +97,133 @@ func TestParsePagination(t *testing.T) {
 	}
 }
 
+func TestParseListOptions(t *testing.T) {
+	tests := []struct {
+		name      string
+		url       string
+		want      ListOptions
+		wantError bool
+	}{
+		{"defaults", "/test", ListOptions{Sort: SortPopularity, Order: OrderDesc}, false},
+		{"name defaults to ascending", "/test?sort=name", ListOptions{Sort: SortName, Order: OrderAsc}, false},
+		{"growth defaults to descending", "/test?sort=growth", ListOptions{Sort: SortGrowth, Order: OrderDesc}, false},
+		{"explicit order", "/test?sort=popularity&order=asc", ListOptions{Sort: SortPopularity, Order: OrderAsc}, false},
+		{"name descending", "/test?sort=name&order=desc", ListOptions{Sort: SortName, Order: OrderDesc}, false},
+		{"minCount at floor", "/test?minCount=5", ListOptions{Sort: SortPopularity, Order: OrderDesc, MinCount: MinCountFloor}, false},
+		{"minCount=0 keeps default", "/test?minCount=0", ListOptions{Sort: SortPopularity, Order: OrderDesc}, false},
+		{"match", "/test?match=fuzzy", ListOptions{Sort: SortPopularity, Order: OrderDesc, Match: MatchFuzzy}, false},
+
+		// Errors
+		{"unknown sort", "/test?sort=count", ListOptions{}, true},
+		{"unknown order", "/test?order=up", ListOptions{}, true},
+		{"minCount below floor", "/test?minCount=4", ListOptions{}, true},
+		{"negative minCount", "/test?minCount=-1", ListOptions{}, true},
+		{"minCount=abc", "/test?minCount=abc", ListOptions{}, true},
+		{"unknown match", "/test?match=regex", ListOptions{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			got, err := ParseListOptions(r)
+			if tt.wantError {
+				if err == nil {
+					t.Error("expected error, got nil")
+				}
+			} else {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				if got != tt.want {
+					t.Errorf("got %+v, want %+v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseListOptions_Cursor(t *testing.T) {
+	popularityCursor := Cursor{Sort: SortPopularity, Order: OrderDesc, Count: 42, Key: "pacman"}.Encode()
+	nameCursor := Cursor{Sort: SortName, Order: OrderAsc, Key: "pacman"}.Encode()
+
+	tests := []struct {
+		name      string
+		url       string
+		want      *Cursor
+		wantError bool
+	}{
+		{"no cursor", "/test", nil, false},
+		{"popularity cursor", "/test?cursor=" + popularityCursor, &Cursor{Sort: SortPopularity, Order: OrderDesc, Count: 42, Key: "pacman"}, false},
+		{"name cursor", "/test?sort=name&cursor=" + nameCursor, &Cursor{Sort: SortName, Order: OrderAsc, Key: "pacman"}, false},
+		{"offset=0 allowed", "/test?offset=0&cursor=" + popularityCursor, &Cursor{Sort: SortPopularity, Order: OrderDesc, Count: 42, Key: "pacman"}, false},
+
+		// Errors
+		{"malformed cursor", "/test?cursor=not-a-cursor", nil, true},
+		{"sort mismatch", "/test?sort=name&cursor=" + popularityCursor, nil, true},
+		{"order mismatch", "/test?order=asc&cursor=" + popularityCursor, nil, true},
+		{"growth sort", "/test?sort=growth&cursor=" + popularityCursor, nil, true},
+		{"combined with offset", "/test?offset=10&cursor=" + popularityCursor, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			got, err := ParseListOptions(r)
+			if tt.wantError {
+				if err == nil {
+					t.Error("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if (got.Cursor == nil) != (tt.want == nil) || (got.Cursor != nil && *got.Cursor != *tt.want) {
+				t.Errorf("cursor: got %+v, want %+v", got.Cursor, tt.want)
+			}
+		})
+	}
+}
+
+func TestListOptionsNextCursor(t *testing.T) {
+	next := ListOptions{}.NextCursor(42, "pacman")
+	if next == nil {
+		t.Fatal("expected cursor, got nil")
+	}
+
+	cursor, err := DecodeCursor(*next)
+	if err != nil {
+		t.Fatalf("decode cursor: %v", err)
+	}
+	want := Cursor{Sort: SortPopularity, Order: OrderDesc, Count: 42, Key: "pacman"}
+	if *cursor != want {
+		t.Errorf("got %+v, want %+v", *cursor, want)
+	}
+
+	if next := (ListOptions{Sort: SortGrowth}).NextCursor(42, "pacman"); next != nil {
+		t.Errorf("expected no cursor for growth sort, got %q", *next)
+	}
+}
+
+func TestListOptionsDescending(t *testing.T) {
+	tests := []struct {
+		opts ListOptions
+		want bool
+	}{
+		{ListOptions{}, true},
+		{ListOptions{Sort: SortName}, false},
+		{ListOptions{Sort: SortGrowth}, true},
+		{ListOptions{Sort: SortName, Order: OrderDesc}, true},
+		{ListOptions{Sort: SortPopularity, Order: OrderAsc}, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.opts.Descending(); got != tt.want {
+			t.Errorf("%+v.Descending() = %v, want %v", tt.opts, got, tt.want)
+		}
+	}
+}
+
 func TestParseQuery(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -118,7 +245,16 @@ func TestParseQuery(t *testing.T) {
 		{"percent wildcard", "/test?query=%25", "", true},
 		{"underscore start", "/test?query=_foo", "", true},
 		{"space", "/test?query=foo+bar", "", true},
-		{"asterisk", "/test?query=foo*", "", true},
+		{"glob asterisk", "/test?query=python-*-git&match=glob", "python-*-git", false},
+		{"glob question mark", "/test?query=?ython&match=glob", "?ython", false},
+		{"glob without wildcards", "/test?query=pacman&match=glob", "pacman", false},
+		{"glob invalid", "/test?query=*+bar&match=glob", "", true},
+		{"asterisk without glob", "/test?query=python-*-git", "", true},
+		{"question mark without glob", "/test?query=?ython", "", true},
+		{"asterisk with prefix", "/test?query=python*&match=prefix", "", true},
+		{"asterisk with contains", "/test?query=*python&match=contains", "", true},
+		{"asterisk with fuzzy", "/test?query=pyth*n&match=fuzzy", "", true},
+		{"bracket", "/test?query=foo[ab]", "", true},
 	}
 
 	for _, tt := range tests {
@@ -141,9 +277,18 @@ func TestParseQuery(t *testing.T) {
 	}
 }
 
+func TestParseQueryParam_Wildcards(t *testing.T) {
+	for _, target := range []string{"/test?q=pac*", "/test?q=pac?an", "/test?q=pac*&match=glob"} {
+		r := httptest.NewRequest(http.MethodGet, target, nil)
+		if _, err := ParseQueryParam(r, "q"); err == nil {
+			t.Errorf("expected an error for %s", target)
+		}
+	}
+}
+
 func TestWriteEntityJSON_CacheControl(t *testing.T) {
 	rr := httptest.NewRecorder()
-	WriteEntityJSON(rr, map[string]string{"key": "value"})
+	WriteEntityJSON(rr, httptest.NewRequest(http.MethodGet, "/api/test", nil), Validators{}, map[string]string{"key": "value"})
 
 	cc := rr.Header().Get("Cache-Control")
 	if !strings.Contains(cc, "max-age=300") {
@@ -157,9 +302,79 @@ func TestWriteEntityJSON_CacheControl(t *testing.T) {
 	}
 }
 
+func TestWriteEntityJSON_ConditionalRequests(t *testing.T) {
+	v := map[string]string{"key": "value"}
+
+	rr := httptest.NewRecorder()
+	WriteEntityJSON(rr, httptest.NewRequest(http.MethodGet, "/api/test", nil), Validators{}, v)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+	etag := rr.Header().Get("ETag")
+	if !strings.HasPrefix(etag, `W/"`) {
+		t.Fatalf("expected weak ETag, got %q", etag)
+	}
+	// Without derived validators, the response may cover the current month.
+	if lastModified := rr.Header().Get("Last-Modified"); lastModified != "" {
+		t.Errorf("expected no Last-Modified, got %q", lastModified)
+	}
+
+	tests := []struct {
+		name   string
+		header string
+		value  string
+		v      any
+		want   int
+	}{
+		{"matching ETag", "If-None-Match", etag, v, http.StatusNotModified},
+		{"changed body", "If-None-Match", etag, map[string]string{"key": "other"}, http.StatusOK},
+		{"other ETag", "If-None-Match", `W/"0"`, v, http.StatusOK},
+		{"modified since", "If-Modified-Since", lastCompleteMonthEnd().Format(http.TimeFormat), v, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/test", nil)
+			req.Header.Set(tt.header, tt.value)
+			rr := httptest.NewRecorder()
+			WriteEntityJSON(rr, req, Validators{}, tt.v)
+
+			if rr.Code != tt.want {
+				t.Fatalf("expected status %d, got %d", tt.want, rr.Code)
+			}
+			if tt.want == http.StatusNotModified {
+				if rr.Body.Len() != 0 {
+					t.Errorf("expected empty body, got %q", rr.Body.String())
+				}
+				if !strings.Contains(rr.Header().Get("Cache-Control"), "s-maxage=") {
+					t.Errorf("expected Cache-Control on 304, got %q", rr.Header().Get("Cache-Control"))
+				}
+			}
+		})
+	}
+}
+
+func TestWriteEntityJSON_IgnoresRange(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/api/test", nil)
+	req.Header.Set("Range", "bytes=0-3")
+	rr := httptest.NewRecorder()
+	WriteEntityJSON(rr, req, Validators{}, map[string]string{"key": "value"})
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+	if rr.Body.String() != "{\"key\":\"value\"}\n" {
+		t.Errorf("expected the complete document, got %q", rr.Body.String())
+	}
+	if rr.Header().Get("Accept-Ranges") != "" {
+		t.Errorf("expected no Accept-Ranges, got %q", rr.Header().Get("Accept-Ranges"))
+	}
+}
+
 func TestWriteEntityJSON_OverridesMiddleware(t *testing.T) {
 	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		WriteEntityJSON(w, map[string]string{"key": "value"})
+		WriteEntityJSON(w, r, Validators{}, map[string]string{"key": "value"})
 	})
 
 	// Wrap with CacheControl middleware (simulates global middleware)
@@ -223,3 +438,195 @@ func TestParseMonthRange(t *testing.T) {
 		})
 	}
 }
+
+func TestParseMonth(t *testing.T) {
+	cm := currentMonth()
+
+	tests := []struct {
+		name      string
+		url       string
+		want      int
+		wantError bool
+	}{
+		{"defaults to last complete month", "/test", cm, false},
+		{"explicit month", "/test?month=202501", 202501, false},
+
+		// Errors
+		{"month=abc", "/test?month=abc", 0, true},
+		{"month=0", "/test?month=0", 0, true},
+		{"invalid month 13", "/test?month=202513", 0, true},
+		{"future month", "/test?month=209912", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			got, err := ParseMonth(r)
+			if tt.wantError {
+				if err == nil {
+					t.Error("expected error, got nil")
+				}
+			} else {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				if got != tt.want {
+					t.Errorf("got %d, want %d", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseDiffMonths(t *testing.T) {
+	cm := currentMonth()
+
+	tests := []struct {
+		name      string
+		url       string
+		wantFrom  int
+		wantTo    int
+		wantError bool
+	}{
+		{"defaults to last two months", "/test", OffsetMonth(cm, -1), cm, false},
+		{"from defaults to month before to", "/test?to=202501", 202412, 202501, false},
+		{"explicit months", "/test?from=202401&to=202501", 202401, 202501, false},
+		{"from after to", "/test?from=202501&to=202401", 202501, 202401, false},
+
+		// Errors
+		{"same month", "/test?from=202501&to=202501", 0, 0, true},
+		{"from=abc", "/test?from=abc", 0, 0, true},
+		{"invalid to", "/test?to=202513", 0, 0, true},
+		{"future from", "/test?from=209912", 0, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			from, to, err := ParseDiffMonths(r)
+			if tt.wantError {
+				if err == nil {
+					t.Error("expected error, got nil")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if from != tt.wantFrom || to != tt.wantTo {
+				t.Errorf("got %d-%d, want %d-%d", from, to, tt.wantFrom, tt.wantTo)
+			}
+		})
+	}
+}
+
+func TestOffsetMonth(t *testing.T) {
+	tests := []struct {
+		yearMonth int
+		months    int
+		want      int
+	}{
+		{202506, 0, 202506},
+		{202506, -1, 202505},
+		{202501, -1, 202412},
+		{202512, 1, 202601},
+		{202503, -15, 202312},
+		{202511, 14, 202701},
+	}
+
+	for _, tt := range tests {
+		if got := OffsetMonth(tt.yearMonth, tt.months); got != tt.want {
+			t.Errorf("OffsetMonth(%d, %d) = %d, want %d", tt.yearMonth, tt.months, got, tt.want)
+		}
+	}
+}
+
+func TestParseGranularity(t *testing.T) {
+	tests := []struct {
+		query   string
+		want    string
+		wantErr bool
+	}{
+		{"", GranularityMonth, false},
+		{"granularity=month", GranularityMonth, false},
+		{"granularity=quarter", GranularityQuarter, false},
+		{"granularity=year", GranularityYear, false},
+		{"granularity=week", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/?"+tt.query, nil)
+			got, err := ParseGranularity(r)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseGranularity() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseGranularity() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSmoothing(t *testing.T) {
+	tests := []struct {
+		query   string
+		want    Smoothing
+		wantErr bool
+	}{
+		{"", Smoothing{}, false},
+		{"smooth=ma3", Smoothing{Method: SmoothMovingAverage, Window: 3}, false},
+		{"smooth=ema12", Smoothing{Method: SmoothExponential, Window: 12}, false},
+		{"smooth=ma24", Smoothing{Method: SmoothMovingAverage, Window: 24}, false},
+		{"smooth=ma1", Smoothing{}, true},
+		{"smooth=ema25", Smoothing{}, true},
+		{"smooth=ma", Smoothing{}, true},
+		{"smooth=median3", Smoothing{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/?"+tt.query, nil)
+			got, err := ParseSmoothing(r)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSmoothing() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseSmoothing() = %+v, want %+v", got, tt.want)
+			}
+			if !tt.wantErr && got.String() != strings.TrimPrefix(tt.query, "smooth=") {
+				t.Errorf("String() = %q, want %q", got.String(), strings.TrimPrefix(tt.query, "smooth="))
+			}
+		})
+	}
+}
+
+func TestPeriods(t *testing.T) {
+	tests := []struct {
+		yearMonth   int
+		granularity string
+		start       int
+		end         int
+		next        int
+	}{
+		{202505, GranularityMonth, 202505, 202505, 202506},
+		{202512, GranularityMonth, 202512, 202512, 202601},
+		{202501, GranularityQuarter, 202501, 202503, 202504},
+		{202505, GranularityQuarter, 202504, 202506, 202507},
+		{202512, GranularityQuarter, 202510, 202512, 202601},
+		{202507, GranularityYear, 202501, 202512, 202601},
+	}
+
+	for _, tt := range tests {
+		if got := PeriodStart(tt.yearMonth, tt.granularity); got != tt.start {
+			t.Errorf("PeriodStart(%d, %s) = %d, want %d", tt.yearMonth, tt.granularity, got, tt.start)
+		}
+		if got := PeriodEnd(tt.yearMonth, tt.granularity); got != tt.end {
+			t.Errorf("PeriodEnd(%d, %s) = %d, want %d", tt.yearMonth, tt.granularity, got, tt.end)
+		}
+		if got := NextPeriod(tt.yearMonth, tt.granularity); got != tt.next {
+			t.Errorf("NextPeriod(%d, %s) = %d, want %d", tt.yearMonth, tt.granularity, got, tt.next)
+		}
+	}
+}
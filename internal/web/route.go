@@ -0,0 +1,45 @@
+package web
+
+import (
+	"context"
+	"net/http"
+)
+
+// unmatchedRoute labels requests that no route pattern matched.
+const unmatchedRoute = "unmatched"
+
+type routeKey struct{}
+
+// routeRecord carries the pattern the ServeMux matched back out to the
+// middlewares that replaced the request with a copy carrying a new context,
+// as the mux only sets Pattern on the request it receives. Metrics, the
+// innermost middleware, records it.
+type routeRecord struct {
+	pattern string
+}
+
+// withRouteRecord returns r with a route record in its context, reusing the
+// one an outer middleware added.
+func withRouteRecord(r *http.Request) (*http.Request, *routeRecord) {
+	if record, ok := r.Context().Value(routeKey{}).(*routeRecord); ok {
+		return r, record
+	}
+
+	record := &routeRecord{}
+	return r.WithContext(context.WithValue(r.Context(), routeKey{}, record)), record
+}
+
+// recordRoute stores the pattern the mux set on r in its route record.
+func recordRoute(r *http.Request) {
+	if record, ok := r.Context().Value(routeKey{}).(*routeRecord); ok {
+		record.pattern = r.Pattern
+	}
+}
+
+// route returns the recorded pattern, or unmatchedRoute.
+func (record *routeRecord) route() string {
+	if record.pattern == "" {
+		return unmatchedRoute
+	}
+	return record.pattern
+}
@@ -0,0 +1,54 @@
+package web
+
+import (
+	"net/http"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"pkgstatsd/internal/tracing"
+)
+
+// Trace starts a server span for every request, continuing a trace
+// propagated in the traceparent header. The span is named after the route
+// pattern recorded by Metrics and marked as failed for 5xx responses.
+func Trace() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+			ctx, span := tracing.Start(ctx, r.Method,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					semconv.HTTPRequestMethodKey.String(r.Method),
+					semconv.URLPath(r.URL.Path),
+				),
+			)
+			defer span.End()
+
+			r, route := withRouteRecord(r.WithContext(ctx))
+			sw := &statusWriter{ResponseWriter: w}
+			next.ServeHTTP(sw, r)
+
+			if route.pattern != "" {
+				span.SetName(spanName(r.Method, route.pattern))
+				span.SetAttributes(semconv.HTTPRoute(route.pattern))
+			}
+			span.SetAttributes(semconv.HTTPResponseStatusCode(sw.Status()))
+			if sw.Status() >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(sw.Status()))
+			}
+		})
+	}
+}
+
+// spanName returns the pattern if it includes the method, or prefixes it.
+func spanName(method, pattern string) string {
+	if strings.Contains(pattern, " ") {
+		return pattern
+	}
+	return method + " " + pattern
+}
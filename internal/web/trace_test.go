@@ -0,0 +1,85 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+
+	recorder := tracetest.NewSpanRecorder()
+	previous, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previous)
+		otel.SetTextMapPropagator(previousPropagator)
+	})
+
+	return recorder
+}
+
+func TestTrace(t *testing.T) {
+	captureLog(t)
+	recorder := recordSpans(t)
+
+	var handlerSpan trace.SpanContext
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/packages/{name}", func(w http.ResponseWriter, r *http.Request) {
+		handlerSpan = trace.SpanContextFromContext(r.Context())
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/packages/pacman", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	Chain(mux, AccessLog(), Trace(), Metrics()).ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	span := spans[0]
+
+	if span.Name() != "GET /api/packages/{name}" {
+		t.Errorf("expected span named after the route, got %q", span.Name())
+	}
+	if span.SpanKind() != trace.SpanKindServer {
+		t.Errorf("expected server span, got %v", span.SpanKind())
+	}
+	if span.Parent().TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("expected propagated trace, got %s", span.Parent().TraceID())
+	}
+	if handlerSpan.SpanID() != span.SpanContext().SpanID() {
+		t.Error("expected the span in the handler context")
+	}
+	if span.Status().Code != codes.Error {
+		t.Errorf("expected error status for a 500, got %v", span.Status().Code)
+	}
+
+	attrs := attribute.NewSet(span.Attributes()...)
+	if v, _ := attrs.Value("http.route"); v.AsString() != "GET /api/packages/{name}" {
+		t.Errorf("expected http.route attribute, got %q", v.AsString())
+	}
+	if v, _ := attrs.Value("http.response.status_code"); v.AsInt64() != http.StatusInternalServerError {
+		t.Errorf("expected status code attribute, got %d", v.AsInt64())
+	}
+}
+
+func TestSpanName(t *testing.T) {
+	if got := spanName(http.MethodGet, "/assets/"); got != "GET /assets/" {
+		t.Errorf("expected method prefix, got %q", got)
+	}
+	if got := spanName(http.MethodPost, "POST /api/submit"); got != "POST /api/submit" {
+		t.Errorf("expected pattern unchanged, got %q", got)
+	}
+}
@@ -2,30 +2,49 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
+	"sync/atomic"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
 	"pkgstatsd/internal/anomalydetection"
 	"pkgstatsd/internal/apidoc"
+	"pkgstatsd/internal/backup"
 	"pkgstatsd/internal/config"
 	"pkgstatsd/internal/countries"
 	"pkgstatsd/internal/database"
+	"pkgstatsd/internal/health"
+	"pkgstatsd/internal/importer"
+	"pkgstatsd/internal/maintenance"
 	"pkgstatsd/internal/mirrors"
 	"pkgstatsd/internal/operatingsystems"
 	"pkgstatsd/internal/osarchitectures"
 	"pkgstatsd/internal/packages"
+	"pkgstatsd/internal/rollup"
 	"pkgstatsd/internal/sitemap"
 	"pkgstatsd/internal/submit"
 	"pkgstatsd/internal/systemarchitectures"
+	"pkgstatsd/internal/tracing"
 	"pkgstatsd/internal/ui"
 	"pkgstatsd/internal/ui/httperror"
 	uilayout "pkgstatsd/internal/ui/layout"
 	"pkgstatsd/internal/web"
 )
 
-const defaultCacheMaxAge = 5 * time.Minute
+const (
+	defaultCacheMaxAge       = 5 * time.Minute
+	resultCacheStatsInterval = time.Hour
+	cacheWarmupRetryInterval = 30 * time.Second
+	dataVersionPollInterval  = time.Minute
+)
+
+var errCachesNotWarm = errors.New("caches are not warmed up")
 
 func main() {
 	cfg, err := config.Load()
@@ -42,35 +61,77 @@ func main() {
 			os.Exit(submit.RunPruneLog(os.Args[2:], cfg))
 		case "analyze-submission-log":
 			os.Exit(submit.RunAnalyzeLog(os.Args[2:], cfg))
+		case "rollup":
+			os.Exit(rollup.Run(os.Args[2:], cfg))
+		case "backup":
+			os.Exit(backup.Run(os.Args[2:], cfg))
+		case "db-maintenance":
+			os.Exit(maintenance.Run(os.Args[2:], cfg))
+		case "migrate":
+			os.Exit(database.RunMigrate(os.Args[2:], cfg))
+		case "import":
+			os.Exit(importer.Run(os.Args[2:], cfg))
 		}
 	}
 
-	if err := run(cfg); err != nil {
+	fs := flag.NewFlagSet("pkgstatsd", flag.ExitOnError)
+	noMigrate := fs.Bool("no-migrate", false, "Do not apply migrations at startup, only check that the schema is current")
+	_ = fs.Parse(os.Args[1:])
+
+	if err := run(cfg, !*noMigrate); err != nil {
 		slog.Error("fatal error", "error", err)
 		os.Exit(1)
 	}
 }
 
-func run(cfg config.Config) error {
+func run(cfg config.Config, migrate bool) error {
 	// Setup logger
 	logger := setupLogger(isDevelopment)
 	slog.SetDefault(logger)
 
+	// Setup tracing
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			slog.Warn("failed to flush traces", "error", err)
+		}
+	}()
+
 	// Initialize database
-	db, err := database.New(cfg.Database)
+	pools, err := database.Open(cfg.Database)
 	if err != nil {
 		return err
 	}
-	defer func() { _ = db.Close() }()
+	defer func() { _ = pools.Close() }()
+
+	if migrate {
+		err = database.Migrate(pools.Writer)
+	} else {
+		err = database.CheckMigrations(context.Background(), pools.Writer)
+	}
+	if err != nil {
+		return fmt.Errorf("migrations: %w", err)
+	}
+
+	// Follow the data version, so imports expire caches and validators
+	if err := database.WatchDataVersion(context.Background(), pools.Reader, dataVersionPollInterval); err != nil {
+		return err
+	}
 
 	// Setup repositories
-	packagesRepo := packages.NewSQLiteRepository(db)
-	countriesRepo := countries.NewSQLiteRepository(db)
-	mirrorsRepo := mirrors.NewSQLiteRepository(db)
-	systemArchRepo := systemarchitectures.NewSQLiteRepository(db)
-	osRepo := operatingsystems.NewSQLiteRepository(db)
-	osArchRepo := osarchitectures.NewSQLiteRepository(db)
-	submitRepo := submit.NewRepository(db)
+	results := database.NewResultCache(cfg.ResultCacheSize)
+	go results.LogStats(resultCacheStatsInterval)
+	database.RegisterMetrics(pools, results)
+	packagesRepo := packages.NewSQLRepository(pools.Reader, results)
+	countriesRepo := countries.NewSQLRepository(pools.Reader, results)
+	mirrorsRepo := mirrors.NewSQLRepository(pools.Reader, results)
+	systemArchRepo := systemarchitectures.NewSQLRepository(pools.Reader, results)
+	osRepo := operatingsystems.NewSQLRepository(pools.Reader, results)
+	osArchRepo := osarchitectures.NewSQLRepository(pools.Reader, results)
+	submitRepo := submit.NewRepository(pools.Writer, results)
 
 	// Setup GeoIP lookup
 	geoip, err := submit.NewMaxMindGeoIP(cfg.GeoIPDatabase)
@@ -84,7 +145,7 @@ func run(cfg config.Config) error {
 	if isDevelopment {
 		rateLimiter = submit.NewInMemoryRateLimiter()
 	} else {
-		rateLimiter = submit.NewSQLiteRateLimiter(db)
+		rateLimiter = submit.NewSQLRateLimiter(pools.Writer)
 	}
 
 	// Parse Vite manifest
@@ -93,15 +154,10 @@ func run(cfg config.Config) error {
 		return err
 	}
 
-	// Warm up caches
-	ctx := context.Background()
-	for _, repo := range []interface{ WarmupCache(context.Context) error }{
-		packagesRepo, countriesRepo, mirrorsRepo, systemArchRepo, osRepo,
-	} {
-		if err := repo.WarmupCache(ctx); err != nil {
-			slog.Warn("failed to warm up cache", "error", err)
-		}
-	}
+	// Warm up caches in the background; the server is not ready until done
+	var cachesWarm atomic.Bool
+	go warmupCaches(context.Background(), &cachesWarm,
+		packagesRepo, countriesRepo, mirrorsRepo, systemArchRepo, osRepo)
 
 	// Setup HTTP routes
 	mux := http.NewServeMux()
@@ -126,19 +182,84 @@ func run(cfg config.Config) error {
 	}
 
 	handler := web.Chain(mux,
+		web.AccessLog(),
+		web.Trace(),
 		web.Recovery(),
+		web.Compress(),
 		web.SecureHeaders(),
 		web.CORS(),
 		ui.LegacyMiddleware,
 		httperror.Middleware(manifest),
 		cacheMiddleware,
+		web.DataVersion(func() (int64, time.Time) {
+			v := database.CurrentDataVersion()
+			return v.Version, v.Modified
+		}),
+		web.WriteGeneration(func(ctx context.Context) (int64, error) {
+			return submit.LastSubmission(ctx, pools.Reader)
+		}),
+		web.Metrics(),
 	)
 
+	// Start admin server
+	if cfg.AdminPort != "" {
+		adminMux := http.NewServeMux()
+		adminMux.Handle("GET /metrics", promhttp.Handler())
+		health.NewHandler(
+			health.Check{Name: "database", Run: pools.Ping},
+			// The check only reads, so it never waits for the single writer
+			// connection held by a long write.
+			health.Check{Name: "migrations", Run: func(ctx context.Context) error {
+				return database.CheckMigrations(ctx, pools.Reader)
+			}},
+			health.Check{Name: "geoip", Run: geoip.Check},
+			health.Check{Name: "caches", Run: func(context.Context) error {
+				if !cachesWarm.Load() {
+					return errCachesNotWarm
+				}
+				return nil
+			}},
+		).RegisterRoutes(adminMux)
+
+		adminServer := web.NewServer(":"+cfg.AdminPort, adminMux)
+		go func() {
+			if err := adminServer.ListenAndServe(); err != nil {
+				slog.Error("admin server failed", "error", err)
+			}
+		}()
+	}
+
 	// Create and start server
 	server := web.NewServer(":"+cfg.Port, handler)
 	return server.ListenAndServe()
 }
 
+type cacheWarmer interface {
+	WarmupCache(ctx context.Context) error
+}
+
+// warmupCaches warms up the caches of repos, retrying the failed ones every
+// cacheWarmupRetryInterval, and sets warm once all succeeded.
+func warmupCaches(ctx context.Context, warm *atomic.Bool, repos ...cacheWarmer) {
+	for {
+		var failed []cacheWarmer
+		for _, repo := range repos {
+			if err := repo.WarmupCache(ctx); err != nil {
+				slog.Warn("failed to warm up cache", "error", err)
+				failed = append(failed, repo)
+			}
+		}
+
+		if len(failed) == 0 {
+			warm.Store(true)
+			return
+		}
+
+		repos = failed
+		time.Sleep(cacheWarmupRetryInterval)
+	}
+}
+
 func setupLogger(isDevelopment bool) *slog.Logger {
 	var handler slog.Handler
 	if isDevelopment {
@@ -1,6 +1,22 @@
+type Granularity = "month" | "quarter" | "year";
+
 interface ChartData {
     labels: number[];
-    datasets: { label: string; data: (number | null)[] }[];
+    granularity?: Granularity;
+    datasets: {
+        label: string;
+        data: (number | null)[];
+        low?: (number | null)[];
+        high?: (number | null)[];
+        borderColor?: string;
+        borderDash?: number[];
+    }[];
+    forecast?: {
+        labels: number[];
+        data: number[];
+        low: number[];
+        high: number[];
+    };
 }
 
 const isSmallScreen = window.matchMedia(
@@ -25,9 +41,63 @@ const colors = [
     "#795548",
 ];
 
-function renderYearMonth(yearMonth: number | string): string {
+function datasetColor(ds: { borderColor?: unknown }, index: number): string {
+    return typeof ds.borderColor === "string"
+        ? ds.borderColor
+        : colors[index % colors.length];
+}
+
+// Appends the forecast as a dashed dataset that continues the first dataset
+// from its last value. Existing datasets are padded with nulls.
+function withForecast(data: ChartData): ChartData {
+    const { forecast } = data;
+    if (!forecast?.labels.length || !data.datasets.length) {
+        return data;
+    }
+
+    const padding = forecast.labels.map(() => null);
+    const history = data.labels.slice(1).map(() => null);
+    const last = data.datasets[0].data[data.labels.length - 1] ?? null;
+    const connect = (values: number[]) => [...history, last, ...values];
+
+    return {
+        ...data,
+        labels: [...data.labels, ...forecast.labels],
+        datasets: [
+            ...data.datasets.map((ds) => ({
+                ...ds,
+                data: [...ds.data, ...padding],
+                low: ds.low && [...ds.low, ...padding],
+                high: ds.high && [...ds.high, ...padding],
+            })),
+            {
+                label: "Forecast",
+                data: connect(forecast.data),
+                low: connect(forecast.low),
+                high: connect(forecast.high),
+                borderColor: colors[0],
+                borderDash: [6, 4],
+            },
+        ],
+    };
+}
+
+function renderPeriod(
+    yearMonth: number | string,
+    granularity: Granularity = "month",
+): string {
     const s = yearMonth.toString();
-    return `${s.substring(0, 4)}-${s.substring(4, 6)}`;
+    const year = s.substring(0, 4);
+    const month = Number(s.substring(4, 6));
+
+    switch (granularity) {
+        case "year":
+            return year;
+        case "quarter":
+            return `${year}-Q${Math.ceil(month / 3)}`;
+        default:
+            return `${year}-${s.substring(4, 6)}`;
+    }
 }
 
 function getTooltipElement(chart: {
@@ -77,56 +147,58 @@ function barTooltipHandler({
     showTooltip(el, value, tooltip.caretX, tooltip.caretY);
 }
 
-function lineTooltipHandler({
-    chart,
-    tooltip,
-}: {
-    chart: { canvas: HTMLCanvasElement };
-    tooltip: {
-        opacity: number;
-        title: string[];
-        dataPoints: {
-            raw: unknown;
-            datasetIndex: number;
-            dataset: { label?: string };
-        }[];
-        caretX: number;
-        caretY: number;
-    };
-}) {
-    const el = getTooltipElement(chart);
-    if (tooltip.opacity === 0) {
-        el.style.opacity = "0";
-        return;
-    }
+function lineTooltipHandler(granularity?: Granularity) {
+    return ({
+        chart,
+        tooltip,
+    }: {
+        chart: { canvas: HTMLCanvasElement };
+        tooltip: {
+            opacity: number;
+            title: string[];
+            dataPoints: {
+                raw: unknown;
+                datasetIndex: number;
+                dataset: { label?: string; borderColor?: unknown };
+            }[];
+            caretX: number;
+            caretY: number;
+        };
+    }) => {
+        const el = getTooltipElement(chart);
+        if (tooltip.opacity === 0) {
+            el.style.opacity = "0";
+            return;
+        }
+
+        const rows = tooltip.dataPoints
+            .map((item) => {
+                const color = datasetColor(item.dataset, item.datasetIndex);
+                return `<tr>
+                    <td style="color:${color}">&#9679;</td>
+                    <td>${item.dataset.label}</td>
+                    <td>${(item.raw as number).toFixed(2)}</td>
+                </tr>`;
+            })
+            .join("");
 
-    const rows = tooltip.dataPoints
-        .map((item) => {
-            const color = colors[item.datasetIndex % colors.length];
-            return `<tr>
-                <td style="color:${color}">&#9679;</td>
-                <td>${item.dataset.label}</td>
-                <td>${(item.raw as number).toFixed(2)}</td>
-            </tr>`;
-        })
-        .join("");
-
-    showTooltip(
-        el,
-        `<div class="chart-tooltip-title">${renderYearMonth(tooltip.title[0])}</div><table>${rows}</table>`,
-        tooltip.caretX,
-        tooltip.caretY,
-    );
+        showTooltip(
+            el,
+            `<div class="chart-tooltip-title">${renderPeriod(tooltip.title[0], granularity)}</div><table>${rows}</table>`,
+            tooltip.caretX,
+            tooltip.caretY,
+        );
+    };
 }
 
 function generateLegendLabels(textColor: string, gridColor: string) {
     return (chart: {
-        data: { datasets: { label?: string }[] };
+        data: { datasets: { label?: string; borderColor?: unknown }[] };
         isDatasetVisible(index: number): boolean;
     }) => {
         return chart.data.datasets.map((ds, i) => {
             const hidden = !chart.isDatasetVisible(i);
-            const color = colors[i % colors.length];
+            const color = datasetColor(ds, i);
             return {
                 text: ds.label ?? "",
                 fontColor: hidden ? gridColor : textColor,
@@ -155,6 +227,74 @@ const legendPaddingPlugin = {
     },
 };
 
+// Draws each visible dataset's confidence interval as a translucent band
+// behind its line, skipping periods without an interval.
+const errorBandPlugin = {
+    id: "errorBand",
+    beforeDatasetsDraw(chart: {
+        ctx: CanvasRenderingContext2D;
+        data: {
+            datasets: {
+                low?: (number | null)[];
+                high?: (number | null)[];
+                borderColor?: unknown;
+            }[];
+        };
+        scales: Record<string, { getPixelForValue(value: number): number }>;
+        isDatasetVisible(index: number): boolean;
+    }) {
+        const { ctx, scales } = chart;
+
+        chart.data.datasets.forEach((ds, i) => {
+            const { low, high } = ds;
+            if (!low || !high || !chart.isDatasetVisible(i)) {
+                return;
+            }
+
+            ctx.save();
+            ctx.fillStyle = datasetColor(ds, i);
+            ctx.globalAlpha = 0.15;
+
+            let start = 0;
+            while (start < low.length) {
+                if (low[start] === null || high[start] === null) {
+                    start++;
+                    continue;
+                }
+
+                let end = start;
+                while (
+                    end + 1 < low.length &&
+                    low[end + 1] !== null &&
+                    high[end + 1] !== null
+                ) {
+                    end++;
+                }
+
+                ctx.beginPath();
+                for (let j = start; j <= end; j++) {
+                    ctx.lineTo(
+                        scales.x.getPixelForValue(j),
+                        scales.y.getPixelForValue(high[j] as number),
+                    );
+                }
+                for (let j = end; j >= start; j--) {
+                    ctx.lineTo(
+                        scales.x.getPixelForValue(j),
+                        scales.y.getPixelForValue(low[j] as number),
+                    );
+                }
+                ctx.closePath();
+                ctx.fill();
+
+                start = end + 1;
+            }
+
+            ctx.restore();
+        });
+    },
+};
+
 class PopularityChart extends HTMLElement {
     connectedCallback() {
         const script = this.querySelector('script[type="application/json"]');
@@ -222,7 +362,10 @@ class PopularityChart extends HTMLElement {
             labels: data.datasets.map((ds) => ds.label),
             datasets: [
                 {
-                    label: renderYearMonth(data.labels[lastIndex]),
+                    label: renderPeriod(
+                        data.labels[lastIndex],
+                        data.granularity,
+                    ),
                     data: data.datasets.map((ds) => ds.data[lastIndex] ?? 0),
                     backgroundColor: colors.slice(0, data.datasets.length),
                 },
@@ -295,8 +438,8 @@ class PopularityChart extends HTMLElement {
 
         new Chart(canvas, {
             type: "line",
-            data,
-            plugins: [legendPaddingPlugin],
+            data: withForecast(data),
+            plugins: [legendPaddingPlugin, errorBandPlugin],
             options: {
                 animation: false,
                 maintainAspectRatio: false,
@@ -311,7 +454,7 @@ class PopularityChart extends HTMLElement {
                             : (a, b) => (b.raw as number) - (a.raw as number),
                         external: isSmallScreen
                             ? undefined
-                            : lineTooltipHandler,
+                            : lineTooltipHandler(data.granularity),
                     },
                     legend: {
                         display: data.datasets.length > 1,
@@ -334,8 +477,9 @@ class PopularityChart extends HTMLElement {
                         border: { color: gridColor },
                         ticks: {
                             callback(val) {
-                                return renderYearMonth(
+                                return renderPeriod(
                                     this.getLabelForValue(val as number),
+                                    data.granularity,
                                 );
                             },
                             color: textColor,
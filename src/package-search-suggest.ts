@@ -26,12 +26,24 @@ if (matchMedia("(pointer: coarse)").matches) {
             return lengthDiff > 1 && isSuggestion(query);
         };
 
-        const fetchSuggestions = async (
+        const fetchPrefixMatches = async (
+            query: string,
+            signal: AbortSignal,
+        ): Promise<string[]> => {
+            const res = await fetch(
+                `/api/packages/suggest?q=${encodeURIComponent(query)}&limit=${SUGGESTION_LIMIT}`,
+                { signal },
+            );
+            const data = await res.json();
+            return data.suggestions;
+        };
+
+        const fetchFuzzyMatches = async (
             query: string,
             signal: AbortSignal,
         ): Promise<string[]> => {
             const res = await fetch(
-                `/api/packages?query=${encodeURIComponent(query)}&limit=${SUGGESTION_LIMIT}`,
+                `/api/packages?query=${encodeURIComponent(query)}&match=fuzzy&limit=${SUGGESTION_LIMIT}`,
                 { signal },
             );
             const data = await res.json();
@@ -40,6 +52,17 @@ if (matchMedia("(pointer: coarse)").matches) {
             );
         };
 
+        // Fall back to fuzzy matching so typos still yield suggestions
+        const fetchSuggestions = async (
+            query: string,
+            signal: AbortSignal,
+        ): Promise<string[]> => {
+            const names = await fetchPrefixMatches(query, signal);
+            return names.length > 0
+                ? names
+                : fetchFuzzyMatches(query, signal);
+        };
+
         const updateDatalist = (names: string[]): void => {
             datalist.replaceChildren(
                 ...names.map((name) => {